@@ -0,0 +1,298 @@
+// Package assets shares textures, fonts, sounds and shader programs by
+// file and parameters, counts their users and frees each one when the last
+// user releases it. In development, Watch reloads the assets whose files
+// change on disk, in place, so a running game picks up edited images,
+// fonts, SND banks and shaders.
+package assets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/veandco/go-sdl2/img"
+	"github.com/veandco/go-sdl2/mix"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+
+	"go-sdl2/leak"
+	"go-sdl2/snd"
+)
+
+// Kind is the type of an asset.
+type Kind int
+
+const (
+	Texture Kind = iota // an image file as a texture of the manager's renderer
+	Font                // a TTF font at a point size
+	Snd                 // a MUGEN SND bank
+	Chunk               // a sound file as a mixer chunk
+	Shader              // a GL program from Path+".vert" and Path+".frag"
+)
+
+var kindNames = [...]string{"texture", "font", "snd", "chunk", "shader"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Key identifies an asset: the same key gives the same asset.
+type Key struct {
+	Kind Kind
+	Path string // cleaned; for shaders the path without extension
+	Size int    // point size of fonts
+}
+
+func (k Key) String() string {
+	if k.Kind == Font {
+		return fmt.Sprintf("%s %s:%d", k.Kind, k.Path, k.Size)
+	}
+	return fmt.Sprintf("%s %s", k.Kind, k.Path)
+}
+
+// files returns the files an asset is made from.
+func (k Key) files() []string {
+	if k.Kind == Shader {
+		return []string{k.Path + ".vert", k.Path + ".frag"}
+	}
+	return []string{k.Path}
+}
+
+// Asset is a loaded asset. A reload replaces its object in place, so keep
+// the *Asset rather than the object it holds.
+type Asset struct {
+	Key
+	Texture *sdl.Texture
+	Font    *ttf.Font
+	Bank    *snd.Bank
+	Chunk   *mix.Chunk
+	Program uint32
+	Version int   // 1 once loaded, one more for every reload
+	Err     error // why the last reload failed, the previous object stays
+
+	refs  int
+	files []string // absolute, for the watcher
+}
+
+// ErrNoShaders is returned for shaders when the manager has no
+// CompileShader.
+var ErrNoShaders = errors.New("assets: no CompileShader set")
+
+// Manager owns the assets. It must be used from the main thread.
+type Manager struct {
+	Renderer *sdl.Renderer
+
+	// CompileShader links a program from vertex and fragment shader source
+	// and DeleteProgram deletes one; GL programs set them to load shaders.
+	CompileShader func(vertex, fragment string) (uint32, error)
+	DeleteProgram func(program uint32)
+
+	assets  map[Key]*Asset
+	watcher *watcher
+}
+
+// New returns a manager creating textures for renderer.
+func New(renderer *sdl.Renderer) *Manager {
+	return &Manager{Renderer: renderer, assets: make(map[Key]*Asset)}
+}
+
+// Get returns the asset of key, loading it on first use, and counts one
+// more user. Every successful Get needs a Release.
+func (m *Manager) Get(key Key) (*Asset, error) {
+	key.Path = filepath.Clean(key.Path)
+	if a, ok := m.assets[key]; ok {
+		a.refs++
+		return a, nil
+	}
+	a := &Asset{Key: key}
+	if err := m.load(a); err != nil {
+		return nil, fmt.Errorf("%v: %w", key, err)
+	}
+	a.Version, a.refs = 1, 1
+	for _, f := range key.files() {
+		if abs, err := filepath.Abs(f); err == nil {
+			a.files = append(a.files, abs)
+		}
+	}
+	m.assets[key] = a
+	if m.watcher != nil {
+		m.watch(a)
+	}
+	return a, nil
+}
+
+// Texture gets an image file as a texture.
+func (m *Manager) Texture(path string) (*Asset, error) { return m.Get(Key{Kind: Texture, Path: path}) }
+
+// Font gets a TTF font at a point size.
+func (m *Manager) Font(path string, size int) (*Asset, error) {
+	return m.Get(Key{Kind: Font, Path: path, Size: size})
+}
+
+// Snd gets an SND bank.
+func (m *Manager) Snd(path string) (*Asset, error) { return m.Get(Key{Kind: Snd, Path: path}) }
+
+// Chunk gets a sound file as a chunk.
+func (m *Manager) Chunk(path string) (*Asset, error) { return m.Get(Key{Kind: Chunk, Path: path}) }
+
+// Shader gets the program of path.vert and path.frag.
+func (m *Manager) Shader(path string) (*Asset, error) { return m.Get(Key{Kind: Shader, Path: path}) }
+
+// load creates the object of an asset from its files and replaces the
+// previous one; on failure the asset is left as it was.
+func (m *Manager) load(a *Asset) error {
+	var tmp Asset
+	var err error
+	switch a.Kind {
+	case Texture:
+		if m.Renderer == nil {
+			return errors.New("assets: no renderer for textures")
+		}
+		tmp.Texture, err = leak.Texture(img.LoadTexture(m.Renderer, a.Path))
+	case Font:
+		tmp.Font, err = leak.Font(ttf.OpenFont(a.Path, a.Size))
+	case Snd:
+		tmp.Bank, err = snd.Load(a.Path)
+	case Chunk:
+		tmp.Chunk, err = leak.Chunk(mix.LoadWAV(a.Path))
+	case Shader:
+		if m.CompileShader == nil {
+			return ErrNoShaders
+		}
+		var vertex, fragment []byte
+		if vertex, err = os.ReadFile(a.Path + ".vert"); err != nil {
+			return err
+		}
+		if fragment, err = os.ReadFile(a.Path + ".frag"); err != nil {
+			return err
+		}
+		tmp.Program, err = m.CompileShader(string(vertex), string(fragment))
+		if err == nil {
+			leak.Track(leak.GLProgram, tmp.Program)
+		}
+	default:
+		err = fmt.Errorf("unknown asset kind %v", a.Kind)
+	}
+	if err != nil {
+		return err
+	}
+	m.free(a)
+	a.Texture, a.Font, a.Bank, a.Chunk, a.Program = tmp.Texture, tmp.Font, tmp.Bank, tmp.Chunk, tmp.Program
+	return nil
+}
+
+// free frees the object of an asset.
+func (m *Manager) free(a *Asset) {
+	leak.DestroyTexture(a.Texture)
+	leak.CloseFont(a.Font)
+	if a.Bank != nil {
+		a.Bank.Free()
+	}
+	leak.FreeChunk(a.Chunk)
+	if a.Program != 0 && m.DeleteProgram != nil {
+		leak.Untrack(leak.GLProgram, a.Program)
+		m.DeleteProgram(a.Program)
+	}
+	a.Texture, a.Font, a.Bank, a.Chunk, a.Program = nil, nil, nil, nil, 0
+}
+
+// Release counts one user less and frees the asset when it was the last.
+func (m *Manager) Release(a *Asset) {
+	if a == nil || a.refs == 0 {
+		return
+	}
+	a.refs--
+	if a.refs == 0 {
+		m.free(a)
+		delete(m.assets, a.Key)
+	}
+}
+
+// Refs returns how many users an asset has.
+func (a *Asset) Refs() int { return a.refs }
+
+// Loaded returns the assets that have users.
+func (m *Manager) Loaded() []*Asset {
+	list := make([]*Asset, 0, len(m.assets))
+	for _, a := range m.assets {
+		list = append(list, a)
+	}
+	return list
+}
+
+// Reload reloads an asset from its files. On failure the asset keeps its
+// object and Err tells why.
+func (m *Manager) Reload(a *Asset) error {
+	if err := m.load(a); err != nil {
+		a.Err = fmt.Errorf("%v: %w", a.Key, err)
+		return a.Err
+	}
+	a.Err = nil
+	a.Version++
+	return nil
+}
+
+// Close frees every asset, whatever its users, and stops watching.
+func (m *Manager) Close() {
+	for key, a := range m.assets {
+		m.free(a)
+		a.refs = 0
+		delete(m.assets, key)
+	}
+	if m.watcher != nil {
+		m.watcher.close()
+		m.watcher = nil
+	}
+}
+
+// Watch starts reloading assets when their files change; Update does the
+// reloading. It needs inotify and so Linux.
+func (m *Manager) Watch() error {
+	if m.watcher != nil {
+		return nil
+	}
+	w, err := newWatcher()
+	if err != nil {
+		return err
+	}
+	m.watcher = w
+	for _, a := range m.assets {
+		m.watch(a)
+	}
+	return nil
+}
+
+func (m *Manager) watch(a *Asset) {
+	for _, f := range a.files {
+		// Editors often save by renaming a new file over the old one, only
+		// the directory sees that
+		m.watcher.add(filepath.Dir(f))
+	}
+}
+
+// Update reloads the assets whose files changed since the last call and
+// returns them, failed reloads included. Call it once per frame.
+func (m *Manager) Update() []*Asset {
+	if m.watcher == nil {
+		return nil
+	}
+	changed := m.watcher.take()
+	if len(changed) == 0 {
+		return nil
+	}
+	var reloaded []*Asset
+	for _, a := range m.assets {
+		for _, f := range a.files {
+			if changed[f] {
+				m.Reload(a)
+				reloaded = append(reloaded, a)
+				break
+			}
+		}
+	}
+	return reloaded
+}
@@ -0,0 +1,100 @@
+package assets
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// watcher collects files written or moved into the watched directories,
+// with inotify. A file changed several times between two takes is there
+// once.
+type watcher struct {
+	fd   int // for InotifyAddWatch, file.Fd would make reads blocking
+	file *os.File
+
+	mu      sync.Mutex
+	dirs    map[string]int32 // directory to watch descriptor
+	wds     map[int32]string
+	changed map[string]bool
+}
+
+func newWatcher() (*watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	w := &watcher{
+		// Non-blocking, so that reads go through the runtime poller and
+		// Close stops them
+		fd:      fd,
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		dirs:    make(map[string]int32),
+		wds:     make(map[int32]string),
+		changed: make(map[string]bool),
+	}
+	go w.read()
+	return w, nil
+}
+
+func (w *watcher) add(dir string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.dirs[dir]; ok {
+		return nil
+	}
+	if w.fd < 0 {
+		return os.ErrClosed
+	}
+	// Only complete writes: IN_MODIFY would come in the middle of a save
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO)
+	if err != nil {
+		return os.NewSyscallError("inotify_add_watch", err)
+	}
+	w.dirs[dir] = int32(wd)
+	w.wds[int32(wd)] = dir
+	return nil
+}
+
+func (w *watcher) read() {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for off := 0; off+syscall.SizeofInotifyEvent <= n; {
+			ev := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[off]))
+			name := buf[off+syscall.SizeofInotifyEvent : off+syscall.SizeofInotifyEvent+int(ev.Len)]
+			off += syscall.SizeofInotifyEvent + int(ev.Len)
+			w.mu.Lock()
+			if dir, ok := w.wds[ev.Wd]; ok && len(name) > 0 {
+				w.changed[filepath.Join(dir, string(bytes.TrimRight(name, "\x00")))] = true
+			}
+			w.mu.Unlock()
+		}
+	}
+}
+
+// take returns the files changed since the last call.
+func (w *watcher) take() map[string]bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.changed) == 0 {
+		return nil
+	}
+	changed := w.changed
+	w.changed = make(map[string]bool)
+	return changed
+}
+
+func (w *watcher) close() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// The number may be handed out again once the file is closed
+	w.fd = -1
+	w.file.Close()
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package assets
+
+import "errors"
+
+type watcher struct{}
+
+func newWatcher() (*watcher, error) {
+	return nil, errors.New("assets: watching files needs inotify (Linux)")
+}
+
+func (w *watcher) add(dir string) error { return nil }
+
+func (w *watcher) take() map[string]bool { return nil }
+
+func (w *watcher) close() {}
@@ -0,0 +1,190 @@
+// Package bus dispatches SDL events to handlers registered by event type,
+// so that the layers of a program (menus, debug overlays, gameplay) each
+// handle their own events instead of sharing one type switch. Handlers run
+// by priority and a handler returning true consumes the event, which lets
+// a UI layer swallow input before the game sees it.
+package bus
+
+import (
+	"sort"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Priorities for common layers; handlers with a higher priority run first.
+const (
+	Overlay  = 200 // debug overlays, consoles
+	UI       = 100 // menus and dialogs
+	Default  = 0
+	Gameplay = -100
+	Fallback = -200 // sees only what nobody consumed
+)
+
+// Handle identifies a registered handler.
+type Handle int
+
+type handler struct {
+	handle   Handle
+	priority int
+	types    []uint32 // nil for every type
+	fn       func(sdl.Event) bool
+}
+
+func (h *handler) wants(typ uint32) bool {
+	if h.types == nil {
+		return true
+	}
+	for _, t := range h.types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Bus holds the handlers. It is used from the thread polling the events;
+// only Push may be called from other goroutines.
+type Bus struct {
+	handlers []*handler
+	next     Handle
+	watches  []sdl.EventWatchHandle
+	user     userEvents
+}
+
+// New returns an empty bus.
+func New() *Bus {
+	return &Bus{}
+}
+
+// On registers fn for the given event types, or for every event when none
+// are given. fn returns true to consume the event. Handlers of the same
+// priority run in the order they were registered.
+func (b *Bus) On(priority int, fn func(sdl.Event) bool, types ...uint32) Handle {
+	b.next++
+	h := &handler{handle: b.next, priority: priority, types: types, fn: fn}
+	if len(types) == 0 {
+		h.types = nil
+	}
+	// A new slice, Dispatch may be iterating over the old one
+	handlers := append(b.handlers[:len(b.handlers):len(b.handlers)], h)
+	sort.SliceStable(handlers, func(i, j int) bool {
+		return handlers[i].priority > handlers[j].priority
+	})
+	b.handlers = handlers
+	return h.handle
+}
+
+// Off removes a handler. It may be called from a handler, the removed
+// handler is skipped for the rest of the event.
+func (b *Bus) Off(handle Handle) {
+	for i, h := range b.handlers {
+		if h.handle == handle {
+			h.fn = nil
+			b.handlers = append(b.handlers[:i:i], b.handlers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Dispatch passes an event to its handlers until one consumes it and
+// reports whether one did.
+func (b *Bus) Dispatch(event sdl.Event) bool {
+	typ := event.GetType()
+	defer b.user.done(event)
+	// A handler registered by another one sees the next event
+	for _, h := range b.handlers {
+		if h.fn != nil && h.wants(typ) && h.fn(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// Poll dispatches every pending event and returns how many there were.
+func (b *Bus) Poll() int {
+	n := 0
+	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+		b.Dispatch(event)
+		n++
+	}
+	return n
+}
+
+// Close removes the event watches added with Watch.
+func (b *Bus) Close() {
+	for _, w := range b.watches {
+		sdl.DelEventWatch(w)
+	}
+	b.watches = nil
+}
+
+// OnQuit registers fn for sdl.QUIT.
+func (b *Bus) OnQuit(priority int, fn func(*sdl.QuitEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.QuitEvent)) }, sdl.QUIT)
+}
+
+// OnWindow registers fn for window events.
+func (b *Bus) OnWindow(priority int, fn func(*sdl.WindowEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.WindowEvent)) }, sdl.WINDOWEVENT)
+}
+
+// OnKey registers fn for key presses and releases.
+func (b *Bus) OnKey(priority int, fn func(*sdl.KeyboardEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.KeyboardEvent)) }, sdl.KEYDOWN, sdl.KEYUP)
+}
+
+// OnText registers fn for typed text.
+func (b *Bus) OnText(priority int, fn func(*sdl.TextInputEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.TextInputEvent)) }, sdl.TEXTINPUT)
+}
+
+// OnMouseButton registers fn for mouse button presses and releases.
+func (b *Bus) OnMouseButton(priority int, fn func(*sdl.MouseButtonEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.MouseButtonEvent)) }, sdl.MOUSEBUTTONDOWN, sdl.MOUSEBUTTONUP)
+}
+
+// OnMouseMotion registers fn for mouse movement.
+func (b *Bus) OnMouseMotion(priority int, fn func(*sdl.MouseMotionEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.MouseMotionEvent)) }, sdl.MOUSEMOTION)
+}
+
+// OnMouseWheel registers fn for the mouse wheel.
+func (b *Bus) OnMouseWheel(priority int, fn func(*sdl.MouseWheelEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.MouseWheelEvent)) }, sdl.MOUSEWHEEL)
+}
+
+// OnJoyButton registers fn for joystick button presses and releases.
+func (b *Bus) OnJoyButton(priority int, fn func(*sdl.JoyButtonEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.JoyButtonEvent)) }, sdl.JOYBUTTONDOWN, sdl.JOYBUTTONUP)
+}
+
+// OnJoyAxis registers fn for joystick axis motion.
+func (b *Bus) OnJoyAxis(priority int, fn func(*sdl.JoyAxisEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.JoyAxisEvent)) }, sdl.JOYAXISMOTION)
+}
+
+// OnJoyHat registers fn for joystick hat motion.
+func (b *Bus) OnJoyHat(priority int, fn func(*sdl.JoyHatEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.JoyHatEvent)) }, sdl.JOYHATMOTION)
+}
+
+// OnJoyDevice registers fn for joysticks being connected and removed.
+func (b *Bus) OnJoyDevice(priority int, fn func(sdl.Event) bool) Handle {
+	return b.On(priority, fn, sdl.JOYDEVICEADDED, sdl.JOYDEVICEREMOVED)
+}
+
+// OnControllerButton registers fn for game controller button presses and
+// releases.
+func (b *Bus) OnControllerButton(priority int, fn func(*sdl.ControllerButtonEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.ControllerButtonEvent)) }, sdl.CONTROLLERBUTTONDOWN, sdl.CONTROLLERBUTTONUP)
+}
+
+// OnControllerAxis registers fn for game controller axis motion.
+func (b *Bus) OnControllerAxis(priority int, fn func(*sdl.ControllerAxisEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.ControllerAxisEvent)) }, sdl.CONTROLLERAXISMOTION)
+}
+
+// OnDrop registers fn for files and text dropped on a window.
+func (b *Bus) OnDrop(priority int, fn func(*sdl.DropEvent) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool { return fn(e.(*sdl.DropEvent)) }, sdl.DROPFILE, sdl.DROPTEXT, sdl.DROPBEGIN, sdl.DROPCOMPLETE)
+}
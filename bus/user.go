@@ -0,0 +1,155 @@
+package bus
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+var (
+	ErrNoEvents = errors.New("no user event types left")      // from Register
+	ErrFiltered = errors.New("event dropped by event filter") // from Push
+	ErrType     = errors.New("event type not registered")     // from Push
+)
+
+// userEvents keeps the payloads of pushed user events until they are
+// dispatched. A Go pointer can't travel in the event's data pointers, so
+// the event's Code carries a key instead. Only the types registered with
+// this bus are looked at, user events pushed by others keep their Code.
+type userEvents struct {
+	mu       sync.Mutex
+	types    map[uint32]string
+	payloads map[userKey]interface{}
+	next     int32
+}
+
+type userKey struct {
+	typ  uint32
+	code int32
+}
+
+// key returns the payload key of an event of a type of this bus. The
+// caller holds mu.
+func (u *userEvents) key(event sdl.Event) (userKey, bool) {
+	e, ok := event.(*sdl.UserEvent)
+	if !ok {
+		return userKey{}, false
+	}
+	if _, ok := u.types[e.Type]; !ok {
+		return userKey{}, false
+	}
+	return userKey{e.Type, e.Code}, true
+}
+
+func (u *userEvents) done(event sdl.Event) {
+	if _, ok := event.(*sdl.UserEvent); !ok {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if key, ok := u.key(event); ok {
+		delete(u.payloads, key)
+	}
+}
+
+// Register reserves a user event type with sdl.RegisterEvents. The name is
+// only for Name.
+func (b *Bus) Register(name string) (uint32, error) {
+	typ := sdl.RegisterEvents(1)
+	if typ == ^uint32(0) {
+		return 0, ErrNoEvents
+	}
+	b.user.mu.Lock()
+	defer b.user.mu.Unlock()
+	if b.user.types == nil {
+		b.user.types = make(map[uint32]string)
+	}
+	b.user.types[typ] = name
+	return typ, nil
+}
+
+// Name returns the name a user event type was registered with.
+func (b *Bus) Name(typ uint32) string {
+	b.user.mu.Lock()
+	defer b.user.mu.Unlock()
+	return b.user.types[typ]
+}
+
+// Push queues a user event of a registered type carrying payload. It may
+// be called from any goroutine; the payload is handed to the OnUser
+// handlers of the bus that dispatches the event. The event's Code is used
+// by the bus.
+func (b *Bus) Push(typ uint32, payload interface{}) error {
+	b.user.mu.Lock()
+	if _, ok := b.user.types[typ]; !ok {
+		b.user.mu.Unlock()
+		return ErrType
+	}
+	if b.user.payloads == nil {
+		b.user.payloads = make(map[userKey]interface{})
+	}
+	b.user.next++
+	key := userKey{typ, b.user.next}
+	b.user.payloads[key] = payload
+	b.user.mu.Unlock()
+
+	filtered, err := sdl.PushEvent(&sdl.UserEvent{Type: typ, Timestamp: sdl.GetTicks(), Code: key.code})
+	if err == nil && filtered {
+		err = ErrFiltered
+	}
+	if err != nil {
+		// It will never be dispatched
+		b.user.mu.Lock()
+		delete(b.user.payloads, key)
+		b.user.mu.Unlock()
+	}
+	return err
+}
+
+// Payload returns the payload of a user event pushed with Push, nil for
+// other events. It is valid until the event has been dispatched.
+func (b *Bus) Payload(event sdl.Event) interface{} {
+	if _, ok := event.(*sdl.UserEvent); !ok {
+		return nil
+	}
+	b.user.mu.Lock()
+	defer b.user.mu.Unlock()
+	key, ok := b.user.key(event)
+	if !ok {
+		return nil
+	}
+	return b.user.payloads[key]
+}
+
+// OnUser registers fn for a user event type, with the payload of Push.
+func (b *Bus) OnUser(priority int, typ uint32, fn func(e *sdl.UserEvent, payload interface{}) bool) Handle {
+	return b.On(priority, func(e sdl.Event) bool {
+		return fn(e.(*sdl.UserEvent), b.Payload(e))
+	}, typ)
+}
+
+// Watch calls fn for every event as it is queued, before the main loop
+// polls it and whether or not a handler consumes it. fn may run on
+// another thread, for events pushed from other goroutines, and while the
+// main loop is blocked, like during a window resize on Windows. Close
+// removes the watches.
+func (b *Bus) Watch(fn func(sdl.Event)) sdl.EventWatchHandle {
+	w := sdl.AddEventWatchFunc(func(e sdl.Event, _ interface{}) bool {
+		fn(e)
+		return true
+	}, nil)
+	b.watches = append(b.watches, w)
+	return w
+}
+
+// Unwatch removes a watch added with Watch.
+func (b *Bus) Unwatch(w sdl.EventWatchHandle) {
+	sdl.DelEventWatch(w)
+	for i, h := range b.watches {
+		if h == w {
+			b.watches = append(b.watches[:i], b.watches[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,345 @@
+// Package eventlog decodes SDL events into structured records and writes
+// them as text or JSON Lines, for inspecting what a device or the window
+// system sends.
+package eventlog
+
+import (
+	"fmt"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/motion"
+)
+
+// Field is one named value of a record.
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Record is a decoded event.
+type Record struct {
+	Time     uint32 // SDL timestamp in milliseconds
+	Delta    uint32 // milliseconds since the previous logged event
+	Type     string // e.g. "JOYBUTTONDOWN"
+	Category string // e.g. "joystick", used by filters
+	Fields   []Field
+}
+
+func (r *Record) add(name string, value interface{}) {
+	r.Fields = append(r.Fields, Field{name, value})
+}
+
+// Field returns the value of the named field, nil when there is none.
+func (r *Record) Field(name string) interface{} {
+	for _, f := range r.Fields {
+		if f.Name == name {
+			return f.Value
+		}
+	}
+	return nil
+}
+
+// eventTypes names the event types with their category. A list rather than
+// a map, as older SDL headers define some of them to the same value.
+var eventTypes = []struct {
+	t        uint32
+	name     string
+	category string
+}{
+	{sdl.QUIT, "QUIT", "app"},
+	{sdl.APP_TERMINATING, "APP_TERMINATING", "app"},
+	{sdl.APP_LOWMEMORY, "APP_LOWMEMORY", "app"},
+	{sdl.APP_WILLENTERBACKGROUND, "APP_WILLENTERBACKGROUND", "app"},
+	{sdl.APP_DIDENTERBACKGROUND, "APP_DIDENTERBACKGROUND", "app"},
+	{sdl.APP_WILLENTERFOREGROUND, "APP_WILLENTERFOREGROUND", "app"},
+	{sdl.APP_DIDENTERFOREGROUND, "APP_DIDENTERFOREGROUND", "app"},
+	{sdl.DISPLAYEVENT, "DISPLAYEVENT", "display"},
+	{sdl.WINDOWEVENT, "WINDOWEVENT", "window"},
+	{sdl.SYSWMEVENT, "SYSWMEVENT", "window"},
+	{sdl.KEYDOWN, "KEYDOWN", "keyboard"},
+	{sdl.KEYUP, "KEYUP", "keyboard"},
+	{sdl.TEXTEDITING, "TEXTEDITING", "text"},
+	{sdl.TEXTINPUT, "TEXTINPUT", "text"},
+	{sdl.KEYMAPCHANGED, "KEYMAPCHANGED", "keyboard"},
+	{sdl.MOUSEMOTION, "MOUSEMOTION", "mouse"},
+	{sdl.MOUSEBUTTONDOWN, "MOUSEBUTTONDOWN", "mouse"},
+	{sdl.MOUSEBUTTONUP, "MOUSEBUTTONUP", "mouse"},
+	{sdl.MOUSEWHEEL, "MOUSEWHEEL", "mouse"},
+	{sdl.JOYAXISMOTION, "JOYAXISMOTION", "joystick"},
+	{sdl.JOYBALLMOTION, "JOYBALLMOTION", "joystick"},
+	{sdl.JOYHATMOTION, "JOYHATMOTION", "joystick"},
+	{sdl.JOYBUTTONDOWN, "JOYBUTTONDOWN", "joystick"},
+	{sdl.JOYBUTTONUP, "JOYBUTTONUP", "joystick"},
+	{sdl.JOYDEVICEADDED, "JOYDEVICEADDED", "joystick"},
+	{sdl.JOYDEVICEREMOVED, "JOYDEVICEREMOVED", "joystick"},
+	{sdl.CONTROLLERAXISMOTION, "CONTROLLERAXISMOTION", "controller"},
+	{sdl.CONTROLLERBUTTONDOWN, "CONTROLLERBUTTONDOWN", "controller"},
+	{sdl.CONTROLLERBUTTONUP, "CONTROLLERBUTTONUP", "controller"},
+	{sdl.CONTROLLERDEVICEADDED, "CONTROLLERDEVICEADDED", "controller"},
+	{sdl.CONTROLLERDEVICEREMOVED, "CONTROLLERDEVICEREMOVED", "controller"},
+	{sdl.CONTROLLERDEVICEREMAPPED, "CONTROLLERDEVICEREMAPPED", "controller"},
+	{motion.ControllerSensorUpdate, "CONTROLLERSENSORUPDATE", "controller"},
+	{sdl.FINGERDOWN, "FINGERDOWN", "touch"},
+	{sdl.FINGERUP, "FINGERUP", "touch"},
+	{sdl.FINGERMOTION, "FINGERMOTION", "touch"},
+	{sdl.DOLLARGESTURE, "DOLLARGESTURE", "touch"},
+	{sdl.DOLLARRECORD, "DOLLARRECORD", "touch"},
+	{sdl.MULTIGESTURE, "MULTIGESTURE", "touch"},
+	{sdl.CLIPBOARDUPDATE, "CLIPBOARDUPDATE", "clipboard"},
+	{sdl.DROPFILE, "DROPFILE", "drop"},
+	{sdl.DROPTEXT, "DROPTEXT", "drop"},
+	{sdl.DROPBEGIN, "DROPBEGIN", "drop"},
+	{sdl.DROPCOMPLETE, "DROPCOMPLETE", "drop"},
+	{sdl.AUDIODEVICEADDED, "AUDIODEVICEADDED", "audio"},
+	{sdl.AUDIODEVICEREMOVED, "AUDIODEVICEREMOVED", "audio"},
+	{sdl.SENSORUPDATE, "SENSORUPDATE", "sensor"},
+	{sdl.RENDER_TARGETS_RESET, "RENDER_TARGETS_RESET", "render"},
+	{sdl.RENDER_DEVICE_RESET, "RENDER_DEVICE_RESET", "render"},
+}
+
+// TypeName returns the name and category of an event type. Types from
+// RegisterEvents are "USEREVENT+n"; types this SDL doesn't know are shown
+// in hex.
+func TypeName(t uint32) (name, category string) {
+	for _, e := range eventTypes {
+		if e.t == t {
+			return e.name, e.category
+		}
+	}
+	if t >= sdl.USEREVENT && t < sdl.LASTEVENT {
+		if t == sdl.USEREVENT {
+			return "USEREVENT", "user"
+		}
+		return fmt.Sprintf("USEREVENT+%d", t-sdl.USEREVENT), "user"
+	}
+	return fmt.Sprintf("0x%x", t), "unknown"
+}
+
+var windowEvents = []struct {
+	id   uint8
+	name string
+}{
+	{sdl.WINDOWEVENT_SHOWN, "SHOWN"},
+	{sdl.WINDOWEVENT_HIDDEN, "HIDDEN"},
+	{sdl.WINDOWEVENT_EXPOSED, "EXPOSED"},
+	{sdl.WINDOWEVENT_MOVED, "MOVED"},
+	{sdl.WINDOWEVENT_RESIZED, "RESIZED"},
+	{sdl.WINDOWEVENT_SIZE_CHANGED, "SIZE_CHANGED"},
+	{sdl.WINDOWEVENT_MINIMIZED, "MINIMIZED"},
+	{sdl.WINDOWEVENT_MAXIMIZED, "MAXIMIZED"},
+	{sdl.WINDOWEVENT_RESTORED, "RESTORED"},
+	{sdl.WINDOWEVENT_ENTER, "ENTER"},
+	{sdl.WINDOWEVENT_LEAVE, "LEAVE"},
+	{sdl.WINDOWEVENT_FOCUS_GAINED, "FOCUS_GAINED"},
+	{sdl.WINDOWEVENT_FOCUS_LOST, "FOCUS_LOST"},
+	{sdl.WINDOWEVENT_CLOSE, "CLOSE"},
+	{sdl.WINDOWEVENT_TAKE_FOCUS, "TAKE_FOCUS"},
+	{sdl.WINDOWEVENT_HIT_TEST, "HIT_TEST"},
+	{sdl.WINDOWEVENT_ICCPROF_CHANGED, "ICCPROF_CHANGED"},
+	{sdl.WINDOWEVENT_DISPLAY_CHANGED, "DISPLAY_CHANGED"},
+}
+
+func windowEventName(id uint8) string {
+	for _, e := range windowEvents {
+		if e.id == id && id != sdl.WINDOWEVENT_NONE {
+			return e.name
+		}
+	}
+	return fmt.Sprintf("%d", id)
+}
+
+// displayEventName names SDL_DisplayEventID, which go-sdl2 doesn't wrap.
+func displayEventName(id uint8) string {
+	switch id {
+	case 1:
+		return "ORIENTATION"
+	case 2:
+		return "CONNECTED"
+	case 3:
+		return "DISCONNECTED"
+	}
+	return fmt.Sprintf("%d", id)
+}
+
+// HatName returns the direction of a hat value, e.g. "RIGHTUP".
+func HatName(v uint8) string {
+	switch v {
+	case sdl.HAT_CENTERED:
+		return "CENTERED"
+	case sdl.HAT_UP:
+		return "UP"
+	case sdl.HAT_RIGHT:
+		return "RIGHT"
+	case sdl.HAT_DOWN:
+		return "DOWN"
+	case sdl.HAT_LEFT:
+		return "LEFT"
+	case sdl.HAT_RIGHTUP:
+		return "RIGHTUP"
+	case sdl.HAT_RIGHTDOWN:
+		return "RIGHTDOWN"
+	case sdl.HAT_LEFTUP:
+		return "LEFTUP"
+	case sdl.HAT_LEFTDOWN:
+		return "LEFTDOWN"
+	}
+	return fmt.Sprintf("%d", v)
+}
+
+func stateName(state uint8) string {
+	if state == sdl.PRESSED {
+		return "pressed"
+	}
+	return "released"
+}
+
+func sensorName(typ sdl.SensorType) string {
+	switch typ {
+	case sdl.SENSOR_ACCEL:
+		return "accel"
+	case sdl.SENSOR_GYRO:
+		return "gyro"
+	}
+	return fmt.Sprint(int(typ))
+}
+
+// Decode turns an event into a record. Delta is left to the logger.
+func Decode(event sdl.Event) Record {
+	r := Record{Time: event.GetTimestamp()}
+	r.Type, r.Category = TypeName(event.GetType())
+	switch t := event.(type) {
+	case *sdl.WindowEvent:
+		r.add("window", t.WindowID)
+		r.add("event", windowEventName(t.Event))
+		switch t.Event {
+		case sdl.WINDOWEVENT_MOVED, sdl.WINDOWEVENT_RESIZED, sdl.WINDOWEVENT_SIZE_CHANGED:
+			r.add("data1", t.Data1)
+			r.add("data2", t.Data2)
+		case sdl.WINDOWEVENT_DISPLAY_CHANGED:
+			r.add("display", t.Data1)
+		}
+	case *sdl.DisplayEvent:
+		r.add("display", t.Display)
+		r.add("event", displayEventName(t.Event))
+		r.add("data1", t.Data1)
+	case *sdl.KeyboardEvent:
+		r.add("window", t.WindowID)
+		r.add("key", sdl.GetKeyName(t.Keysym.Sym))
+		r.add("scancode", sdl.GetScancodeName(t.Keysym.Scancode))
+		r.add("sym", int32(t.Keysym.Sym))
+		r.add("mod", t.Keysym.Mod)
+		r.add("state", stateName(t.State))
+		r.add("repeat", t.Repeat != 0)
+	case *sdl.TextEditingEvent:
+		r.add("window", t.WindowID)
+		r.add("text", t.GetText())
+		r.add("start", t.Start)
+		r.add("length", t.Length)
+	case *sdl.TextInputEvent:
+		r.add("window", t.WindowID)
+		r.add("text", t.GetText())
+	case *sdl.MouseMotionEvent:
+		r.add("window", t.WindowID)
+		r.add("which", t.Which)
+		r.add("buttons", t.State)
+		r.add("x", t.X)
+		r.add("y", t.Y)
+		r.add("xrel", t.XRel)
+		r.add("yrel", t.YRel)
+	case *sdl.MouseButtonEvent:
+		r.add("window", t.WindowID)
+		r.add("which", t.Which)
+		r.add("button", t.Button)
+		r.add("state", stateName(t.State))
+		r.add("clicks", t.Clicks)
+		r.add("x", t.X)
+		r.add("y", t.Y)
+	case *sdl.MouseWheelEvent:
+		r.add("window", t.WindowID)
+		r.add("which", t.Which)
+		r.add("x", t.X)
+		r.add("y", t.Y)
+		r.add("flipped", t.Direction == sdl.MOUSEWHEEL_FLIPPED)
+	case *sdl.JoyAxisEvent:
+		r.add("which", int32(t.Which))
+		r.add("axis", t.Axis)
+		r.add("value", t.Value)
+	case *sdl.JoyBallEvent:
+		r.add("which", int32(t.Which))
+		r.add("ball", t.Ball)
+		r.add("xrel", t.XRel)
+		r.add("yrel", t.YRel)
+	case *sdl.JoyHatEvent:
+		r.add("which", int32(t.Which))
+		r.add("hat", t.Hat)
+		r.add("value", HatName(t.Value))
+	case *sdl.JoyButtonEvent:
+		r.add("which", int32(t.Which))
+		r.add("button", t.Button)
+		r.add("state", stateName(t.State))
+	case *sdl.JoyDeviceAddedEvent:
+		r.add("index", int32(t.Which))
+	case *sdl.JoyDeviceRemovedEvent:
+		r.add("which", int32(t.Which))
+	case *sdl.ControllerAxisEvent:
+		r.add("which", int32(t.Which))
+		r.add("axis", sdl.GameControllerGetStringForAxis(sdl.GameControllerAxis(t.Axis)))
+		r.add("value", t.Value)
+	case *sdl.ControllerButtonEvent:
+		r.add("which", int32(t.Which))
+		r.add("button", sdl.GameControllerGetStringForButton(sdl.GameControllerButton(t.Button)))
+		r.add("state", stateName(t.State))
+	case *sdl.ControllerDeviceEvent:
+		if t.Type == sdl.CONTROLLERDEVICEADDED {
+			r.add("index", int32(t.Which))
+		} else {
+			r.add("which", int32(t.Which))
+		}
+	case *sdl.AudioDeviceEvent:
+		r.add("which", t.Which)
+		r.add("capture", t.IsCapture != 0)
+	case *sdl.TouchFingerEvent:
+		r.add("touch", int64(t.TouchID))
+		r.add("finger", int64(t.FingerID))
+		r.add("x", t.X)
+		r.add("y", t.Y)
+		r.add("dx", t.DX)
+		r.add("dy", t.DY)
+		r.add("pressure", t.Pressure)
+	case *sdl.MultiGestureEvent:
+		r.add("touch", int64(t.TouchID))
+		r.add("fingers", t.NumFingers)
+		r.add("dtheta", t.DTheta)
+		r.add("ddist", t.DDist)
+		r.add("x", t.X)
+		r.add("y", t.Y)
+	case *sdl.DollarGestureEvent:
+		r.add("touch", int64(t.TouchID))
+		r.add("gesture", int64(t.GestureID))
+		r.add("fingers", t.NumFingers)
+		r.add("error", t.Error)
+		r.add("x", t.X)
+		r.add("y", t.Y)
+	case *sdl.DropEvent:
+		r.add("window", t.WindowID)
+		if t.Type == sdl.DROPFILE || t.Type == sdl.DROPTEXT {
+			r.add("file", t.File)
+		}
+	case *sdl.SensorEvent:
+		r.add("which", t.Which)
+		r.add("data", t.Data[:])
+	case *sdl.CommonEvent:
+		if e, ok := motion.Decode(t); ok {
+			r.add("which", e.Which)
+			r.add("sensor", sensorName(e.Sensor))
+			r.add("data", e.Data[:])
+		}
+	case *sdl.UserEvent:
+		r.add("window", t.WindowID)
+		r.add("code", t.Code)
+		r.add("data1", fmt.Sprintf("%p", t.Data1))
+		r.add("data2", fmt.Sprintf("%p", t.Data2))
+	}
+	return r
+}
@@ -0,0 +1,137 @@
+package eventlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		event    sdl.Event
+		typ      string
+		category string
+		fields   []Field
+	}{
+		{&sdl.JoyHatEvent{Type: sdl.JOYHATMOTION, Timestamp: 5, Which: 3, Hat: 1, Value: sdl.HAT_LEFTUP},
+			"JOYHATMOTION", "joystick", []Field{{"which", int32(3)}, {"hat", uint8(1)}, {"value", HatName(sdl.HAT_LEFTUP)}}},
+		{&sdl.JoyButtonEvent{Type: sdl.JOYBUTTONDOWN, Which: 3, Button: 7, State: sdl.PRESSED},
+			"JOYBUTTONDOWN", "joystick", []Field{{"which", int32(3)}, {"button", uint8(7)}, {"state", "pressed"}}},
+		{&sdl.JoyDeviceAddedEvent{Type: sdl.JOYDEVICEADDED, Which: 2},
+			"JOYDEVICEADDED", "joystick", []Field{{"index", int32(2)}}},
+		{&sdl.MouseWheelEvent{Type: sdl.MOUSEWHEEL, WindowID: 1, Y: -1, Direction: sdl.MOUSEWHEEL_FLIPPED},
+			"MOUSEWHEEL", "mouse", []Field{{"window", uint32(1)}, {"which", uint32(0)}, {"x", int32(0)}, {"y", int32(-1)}, {"flipped", true}}},
+		{&sdl.WindowEvent{Type: sdl.WINDOWEVENT, WindowID: 1, Event: sdl.WINDOWEVENT_RESIZED, Data1: 640, Data2: 480},
+			"WINDOWEVENT", "window", []Field{{"window", uint32(1)}, {"event", "RESIZED"}, {"data1", int32(640)}, {"data2", int32(480)}}},
+		{&sdl.QuitEvent{Type: sdl.QUIT}, "QUIT", "app", nil},
+	}
+	for _, tt := range tests {
+		r := Decode(tt.event)
+		if r.Type != tt.typ || r.Category != tt.category || r.Time != tt.event.GetTimestamp() {
+			t.Errorf("%T: type %s, category %s, time %d", tt.event, r.Type, r.Category, r.Time)
+		}
+		if !reflect.DeepEqual(r.Fields, tt.fields) {
+			t.Errorf("%s: fields %v, want %v", tt.typ, r.Fields, tt.fields)
+		}
+	}
+	if r := Decode(&sdl.JoyButtonEvent{Type: sdl.JOYBUTTONUP}); r.Field("state") != "released" || r.Field("value") != nil {
+		t.Errorf("Field: state %v, value %v", r.Field("state"), r.Field("value"))
+	}
+}
+
+// events is a short session: two joystick buttons with axis noise in
+// between, then a mouse click.
+func events() []sdl.Event {
+	return []sdl.Event{
+		&sdl.JoyButtonEvent{Type: sdl.JOYBUTTONDOWN, Timestamp: 100, Button: 1, State: sdl.PRESSED},
+		&sdl.JoyAxisEvent{Type: sdl.JOYAXISMOTION, Timestamp: 110, Value: 1200},
+		&sdl.JoyAxisEvent{Type: sdl.JOYAXISMOTION, Timestamp: 120, Value: -300},
+		&sdl.JoyButtonEvent{Type: sdl.JOYBUTTONUP, Timestamp: 150, Button: 1, State: sdl.RELEASED},
+		&sdl.MouseButtonEvent{Type: sdl.MOUSEBUTTONDOWN, Timestamp: 155, Button: sdl.BUTTON_LEFT, State: sdl.PRESSED, Clicks: 1},
+	}
+}
+
+func TestLoggerJSON(t *testing.T) {
+	var b strings.Builder
+	l := New(&b)
+	l.Format = JSON
+	for _, e := range events() {
+		if err := l.Log(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if l.Count != 5 {
+		t.Errorf("%d events written", l.Count)
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(strings.NewReader(b.String()))
+	for scanner.Scan() {
+		var object map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &object); err != nil {
+			t.Fatalf("line %d: %v", len(lines)+1, err)
+		}
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) != 5 {
+		t.Fatalf("%d lines", len(lines))
+	}
+	// Fields keep their order after the common ones
+	want := `{"time":110,"delta":10,"type":"JOYAXISMOTION","category":"joystick","which":0,"axis":0,"value":1200}`
+	if lines[1] != want {
+		t.Errorf("line 2:\n%s\nwant\n%s", lines[1], want)
+	}
+	if !strings.HasPrefix(lines[0], `{"time":100,"delta":0,`) {
+		t.Errorf("first line %s", lines[0])
+	}
+}
+
+func TestLoggerFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		types  []string
+		deltas []uint32
+	}{
+		{"", []string{"JOYBUTTONDOWN", "JOYAXISMOTION", "JOYAXISMOTION", "JOYBUTTONUP", "MOUSEBUTTONDOWN"}, []uint32{0, 10, 10, 30, 5}},
+		{"joystick,-JOYAXISMOTION", []string{"JOYBUTTONDOWN", "JOYBUTTONUP"}, []uint32{0, 50}},
+		{"-joystick", []string{"MOUSEBUTTONDOWN"}, []uint32{0}},
+		{"mouse, joybuttonup", []string{"JOYBUTTONUP", "MOUSEBUTTONDOWN"}, []uint32{0, 5}},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		l := New(&b)
+		l.Include, l.Exclude = ParseFilter(tt.filter)
+		for _, e := range events() {
+			l.Log(e)
+		}
+		lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+		if len(lines) != len(tt.types) || l.Count != len(tt.types) {
+			t.Errorf("%q: %d lines, count %d:\n%s", tt.filter, len(lines), l.Count, b.String())
+			continue
+		}
+		// The delta counts from the previous event written
+		for i, line := range lines {
+			var time uint32
+			var delta int
+			var typ string
+			if _, err := fmt.Sscanf(line, "[%d ms %d] %s", &time, &delta, &typ); err != nil || typ != tt.types[i] || uint32(delta) != tt.deltas[i] {
+				t.Errorf("%q: line %d is %q, want %s after %d ms", tt.filter, i+1, line, tt.types[i], tt.deltas[i])
+			}
+		}
+	}
+
+	// A timestamp going back gives no delta
+	var b strings.Builder
+	l := New(&b)
+	l.Format = JSON
+	l.Log(&sdl.QuitEvent{Type: sdl.QUIT, Timestamp: 50})
+	l.Log(&sdl.QuitEvent{Type: sdl.QUIT, Timestamp: 20})
+	if !strings.Contains(b.String(), `{"time":20,"delta":0,`) {
+		t.Errorf("timestamp going back:\n%s", b.String())
+	}
+}
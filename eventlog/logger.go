@@ -0,0 +1,143 @@
+package eventlog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Format is the output format of a logger.
+type Format int
+
+const (
+	Text Format = iota // one aligned line per event
+	JSON               // JSON Lines, one object per event
+)
+
+// Logger writes the events that pass its filters.
+type Logger struct {
+	W       io.Writer
+	Format  Format
+	Include []string // type names or categories to log, everything when empty
+	Exclude []string // type names or categories to drop, checked after Include
+	Count   int      // events written
+
+	last    uint32
+	started bool
+}
+
+// New returns a logger writing text to w.
+func New(w io.Writer) *Logger {
+	return &Logger{W: w}
+}
+
+// ParseFilter splits a comma separated list such as "joystick,-MOUSEMOTION"
+// into included and excluded names; a leading minus excludes.
+func ParseFilter(s string) (include, exclude []string) {
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "", name == "-":
+		case strings.HasPrefix(name, "-"):
+			exclude = append(exclude, name[1:])
+		default:
+			include = append(include, name)
+		}
+	}
+	return include, exclude
+}
+
+func matches(list []string, r *Record) bool {
+	for _, name := range list {
+		if strings.EqualFold(name, r.Type) || strings.EqualFold(name, r.Category) {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether a record passes the filters.
+func (l *Logger) Match(r *Record) bool {
+	if len(l.Include) > 0 && !matches(l.Include, r) {
+		return false
+	}
+	return !matches(l.Exclude, r)
+}
+
+// Log decodes and writes an event if it passes the filters.
+func (l *Logger) Log(event sdl.Event) error {
+	r := Decode(event)
+	if !l.Match(&r) {
+		return nil
+	}
+	if l.started && r.Time >= l.last {
+		r.Delta = r.Time - l.last
+	}
+	l.last, l.started = r.Time, true
+	var err error
+	if l.Format == JSON {
+		err = l.writeJSON(&r)
+	} else {
+		err = l.writeText(&r)
+	}
+	if err == nil {
+		l.Count++
+	}
+	return err
+}
+
+func (l *Logger) writeText(r *Record) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%8d ms %+6d] %-22s", r.Time, r.Delta, r.Type)
+	for _, f := range r.Fields {
+		switch v := f.Value.(type) {
+		case string:
+			if v == "" || strings.ContainsAny(v, " \t\"") {
+				fmt.Fprintf(&b, " %s=%q", f.Name, v)
+			} else {
+				fmt.Fprintf(&b, " %s=%s", f.Name, v)
+			}
+		case float32:
+			fmt.Fprintf(&b, " %s=%.3f", f.Name, v)
+		default:
+			fmt.Fprintf(&b, " %s=%v", f.Name, v)
+		}
+	}
+	_, err := io.WriteString(l.W, strings.TrimRight(b.String(), " ")+"\n")
+	return err
+}
+
+// MarshalJSON writes the record as one object with the fields in order.
+func (r Record) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `{"time":%d,"delta":%d,"type":%q,"category":%q`, r.Time, r.Delta, r.Type, r.Category)
+	for _, f := range r.Fields {
+		name, err := json.Marshal(f.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.WriteByte(',')
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+func (l *Logger) writeJSON(r *Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	_, err = l.W.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,260 @@
+package input
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// AxisCalibration holds the measured range of one axis. Sticks rest at
+// Center; triggers rest at Min, so Center equals Min for them.
+type AxisCalibration struct {
+	Min    int16 `json:"min"`
+	Center int16 `json:"center"`
+	Max    int16 `json:"max"`
+}
+
+// DefaultAxisCalibration is the full range SDL reports.
+var DefaultAxisCalibration = AxisCalibration{Min: -32768, Center: 0, Max: 32767}
+
+// Normalize maps a raw value to [-1, 1], 0 at the resting point.
+func (c AxisCalibration) Normalize(v int16) float64 {
+	var f float64
+	if v < c.Center {
+		if c.Center <= c.Min {
+			return 0
+		}
+		f = float64(int(v)-int(c.Center)) / float64(int(c.Center)-int(c.Min))
+	} else {
+		if c.Max <= c.Center {
+			return 0
+		}
+		f = float64(int(v)-int(c.Center)) / float64(int(c.Max)-int(c.Center))
+	}
+	return math.Max(-1, math.Min(1, f))
+}
+
+// Calibration is the per-axis calibration of one device.
+type Calibration struct {
+	Axes map[int]AxisCalibration `json:"axes"`
+}
+
+// Axis returns the calibration of an axis, the full SDL range if unknown.
+func (c *Calibration) Axis(axis int) AxisCalibration {
+	if c == nil {
+		return DefaultAxisCalibration
+	}
+	if ac, ok := c.Axes[axis]; ok {
+		return ac
+	}
+	return DefaultAxisCalibration
+}
+
+// Normalize maps a raw value of axis to [-1, 1].
+func (c *Calibration) Normalize(axis int, v int16) float64 {
+	return c.Axis(axis).Normalize(v)
+}
+
+// Begin starts calibrating an axis from its resting value.
+func (c *Calibration) Begin(axis int, rest int16) {
+	if c.Axes == nil {
+		c.Axes = make(map[int]AxisCalibration)
+	}
+	c.Axes[axis] = AxisCalibration{Min: rest, Center: rest, Max: rest}
+}
+
+// Observe widens the range of an axis being calibrated. An axis Begin
+// wasn't called for rests at the first value observed.
+func (c *Calibration) Observe(axis int, v int16) {
+	ac, ok := c.Axes[axis]
+	if !ok {
+		c.Begin(axis, v)
+		ac = c.Axes[axis]
+	}
+	if v < ac.Min {
+		ac.Min = v
+	}
+	if v > ac.Max {
+		ac.Max = v
+	}
+	c.Axes[axis] = ac
+}
+
+// Calibrations stores device calibrations by joystick GUID.
+type Calibrations map[string]*Calibration
+
+// LoadCalibrations reads calibrations saved with Save. A missing file
+// yields an empty set.
+func LoadCalibrations(filename string) (Calibrations, error) {
+	cs := make(Calibrations)
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return cs, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &cs); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// Save writes the calibrations as JSON.
+func (cs Calibrations) Save(filename string) error {
+	data, err := json.MarshalIndent(cs, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// Deadzone describes how a normalized axis or stick position is shaped.
+type Deadzone struct {
+	Inner  float64 // output is 0 below this magnitude
+	Outer  float64 // output is 1 above this magnitude
+	Radial bool    // apply to the stick magnitude instead of each axis
+	Curve  float64 // response exponent, 1 is linear, 2 gives finer control near the center
+}
+
+// DefaultDeadzone suits most worn handheld sticks.
+var DefaultDeadzone = Deadzone{Inner: 0.15, Outer: 0.95, Radial: true, Curve: 1}
+
+// shape maps a magnitude in [0, 1] through the dead zones and curve.
+func (dz Deadzone) shape(m float64) float64 {
+	outer := dz.Outer
+	if outer <= dz.Inner || outer > 1 {
+		outer = 1
+	}
+	if m <= dz.Inner {
+		return 0
+	}
+	if m >= outer {
+		return 1
+	}
+	m = (m - dz.Inner) / (outer - dz.Inner)
+	if dz.Curve > 0 && dz.Curve != 1 {
+		m = math.Pow(m, dz.Curve)
+	}
+	return m
+}
+
+// Axis applies the dead zones to a single normalized axis such as a trigger.
+func (dz Deadzone) Axis(v float64) float64 {
+	if v < 0 {
+		return -dz.shape(-v)
+	}
+	return dz.shape(v)
+}
+
+// Stick applies the dead zones to a normalized stick position.
+func (dz Deadzone) Stick(x, y float64) (float64, float64) {
+	if !dz.Radial {
+		return dz.Axis(x), dz.Axis(y)
+	}
+	m := math.Hypot(x, y)
+	if m == 0 {
+		return 0, 0
+	}
+	s := dz.shape(math.Min(m, 1)) / m
+	return x * s, y * s
+}
+
+// Hysteresis converts an analog value into a digital direction. A direction
+// turns on at Press and only turns off again below Release, so noise around
+// the threshold does not make it flicker.
+type Hysteresis struct {
+	Press   float64
+	Release float64
+	state   int
+}
+
+// DefaultHysteresis is used when a Stick or Trigger has none set.
+var DefaultHysteresis = Hysteresis{Press: 0.5, Release: 0.35}
+
+// Update feeds a value in [-1, 1] and returns -1, 0 or +1.
+func (h *Hysteresis) Update(v float64) int {
+	switch {
+	case h.state > 0 && v >= h.Release, h.state < 0 && v <= -h.Release:
+		return h.state
+	case v >= h.Press:
+		h.state = 1
+	case v <= -h.Press:
+		h.state = -1
+	default:
+		h.state = 0
+	}
+	return h.state
+}
+
+// State returns the last direction without changing it.
+func (h *Hysteresis) State() int {
+	return h.state
+}
+
+// Stick processes a pair of axes into a shaped position and hat directions,
+// so that an analog stick can drive the same code as the d-pad.
+type Stick struct {
+	X, Y     int // axis indices
+	Deadzone Deadzone
+	Digital  Hysteresis // thresholds for both axes
+
+	x, y Hysteresis
+}
+
+// NewStick returns a stick on axes x and y with the default settings.
+func NewStick(x, y int) *Stick {
+	return &Stick{X: x, Y: y, Deadzone: DefaultDeadzone, Digital: DefaultHysteresis}
+}
+
+// Update processes raw axis values. cal may be nil.
+func (s *Stick) Update(cal *Calibration, rawX, rawY int16) (x, y float64, hat uint8) {
+	x, y = s.Deadzone.Stick(cal.Normalize(s.X, rawX), cal.Normalize(s.Y, rawY))
+	s.x.Press, s.x.Release = s.Digital.Press, s.Digital.Release
+	s.y.Press, s.y.Release = s.Digital.Press, s.Digital.Release
+	switch s.x.Update(x) {
+	case 1:
+		hat |= sdl.HAT_RIGHT
+	case -1:
+		hat |= sdl.HAT_LEFT
+	}
+	// SDL axes grow downwards
+	switch s.y.Update(y) {
+	case 1:
+		hat |= sdl.HAT_DOWN
+	case -1:
+		hat |= sdl.HAT_UP
+	}
+	return x, y, hat
+}
+
+// UpdateDevice processes the stick axes of a registered device.
+func (s *Stick) UpdateDevice(cal *Calibration, d *Device) (x, y float64, hat uint8) {
+	return s.Update(cal, d.Axis(s.X), d.Axis(s.Y))
+}
+
+// Trigger processes an analog trigger into a shaped value and a button.
+type Trigger struct {
+	Axis     int
+	Deadzone Deadzone
+	Digital  Hysteresis
+}
+
+// NewTrigger returns a trigger on axis with the default settings.
+func NewTrigger(axis int) *Trigger {
+	return &Trigger{Axis: axis, Deadzone: Deadzone{Inner: 0.05, Outer: 0.95, Curve: 1}, Digital: DefaultHysteresis}
+}
+
+// Update processes a raw axis value. Without calibration a trigger resting
+// at -32768 is assumed.
+func (t *Trigger) Update(cal *Calibration, raw int16) (value float64, pressed bool) {
+	ac := AxisCalibration{Min: -32768, Center: -32768, Max: 32767}
+	if cal != nil {
+		if c, ok := cal.Axes[t.Axis]; ok {
+			ac = c
+		}
+	}
+	value = t.Deadzone.Axis(math.Max(0, ac.Normalize(raw)))
+	return value, t.Digital.Update(value) > 0
+}
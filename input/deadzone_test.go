@@ -0,0 +1,244 @@
+package input
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestAxisCalibrationNormalize(t *testing.T) {
+	stick := AxisCalibration{Min: -20000, Center: 1000, Max: 21000}
+	trigger := AxisCalibration{Min: -32768, Center: -32768, Max: 32767}
+	tests := []struct {
+		name string
+		cal  AxisCalibration
+		v    int16
+		want float64
+	}{
+		{"default center", DefaultAxisCalibration, 0, 0},
+		{"default min", DefaultAxisCalibration, -32768, -1},
+		{"default max", DefaultAxisCalibration, 32767, 1},
+		{"default half", DefaultAxisCalibration, -16384, -0.5},
+		{"offset center", stick, 1000, 0},
+		{"offset low half", stick, -9500, -0.5},
+		{"offset high half", stick, 11000, 0.5},
+		{"beyond min clamps", stick, -30000, -1},
+		{"beyond max clamps", stick, 30000, 1},
+		{"trigger at rest", trigger, -32768, 0},
+		{"trigger full", trigger, 32767, 1},
+		{"no range below", AxisCalibration{Min: 5, Center: 5, Max: 100}, -100, 0},
+		{"no range above", AxisCalibration{Min: -100, Center: 5, Max: 5}, 100, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.cal.Normalize(tt.v); !near(got, tt.want) {
+			t.Errorf("%s: Normalize(%d) = %v, want %v", tt.name, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestCalibrationObserve(t *testing.T) {
+	var c Calibration
+	c.Begin(0, 100)
+	for _, v := range []int16{-3000, 50, 28000, 200} {
+		c.Observe(0, v)
+	}
+	if got, want := c.Axis(0), (AxisCalibration{Min: -3000, Center: 100, Max: 28000}); got != want {
+		t.Errorf("after Begin: %+v, want %+v", got, want)
+	}
+
+	// Without Begin the first value is the resting point
+	c.Observe(1, -500)
+	c.Observe(1, 9000)
+	if got, want := c.Axis(1), (AxisCalibration{Min: -500, Center: -500, Max: 9000}); got != want {
+		t.Errorf("without Begin: %+v, want %+v", got, want)
+	}
+
+	if got := c.Axis(7); got != DefaultAxisCalibration {
+		t.Errorf("unknown axis: %+v, want the default", got)
+	}
+	var none *Calibration
+	if got := none.Normalize(0, 32767); got != 1 {
+		t.Errorf("nil calibration: %v, want 1", got)
+	}
+}
+
+func TestCalibrationsRoundTrip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "calibration.json")
+	cs, err := LoadCalibrations(filename)
+	if err != nil || len(cs) != 0 {
+		t.Fatalf("missing file: %v, %v; want an empty set", cs, err)
+	}
+	cs["03000000de280000ff11000001000000"] = &Calibration{Axes: map[int]AxisCalibration{
+		0: {Min: -31000, Center: 120, Max: 30500},
+		2: {Min: -32768, Center: -32768, Max: 32767},
+	}}
+	cs["keypad"] = &Calibration{Axes: map[int]AxisCalibration{}}
+	if err := cs.Save(filename); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCalibrations(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(loaded, cs) {
+		t.Errorf("loaded %v, saved %v", loaded, cs)
+	}
+}
+
+func TestDeadzoneAxis(t *testing.T) {
+	linear := Deadzone{Inner: 0.2, Outer: 0.8, Curve: 1}
+	squared := Deadzone{Inner: 0.2, Outer: 0.8, Curve: 2}
+	tests := []struct {
+		name string
+		dz   Deadzone
+		v    float64
+		want float64
+	}{
+		{"inside inner", linear, 0.1, 0},
+		{"at inner", linear, 0.2, 0},
+		{"middle", linear, 0.5, 0.5},
+		{"middle negative", linear, -0.5, -0.5},
+		{"at outer", linear, 0.8, 1},
+		{"beyond outer", linear, 0.95, 1},
+		{"squared quarter", squared, 0.35, 0.0625},
+		{"squared middle", squared, 0.5, 0.25},
+		{"squared negative", squared, -0.5, -0.25},
+		{"squared full", squared, 1, 1},
+		{"zero curve is linear", Deadzone{Inner: 0.2, Outer: 0.8}, 0.5, 0.5},
+		{"bad outer means 1", Deadzone{Inner: 0.5, Outer: 0.2, Curve: 1}, 0.75, 0.5},
+	}
+	for _, tt := range tests {
+		if got := tt.dz.Axis(tt.v); !near(got, tt.want) {
+			t.Errorf("%s: Axis(%v) = %v, want %v", tt.name, tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestDeadzoneStick(t *testing.T) {
+	radial := Deadzone{Inner: 0.2, Outer: 1, Radial: true, Curve: 1}
+	axial := Deadzone{Inner: 0.2, Outer: 1, Curve: 1}
+	d := math.Sqrt(0.5) // 45 degrees at full tilt
+	tests := []struct {
+		name         string
+		dz           Deadzone
+		x, y         float64
+		wantX, wantY float64
+	}{
+		{"radial center", radial, 0, 0, 0, 0},
+		{"radial inside", radial, 0.1, 0.1, 0, 0},
+		{"radial right", radial, 0.6, 0, 0.5, 0},
+		{"radial up", radial, 0, -0.6, 0, -0.5},
+		{"radial diagonal keeps direction", radial, 0.6 * d, 0.6 * d, 0.5 * d, 0.5 * d},
+		{"radial corner clamps", radial, 1, 1, d, d},
+		// Axial zones cut each axis, a diagonal near one axis snaps to it
+		{"axial snaps", axial, 0.6, 0.15, 0.5, 0},
+		{"axial both", axial, 0.6, -0.6, 0.5, -0.5},
+		{"axial corner", axial, 1, 1, 1, 1},
+	}
+	for _, tt := range tests {
+		x, y := tt.dz.Stick(tt.x, tt.y)
+		if !near(x, tt.wantX) || !near(y, tt.wantY) {
+			t.Errorf("%s: Stick(%v, %v) = %v, %v, want %v, %v", tt.name, tt.x, tt.y, x, y, tt.wantX, tt.wantY)
+		}
+	}
+}
+
+func TestHysteresis(t *testing.T) {
+	h := Hysteresis{Press: 0.5, Release: 0.3}
+	steps := []struct {
+		v    float64
+		want int
+	}{
+		{0, 0},
+		{0.45, 0},  // below Press
+		{0.5, 1},   // on
+		{0.35, 1},  // above Release stays on
+		{0.3, 1},   // at Release stays on
+		{0.29, 0},  // off
+		{0.45, 0},  // needs Press again
+		{-0.6, -1}, // straight to the other side
+		{-0.4, -1},
+		{0.6, 1}, // and back
+		{-0.1, 0},
+	}
+	for i, s := range steps {
+		if got := h.Update(s.v); got != s.want {
+			t.Errorf("step %d: Update(%v) = %d, want %d", i, s.v, got, s.want)
+		}
+		if h.State() != s.want {
+			t.Errorf("step %d: State() = %d, want %d", i, h.State(), s.want)
+		}
+	}
+}
+
+func TestStickUpdate(t *testing.T) {
+	s := NewStick(0, 1)
+	s.Deadzone = Deadzone{Inner: 0.1, Outer: 1, Radial: true, Curve: 1}
+	s.Digital = Hysteresis{Press: 0.5, Release: 0.3}
+	steps := []struct {
+		x, y int16
+		hat  uint8
+	}{
+		{0, 0, sdl.HAT_CENTERED},
+		{32767, 0, sdl.HAT_RIGHT},
+		{14000, 0, sdl.HAT_RIGHT}, // 0.38 after the dead zone, above Release
+		{8000, 0, sdl.HAT_CENTERED},
+		{0, -32768, sdl.HAT_UP},
+		{-23170, 23170, sdl.HAT_LEFT | sdl.HAT_DOWN},
+		{0, 0, sdl.HAT_CENTERED},
+	}
+	for i, st := range steps {
+		_, _, hat := s.Update(nil, st.x, st.y)
+		if hat != st.hat {
+			t.Errorf("step %d: Update(%d, %d) hat = %#x, want %#x", i, st.x, st.y, hat, st.hat)
+		}
+	}
+
+	// A calibrated stick resting off center reads 0 there
+	cal := &Calibration{Axes: map[int]AxisCalibration{
+		0: {Min: -30000, Center: 2000, Max: 30000},
+		1: {Min: -30000, Center: -1500, Max: 30000},
+	}}
+	if x, y, hat := s.Update(cal, 2000, -1500); x != 0 || y != 0 || hat != sdl.HAT_CENTERED {
+		t.Errorf("calibrated rest: %v, %v, %#x, want centered", x, y, hat)
+	}
+}
+
+func TestTriggerUpdate(t *testing.T) {
+	tr := NewTrigger(2)
+	tr.Deadzone = Deadzone{Inner: 0.1, Outer: 0.9, Curve: 1}
+	tr.Digital = Hysteresis{Press: 0.5, Release: 0.3}
+	steps := []struct {
+		raw     int16
+		value   float64
+		pressed bool
+	}{
+		{-32768, 0, false},
+		{0, 0.5, true},        // half way, 0.5 normalized
+		{-9830, 0.3125, true}, // 0.35 normalized, held above Release
+		{-16384, 0.1875, false},
+		{32767, 1, true},
+	}
+	for i, st := range steps {
+		value, pressed := tr.Update(nil, st.raw)
+		if math.Abs(value-st.value) > 1e-4 || pressed != st.pressed {
+			t.Errorf("step %d: Update(%d) = %v, %v, want %v, %v", i, st.raw, value, pressed, st.value, st.pressed)
+		}
+	}
+
+	// A trigger calibrated to rest at 0, like some drivers report
+	cal := &Calibration{Axes: map[int]AxisCalibration{2: {Min: 0, Center: 0, Max: 32767}}}
+	if value, _ := tr.Update(cal, 0); value != 0 {
+		t.Errorf("calibrated rest: %v, want 0", value)
+	}
+	if value, _ := tr.Update(cal, -200); value != 0 {
+		t.Errorf("below rest: %v, want 0", value)
+	}
+}
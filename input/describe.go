@@ -0,0 +1,84 @@
+package input
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Description is everything known about a device, for bug reports and
+// for writing a profile or a mapping for it.
+type Description struct {
+	Name           string         `json:"name"`
+	GUID           string         `json:"guid"`
+	Vendor         int            `json:"vendor,omitempty"`
+	Product        int            `json:"product,omitempty"`
+	ProductVersion int            `json:"product_version,omitempty"`
+	Type           string         `json:"type"`
+	Buttons        int            `json:"buttons"`
+	Axes           int            `json:"axes"`
+	Hats           int            `json:"hats"`
+	Balls          int            `json:"balls"`
+	Power          string         `json:"power"`
+	Profile        string         `json:"profile"`
+	ButtonNames    map[int]string `json:"button_names,omitempty"`
+	AxisNames      map[int]string `json:"axis_names,omitempty"`
+	Mapping        string         `json:"mapping,omitempty"`     // SDL GameController mapping, if SDL has one
+	AxisValues     []int16        `json:"axis_values,omitempty"` // when described, normally at rest
+}
+
+// joystickTypes names sdl.JoystickType values.
+var joystickTypes = map[sdl.JoystickType]string{
+	sdl.JOYSTICK_TYPE_GAMECONTROLLER: "game controller",
+	sdl.JOYSTICK_TYPE_WHEEL:          "wheel",
+	sdl.JOYSTICK_TYPE_ARCADE_STICK:   "arcade stick",
+	sdl.JOYSTICK_TYPE_FLIGHT_STICK:   "flight stick",
+	sdl.JOYSTICK_TYPE_DANCE_PAD:      "dance pad",
+	sdl.JOYSTICK_TYPE_GUITAR:         "guitar",
+	sdl.JOYSTICK_TYPE_DRUM_KIT:       "drum kit",
+	sdl.JOYSTICK_TYPE_ARCADE_PAD:     "arcade pad",
+	sdl.JOYSTICK_TYPE_THROTTLE:       "throttle",
+}
+
+// Describe returns the description of the device. Devices recreated from a
+// recording have no joystick, their USB IDs and ball count stay 0.
+func (d *Device) Describe() Description {
+	desc := Description{
+		Name:       d.Name,
+		GUID:       d.GUID,
+		Type:       "unknown",
+		Buttons:    d.NumButtons(),
+		Axes:       d.NumAxes(),
+		Hats:       d.NumHats(),
+		Power:      PowerName(d.PowerLevel()),
+		AxisValues: d.Snapshot().Axes,
+	}
+	if d.Profile != nil {
+		desc.Profile = d.Profile.Name
+		desc.ButtonNames = d.Profile.Buttons
+		desc.AxisNames = d.Profile.Axes
+	}
+	if d.Joystick != nil {
+		desc.Vendor = d.Joystick.Vendor()
+		desc.Product = d.Joystick.Product()
+		desc.ProductVersion = d.Joystick.ProductVersion()
+		desc.Balls = d.Joystick.NumBalls()
+		if name, ok := joystickTypes[d.Joystick.Type()]; ok {
+			desc.Type = name
+		}
+	}
+	if d.GUID != "" {
+		desc.Mapping = sdl.GameControllerMappingForGUID(sdl.JoystickGetGUIDFromString(d.GUID))
+	}
+	return desc
+}
+
+// Save writes the description as indented JSON.
+func (desc Description) Save(filename string) error {
+	data, err := json.MarshalIndent(desc, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,406 @@
+package input
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Action is a system action started by a hotkey.
+type Action string
+
+const (
+	ActionQuit       Action = "quit"
+	ActionPause      Action = "pause"
+	ActionScreenshot Action = "screenshot"
+	ActionFPS        Action = "fps"
+	ActionVolumeUp   Action = "volume_up"
+	ActionVolumeDown Action = "volume_down"
+)
+
+// Hotkey starts an action when its buttons or keys are held together. A
+// single button with HoldMS is a long press.
+type Hotkey struct {
+	Action  Action   `json:"action"`
+	Buttons []string `json:"buttons,omitempty"` // profile button names, or UP DOWN LEFT RIGHT of the first hat
+	Keys    []string `json:"keys,omitempty"`    // SDL key names such as "Escape" or "Left Ctrl"
+	HoldMS  uint32   `json:"hold_ms,omitempty"` // how long they have to be held, 0 fires at once
+}
+
+// DefaultButtonHotkeys are used for joysticks whose profile has none.
+// SELECT+START quits through the profile's exit chord.
+func DefaultButtonHotkeys() []Hotkey {
+	return []Hotkey{
+		{Action: ActionPause, Buttons: []string{"START"}, HoldMS: 1000},
+		{Action: ActionScreenshot, Buttons: []string{"SELECT", "L1"}},
+		{Action: ActionFPS, Buttons: []string{"SELECT", "R1"}},
+		{Action: ActionVolumeUp, Buttons: []string{"SELECT", "UP"}},
+		{Action: ActionVolumeDown, Buttons: []string{"SELECT", "DOWN"}},
+	}
+}
+
+// DefaultKeyHotkeys are the keyboard hotkeys of NewHotkeys.
+func DefaultKeyHotkeys() []Hotkey {
+	return []Hotkey{
+		{Action: ActionQuit, Keys: []string{"Escape"}},
+		{Action: ActionPause, Keys: []string{"Pause"}},
+		{Action: ActionScreenshot, Keys: []string{"F12"}},
+		{Action: ActionFPS, Keys: []string{"F3"}},
+		{Action: ActionVolumeUp, Keys: []string{"Left Ctrl", "Up"}},
+		{Action: ActionVolumeDown, Keys: []string{"Left Ctrl", "Down"}},
+	}
+}
+
+// hatDirs are the names of the first hat's directions in Hotkey.Buttons.
+var hatDirs = map[string]uint8{"UP": sdl.HAT_UP, "DOWN": sdl.HAT_DOWN, "LEFT": sdl.HAT_LEFT, "RIGHT": sdl.HAT_RIGHT}
+
+// resolved is a hotkey turned into controls of one device.
+type resolved struct {
+	action   Action
+	controls []Control
+	holdMS   uint32
+}
+
+func (r *resolved) has(c Control) bool {
+	for _, o := range r.controls {
+		if o == c {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveButtons turns hotkeys into controls of a joystick with profile p.
+// Hotkeys naming a button the profile doesn't have are left out.
+func resolveButtons(hotkeys []Hotkey, p *Profile) []resolved {
+	var list []resolved
+	for _, hk := range hotkeys {
+		r := resolved{action: hk.Action, holdMS: hk.HoldMS}
+		for _, name := range hk.Buttons {
+			if b := p.Button(name); b >= 0 {
+				r.controls = append(r.controls, JoyButton(b))
+			} else if dir, ok := hatDirs[name]; ok {
+				r.controls = append(r.controls, JoyHat(0, dir))
+			} else {
+				r.controls = nil
+				break
+			}
+		}
+		if len(r.controls) > 0 {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// resolveKeys turns hotkeys into keyboard controls. Unknown key names
+// leave the hotkey out.
+func resolveKeys(hotkeys []Hotkey) []resolved {
+	var list []resolved
+	for _, hk := range hotkeys {
+		r := resolved{action: hk.Action, holdMS: hk.HoldMS}
+		for _, name := range hk.Keys {
+			code := sdl.GetScancodeFromName(name)
+			if code == sdl.SCANCODE_UNKNOWN {
+				r.controls = nil
+				break
+			}
+			r.controls = append(r.controls, Key(code))
+		}
+		if len(r.controls) > 0 {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// source is the keyboard or one joystick.
+type source struct {
+	hotkeys []resolved
+	held    map[Control]bool // physically down
+	passed  map[Control]bool // down as far as the game knows
+	swallow map[Control]bool // press kept from the game, keep its release too
+	pending map[Control]bool // press held back until the hotkey fires or is let go
+	since   map[int]uint32   // completion time of hotkeys waiting for HoldMS
+	fired   map[int]bool     // hotkeys that fired and are still held
+	hats    map[uint8]uint8  // last physical value of every hat
+	sent    map[uint8]uint8  // last value of every hat the game got
+}
+
+func newSource(hotkeys []resolved) *source {
+	return &source{
+		hotkeys: hotkeys,
+		held:    make(map[Control]bool),
+		passed:  make(map[Control]bool),
+		swallow: make(map[Control]bool),
+		pending: make(map[Control]bool),
+		since:   make(map[int]uint32),
+		fired:   make(map[int]bool),
+		hats:    make(map[uint8]uint8),
+		sent:    make(map[uint8]uint8),
+	}
+}
+
+// Hotkeys turns chords, long presses and key combos into actions and keeps
+// the presses that make them from the game. A press completing a hotkey
+// is swallowed together with its release; if the hotkey has a hold time
+// the press is held back and given to the game after all when it is let
+// go too early. The buttons pressed before the completing one, such as
+// SELECT of SELECT+START, have already reached the game.
+//
+// Joystick hotkeys come from the device's profile, or Buttons for
+// profiles without any; the profile's exit chord always quits.
+type Hotkeys struct {
+	Registry *Registry
+	Buttons  []Hotkey // joystick hotkeys for profiles without Hotkeys
+
+	keyboard *source
+	devices  map[sdl.JoystickID]*source
+	actions  []Action
+}
+
+// NewHotkeys returns the default keyboard and joystick hotkeys. The
+// registry must see every event before the hotkeys do; without one,
+// joysticks get the Buttons hotkeys.
+func NewHotkeys(registry *Registry) *Hotkeys {
+	h := &Hotkeys{
+		Registry: registry,
+		Buttons:  DefaultButtonHotkeys(),
+		devices:  make(map[sdl.JoystickID]*source),
+	}
+	h.SetKeys(DefaultKeyHotkeys())
+	return h
+}
+
+// SetKeys replaces the keyboard hotkeys.
+func (h *Hotkeys) SetKeys(hotkeys []Hotkey) {
+	h.keyboard = newSource(resolveKeys(hotkeys))
+}
+
+// device returns the source of joystick id, resolving its hotkeys from
+// the profile the first time.
+func (h *Hotkeys) device(id sdl.JoystickID) *source {
+	if s, ok := h.devices[id]; ok {
+		return s
+	}
+	profile := GenericProfile()
+	if h.Registry != nil {
+		if d := h.Registry.Device(id); d != nil && d.Profile != nil {
+			profile = d.Profile
+		}
+	}
+	hotkeys := profile.Hotkeys
+	if hotkeys == nil {
+		hotkeys = h.Buttons
+	}
+	list := resolveButtons(hotkeys, profile)
+	if len(profile.ExitChord) > 0 {
+		quit := resolved{action: ActionQuit}
+		for _, b := range profile.ExitChord {
+			quit.controls = append(quit.controls, JoyButton(b))
+		}
+		list = append(list, quit)
+	}
+	s := newSource(list)
+	h.devices[id] = s
+	return s
+}
+
+// press handles control c going down. emit builds the event telling the
+// game about a change of s.passed.
+func (h *Hotkeys) press(s *source, c Control, timestamp uint32, emit func(c Control) sdl.Event) []sdl.Event {
+	s.held[c] = true
+	consumed := false
+	for i := range s.hotkeys {
+		hk := &s.hotkeys[i]
+		if !hk.has(c) || !s.allHeld(hk) {
+			continue
+		}
+		consumed = true
+		if hk.holdMS == 0 {
+			h.actions = append(h.actions, hk.action)
+			s.fired[i] = true
+			s.swallow[c] = true
+		} else {
+			s.since[i] = timestamp
+			s.pending[c] = true
+		}
+	}
+	if consumed {
+		return nil
+	}
+	s.passed[c] = true
+	return []sdl.Event{emit(c)}
+}
+
+// release handles control c going up.
+func (h *Hotkeys) release(s *source, c Control, emit func(c Control) sdl.Event) []sdl.Event {
+	delete(s.held, c)
+	for i := range s.hotkeys {
+		if s.hotkeys[i].has(c) {
+			delete(s.since, i)
+			delete(s.fired, i)
+		}
+	}
+	switch {
+	case s.swallow[c]:
+		delete(s.swallow, c)
+		delete(s.pending, c)
+	case s.pending[c]:
+		// Let go before the hotkey fired, the game gets the press after all
+		delete(s.pending, c)
+		s.passed[c] = true
+		press := emit(c)
+		delete(s.passed, c)
+		return []sdl.Event{press, emit(c)}
+	case s.passed[c]:
+		delete(s.passed, c)
+		return []sdl.Event{emit(c)}
+	}
+	return nil
+}
+
+func (s *source) allHeld(hk *resolved) bool {
+	for _, c := range hk.controls {
+		if !s.held[c] {
+			return false
+		}
+	}
+	return true
+}
+
+// hatValue returns the directions of hat the game knows to be held.
+func (s *source) hatValue(hat uint8) uint8 {
+	var v uint8
+	for _, dir := range hatOrder {
+		if s.passed[JoyHat(int(hat), dir)] {
+			v |= dir
+		}
+	}
+	return v
+}
+
+var hatOrder = []uint8{sdl.HAT_UP, sdl.HAT_RIGHT, sdl.HAT_DOWN, sdl.HAT_LEFT}
+
+// HandleEvent runs an event through the hotkeys and returns the events
+// the game should see instead: none when the event belongs to a hotkey,
+// usually the event itself, and sometimes a held back press before it.
+// Actions are collected for Update.
+func (h *Hotkeys) HandleEvent(event sdl.Event) []sdl.Event {
+	switch t := event.(type) {
+	case *sdl.KeyboardEvent:
+		s := h.keyboard
+		c := Key(t.Keysym.Scancode)
+		if t.Repeat != 0 {
+			if s.swallow[c] || s.pending[c] {
+				return nil
+			}
+			return []sdl.Event{event}
+		}
+		emit := func(c Control) sdl.Event {
+			e := *t
+			e.Type, e.State = sdl.KEYUP, sdl.RELEASED
+			if s.passed[c] {
+				e.Type, e.State = sdl.KEYDOWN, sdl.PRESSED
+			}
+			return &e
+		}
+		if t.State == sdl.PRESSED {
+			return h.press(s, c, t.Timestamp, emit)
+		}
+		return h.release(s, c, emit)
+	case *sdl.JoyButtonEvent:
+		s := h.device(t.Which)
+		c := JoyButton(int(t.Button))
+		emit := func(c Control) sdl.Event {
+			e := *t
+			e.Type, e.State = sdl.JOYBUTTONUP, sdl.RELEASED
+			if s.passed[c] {
+				e.Type, e.State = sdl.JOYBUTTONDOWN, sdl.PRESSED
+			}
+			return &e
+		}
+		if t.State == sdl.PRESSED {
+			return h.press(s, c, t.Timestamp, emit)
+		}
+		return h.release(s, c, emit)
+	case *sdl.JoyHatEvent:
+		s := h.device(t.Which)
+		old := s.hats[t.Hat]
+		s.hats[t.Hat] = t.Value
+		// The game gets one event with the new value, preceded by one
+		// with the held back directions that were let go too early.
+		none := func(c Control) sdl.Event { return nil }
+		var early uint8
+		for _, dir := range hatOrder {
+			c := JoyHat(int(t.Hat), dir)
+			if old&dir == 0 && t.Value&dir != 0 {
+				h.press(s, c, t.Timestamp, none)
+			} else if old&dir != 0 && t.Value&dir == 0 {
+				if s.pending[c] && !s.swallow[c] {
+					early |= dir
+				}
+				h.release(s, c, none)
+			}
+		}
+		value := s.hatValue(t.Hat)
+		var out []sdl.Event
+		if early != 0 {
+			e := *t
+			e.Value = value | early
+			out = append(out, &e)
+		}
+		if early != 0 || value != s.sent[t.Hat] {
+			e := *t
+			e.Value = value
+			out = append(out, &e)
+		}
+		s.sent[t.Hat] = value
+		return out
+	case *sdl.JoyDeviceRemovedEvent:
+		// The profile is looked up again when the joystick comes back
+		delete(h.devices, t.Which)
+	}
+	return []sdl.Event{event}
+}
+
+// Update fires the hotkeys held long enough and returns the actions
+// started since the last call. Call it once per frame with sdl.GetTicks.
+func (h *Hotkeys) Update(now uint32) []Action {
+	update := func(s *source) {
+		for i, since := range s.since {
+			hk := &s.hotkeys[i]
+			if now-since < hk.holdMS {
+				continue
+			}
+			h.actions = append(h.actions, hk.action)
+			delete(s.since, i)
+			s.fired[i] = true
+			for _, c := range hk.controls {
+				if s.pending[c] {
+					delete(s.pending, c)
+					s.swallow[c] = true
+				}
+			}
+		}
+	}
+	update(h.keyboard)
+	for _, s := range h.devices {
+		update(s)
+	}
+	actions := h.actions
+	h.actions = nil
+	return actions
+}
+
+// Active reports whether a hotkey is complete or fired and still held,
+// for games that want to ignore the rest of the input meanwhile.
+func (h *Hotkeys) Active() bool {
+	if len(h.keyboard.since) > 0 || len(h.keyboard.fired) > 0 {
+		return true
+	}
+	for _, s := range h.devices {
+		if len(s.since) > 0 || len(s.fired) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,348 @@
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// BindKind tells which raw joystick control a Binding refers to.
+type BindKind int
+
+const (
+	BindNone BindKind = iota
+	BindButton
+	BindHat
+	BindAxis
+)
+
+// axisThreshold is how far an axis has to leave its resting value to be captured.
+const axisThreshold = 16384
+
+// Binding is a raw joystick control as written in an SDL GameController mapping.
+type Binding struct {
+	Kind   BindKind
+	Index  int
+	Hat    uint8 // hat direction mask, for BindHat
+	Sign   int   // +1 or -1 for a half axis, 0 for the whole axis
+	Invert bool  // whole axis moving the opposite way
+}
+
+// String returns the binding in mapping syntax: b3, h0.4, a1, +a1, -a1 or a1~.
+func (b Binding) String() string {
+	switch b.Kind {
+	case BindButton:
+		return fmt.Sprintf("b%d", b.Index)
+	case BindHat:
+		return fmt.Sprintf("h%d.%d", b.Index, b.Hat)
+	case BindAxis:
+		s := fmt.Sprintf("a%d", b.Index)
+		if b.Sign > 0 {
+			s = "+" + s
+		} else if b.Sign < 0 {
+			s = "-" + s
+		}
+		if b.Invert {
+			s += "~"
+		}
+		return s
+	}
+	return ""
+}
+
+// overlaps reports whether b and o would fire from the same physical movement.
+func (b Binding) overlaps(o Binding) bool {
+	if b.Kind != o.Kind || b.Index != o.Index {
+		return false
+	}
+	switch b.Kind {
+	case BindHat:
+		return b.Hat == o.Hat
+	case BindAxis:
+		return b.Sign == 0 || o.Sign == 0 || b.Sign == o.Sign
+	}
+	return true
+}
+
+// Prompt is one step of the remapping wizard.
+type Prompt struct {
+	Target   string // GameController element name, e.g. "a", "dpup", "leftx"
+	Text     string
+	Axis     bool // expects a stick movement; the whole axis gets bound
+	Optional bool
+}
+
+// DefaultPrompts asks for every element of a standard gamepad.
+var DefaultPrompts = []Prompt{
+	{Target: "a", Text: "press A"},
+	{Target: "b", Text: "press B"},
+	{Target: "x", Text: "press X"},
+	{Target: "y", Text: "press Y"},
+	{Target: "back", Text: "press SELECT"},
+	{Target: "start", Text: "press START"},
+	{Target: "dpup", Text: "press D-pad Up"},
+	{Target: "dpdown", Text: "press D-pad Down"},
+	{Target: "dpleft", Text: "press D-pad Left"},
+	{Target: "dpright", Text: "press D-pad Right"},
+	{Target: "leftshoulder", Text: "press L1", Optional: true},
+	{Target: "rightshoulder", Text: "press R1", Optional: true},
+	{Target: "lefttrigger", Text: "press L2", Optional: true},
+	{Target: "righttrigger", Text: "press R2", Optional: true},
+	{Target: "guide", Text: "press MENU/HOME", Optional: true},
+	{Target: "leftstick", Text: "press left stick (L3)", Optional: true},
+	{Target: "rightstick", Text: "press right stick (R3)", Optional: true},
+	{Target: "leftx", Text: "move left stick right", Axis: true, Optional: true},
+	{Target: "lefty", Text: "move left stick down", Axis: true, Optional: true},
+	{Target: "rightx", Text: "move right stick right", Axis: true, Optional: true},
+	{Target: "righty", Text: "move right stick down", Axis: true, Optional: true},
+}
+
+// Wizard walks through a list of prompts and captures the raw control
+// used for each of them from joystick events.
+type Wizard struct {
+	Prompts []Prompt
+	Step    int
+	// Message explains why the last input was rejected, empty otherwise.
+	Message string
+	// On optional prompts the control bound to SkipTarget skips the step,
+	// so that devices without a keyboard can get through the wizard.
+	SkipTarget string
+
+	bound   []Binding
+	rest    map[int]int16
+	waiting *Binding // captured control that must return to rest first
+}
+
+// NewWizard returns a wizard over prompts.
+func NewWizard(prompts []Prompt) *Wizard {
+	return &Wizard{
+		Prompts:    prompts,
+		SkipTarget: "start",
+		bound:      make([]Binding, len(prompts)),
+		rest:       make(map[int]int16),
+	}
+}
+
+// SetRest records the resting value of an axis. Triggers usually rest at
+// -32768, so call it for every axis before the wizard starts.
+func (w *Wizard) SetRest(axis int, value int16) {
+	w.rest[axis] = value
+}
+
+// Current returns the active prompt, or nil when the wizard is done.
+func (w *Wizard) Current() *Prompt {
+	if w.Done() {
+		return nil
+	}
+	return &w.Prompts[w.Step]
+}
+
+// Done reports whether every prompt was answered or skipped.
+func (w *Wizard) Done() bool {
+	return w.Step >= len(w.Prompts)
+}
+
+// Bound returns the control captured for target.
+func (w *Wizard) Bound(target string) (Binding, bool) {
+	for i, p := range w.Prompts {
+		if p.Target == target && w.bound[i].Kind != BindNone {
+			return w.bound[i], true
+		}
+	}
+	return Binding{}, false
+}
+
+// Skip leaves the current prompt unbound and moves on. Prompts that are
+// not optional can't be skipped; Skip reports whether it moved on.
+func (w *Wizard) Skip() bool {
+	if w.Done() {
+		return false
+	}
+	if p := w.Prompts[w.Step]; !p.Optional {
+		w.Message = fmt.Sprintf("%v can't be skipped", p.Target)
+		return false
+	}
+	w.bound[w.Step] = Binding{}
+	w.Step++
+	w.Message = ""
+	return true
+}
+
+// Back returns to the previous prompt and forgets its binding.
+func (w *Wizard) Back() {
+	if w.Step > 0 {
+		w.Step--
+		w.bound[w.Step] = Binding{}
+		w.Message = ""
+	}
+}
+
+// Feed processes a joystick event. It returns true when the event answered
+// the current prompt.
+func (w *Wizard) Feed(event sdl.Event) bool {
+	if w.Done() {
+		return false
+	}
+	var b Binding
+	var value int16
+	switch t := event.(type) {
+	case *sdl.JoyButtonEvent:
+		if w.released(Binding{Kind: BindButton, Index: int(t.Button)}, t.State == sdl.RELEASED) || t.State != sdl.PRESSED {
+			return false
+		}
+		b = Binding{Kind: BindButton, Index: int(t.Button)}
+	case *sdl.JoyHatEvent:
+		if w.released(Binding{Kind: BindHat, Index: int(t.Hat), Hat: t.Value}, t.Value == sdl.HAT_CENTERED) {
+			return false
+		}
+		// Only single directions can be mapped, diagonals are ignored
+		switch t.Value {
+		case sdl.HAT_UP, sdl.HAT_RIGHT, sdl.HAT_DOWN, sdl.HAT_LEFT:
+			b = Binding{Kind: BindHat, Index: int(t.Hat), Hat: t.Value}
+		default:
+			return false
+		}
+	case *sdl.JoyAxisEvent:
+		value = t.Value
+		delta := int(t.Value) - int(w.rest[int(t.Axis)])
+		if delta < 0 {
+			delta = -delta
+		}
+		if w.released(Binding{Kind: BindAxis, Index: int(t.Axis)}, delta < axisThreshold/2) || delta < axisThreshold {
+			return false
+		}
+		b = w.axisBinding(int(t.Axis), value)
+	default:
+		return false
+	}
+	return w.capture(b)
+}
+
+// released clears the pending control once it is back at rest. It reports
+// whether the event still belongs to the pending control.
+func (w *Wizard) released(b Binding, atRest bool) bool {
+	if w.waiting == nil || w.waiting.Kind != b.Kind || w.waiting.Index != b.Index {
+		return false
+	}
+	if atRest {
+		w.waiting = nil
+	}
+	return true
+}
+
+func (w *Wizard) axisBinding(axis int, value int16) Binding {
+	b := Binding{Kind: BindAxis, Index: axis}
+	rest := w.rest[axis]
+	p := w.Prompts[w.Step]
+	switch {
+	case p.Axis:
+		// Sticks are prompted in their positive direction
+		b.Invert = value < rest
+	case rest < -axisThreshold:
+		// A trigger resting at one end uses the whole axis
+	case value > rest:
+		b.Sign = 1
+	default:
+		b.Sign = -1
+	}
+	return b
+}
+
+func (w *Wizard) capture(b Binding) bool {
+	for i, old := range w.bound {
+		if i == w.Step || old.Kind == BindNone || !old.overlaps(b) {
+			continue
+		}
+		if w.Prompts[w.Step].Optional && w.Prompts[i].Target == w.SkipTarget {
+			w.waiting = &b
+			w.Skip()
+			return false
+		}
+		w.Message = fmt.Sprintf("conflict: %v is already used by %v", b, w.Prompts[i].Target)
+		return false
+	}
+	w.bound[w.Step] = b
+	w.waiting = &b
+	w.Message = ""
+	w.Step++
+	return true
+}
+
+// Mapping returns the SDL GameController mapping line of the captured bindings.
+func (w *Wizard) Mapping(guid, name string) string {
+	var sb strings.Builder
+	sb.WriteString(guid)
+	sb.WriteString(",")
+	sb.WriteString(strings.ReplaceAll(name, ",", " "))
+	sb.WriteString(",")
+	for i, p := range w.Prompts {
+		if w.bound[i].Kind == BindNone {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s:%v,", p.Target, w.bound[i])
+	}
+	fmt.Fprintf(&sb, "platform:%s,", sdl.GetPlatform())
+	return sb.String()
+}
+
+// SaveMapping adds a mapping line to a user mappings file, replacing any
+// earlier line for the same GUID and platform.
+func SaveMapping(filename, mapping string) error {
+	var lines []string
+	key := mappingKey(mapping)
+	if f, err := os.Open(filename); err == nil {
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if line := scanner.Text(); mappingKey(line) != key {
+				lines = append(lines, line)
+			}
+		}
+		f.Close()
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	lines = append(lines, mapping)
+	return os.WriteFile(filename, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+// LoadMappings adds every mapping of a user mappings file to SDL and
+// returns how many were accepted. Call it before opening game controllers.
+func LoadMappings(filename string) (int, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	n := 0
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		if sdl.GameControllerAddMapping(text) < 0 {
+			return n, fmt.Errorf("%v:%d: %v", filename, line, sdl.GetError())
+		}
+		n++
+	}
+	return n, scanner.Err()
+}
+
+func mappingKey(line string) string {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ""
+	}
+	guid, _, _ := strings.Cut(line, ",")
+	platform := ""
+	if i := strings.Index(line, "platform:"); i >= 0 {
+		platform, _, _ = strings.Cut(line[i:], ",")
+	}
+	return strings.ToLower(guid) + "|" + platform
+}
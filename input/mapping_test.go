@@ -0,0 +1,155 @@
+package input
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func hat(value uint8) *sdl.JoyHatEvent {
+	return &sdl.JoyHatEvent{Type: sdl.JOYHATMOTION, Value: value}
+}
+
+func axis(a uint8, value int16) *sdl.JoyAxisEvent {
+	return &sdl.JoyAxisEvent{Type: sdl.JOYAXISMOTION, Axis: a, Value: value}
+}
+
+// press feeds a press and the release that lets the next prompt capture.
+func press(w *Wizard, b uint8) bool {
+	ok := w.Feed(button(b, true))
+	w.Feed(button(b, false))
+	return ok
+}
+
+func TestWizardFeed(t *testing.T) {
+	w := NewWizard([]Prompt{
+		{Target: "a", Text: "press A"},
+		{Target: "dpup", Text: "press D-pad Up"},
+		{Target: "lefttrigger", Text: "press L2", Optional: true},
+		{Target: "leftx", Text: "move left stick right", Axis: true, Optional: true},
+	})
+	w.SetRest(0, 0)
+	w.SetRest(2, -32768)
+
+	if w.Feed(button(1, false)) {
+		t.Error("release answered a prompt")
+	}
+	if !press(w, 1) || w.Current().Target != "dpup" {
+		t.Fatalf("button not captured, at %v", w.Current())
+	}
+	if w.Feed(hat(sdl.HAT_UP | sdl.HAT_LEFT)) {
+		t.Error("diagonal answered a prompt")
+	}
+	if !w.Feed(hat(sdl.HAT_UP)) {
+		t.Fatal("hat not captured")
+	}
+	w.Feed(hat(sdl.HAT_CENTERED))
+	if w.Feed(axis(2, -20000)) {
+		t.Error("trigger barely moved answered a prompt")
+	}
+	if !w.Feed(axis(2, 32767)) {
+		t.Fatal("trigger not captured")
+	}
+	w.Feed(axis(2, -32768))
+	// The stick is pushed left although the prompt asks for right
+	if !w.Feed(axis(0, -32768)) || !w.Done() {
+		t.Fatal("stick not captured")
+	}
+
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"a", "b1"},
+		{"dpup", "h0.1"},
+		{"lefttrigger", "a2"},
+		{"leftx", "a0~"},
+	}
+	for _, tt := range tests {
+		if b, ok := w.Bound(tt.target); !ok || b.String() != tt.want {
+			t.Errorf("%s bound to %q, want %q", tt.target, b, tt.want)
+		}
+	}
+}
+
+func TestWizardConflicts(t *testing.T) {
+	w := NewWizard([]Prompt{
+		{Target: "a", Text: "press A"},
+		{Target: "start", Text: "press START"},
+		{Target: "b", Text: "press B"},
+		{Target: "guide", Text: "press MENU", Optional: true},
+		{Target: "x", Text: "press X"},
+	})
+	press(w, 0)
+	press(w, 7)
+	if press(w, 0) || w.Step != 2 || !strings.Contains(w.Message, "already used by a") {
+		t.Errorf("button of a taken twice: step %d, message %q", w.Step, w.Message)
+	}
+	if !press(w, 1) || w.Message != "" {
+		t.Errorf("free button rejected: %q", w.Message)
+	}
+	// START on an optional prompt skips it
+	if press(w, 7) || w.Current().Target != "x" {
+		t.Errorf("START didn't skip the optional prompt, at %v", w.Current())
+	}
+	if _, ok := w.Bound("guide"); ok {
+		t.Error("skipped prompt bound")
+	}
+	// A required prompt can't be skipped, neither by START nor by Skip
+	if press(w, 7) || w.Current().Target != "x" {
+		t.Error("START skipped a required prompt")
+	}
+	if w.Skip() || w.Current().Target != "x" || w.Message == "" {
+		t.Errorf("Skip moved past a required prompt, message %q", w.Message)
+	}
+
+	w.Back()
+	if w.Current().Target != "guide" || !w.Skip() {
+		t.Error("optional prompt not skipped after Back")
+	}
+}
+
+func TestWizardMapping(t *testing.T) {
+	w := NewWizard([]Prompt{
+		{Target: "a", Text: "press A"},
+		{Target: "back", Text: "press SELECT"},
+		{Target: "guide", Text: "press MENU", Optional: true},
+		{Target: "dpleft", Text: "press D-pad Left"},
+		{Target: "lefttrigger", Text: "press L2", Optional: true},
+	})
+	w.SetRest(4, 0)
+	press(w, 0)
+	press(w, 6)
+	w.Skip()
+	w.Feed(hat(sdl.HAT_LEFT))
+	w.Feed(hat(sdl.HAT_CENTERED))
+	w.Feed(axis(4, -30000))
+	if !w.Done() {
+		t.Fatalf("not done, at %v", w.Current())
+	}
+	guid := "03000000de280000ff11000001000000"
+	want := guid + ",Pad  with comma,a:b0,back:b6,dpleft:h0.8,lefttrigger:-a4,platform:" + sdl.GetPlatform() + ","
+	mapping := w.Mapping(guid, "Pad, with comma")
+	if mapping != want {
+		t.Errorf("mapping\n%s\nwant\n%s", mapping, want)
+	}
+
+	// Saving replaces the line of the same GUID and platform
+	filename := filepath.Join(t.TempDir(), "gamecontrollerdb_user.txt")
+	other := "030000005e0400008e02000010010000,Other,a:b0,platform:" + sdl.GetPlatform() + ","
+	for _, line := range []string{other, strings.Replace(mapping, "b0", "b1", 1), mapping} {
+		if err := SaveMapping(filename, line); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != other+"\n"+mapping+"\n" {
+		t.Errorf("saved file:\n%s", got)
+	}
+}
@@ -0,0 +1,372 @@
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// KeyboardHalf is the part of a shared keyboard one player uses. Its keys
+// act as the buttons and the hat of the "Keyboard" profile, so the game
+// reads a keyboard player the same way as a joystick player.
+type KeyboardHalf struct {
+	Name string
+	Keys map[sdl.Scancode]Control // JoyButton or JoyHat(0, dir)
+
+	hat uint8
+}
+
+// LeftKeys is the left half: WASD moves, F G H R T Y are A B X Y L1 R1,
+// Q is SELECT and E is START.
+func LeftKeys() *KeyboardHalf {
+	return &KeyboardHalf{Name: "Keyboard left", Keys: map[sdl.Scancode]Control{
+		sdl.SCANCODE_W: JoyHat(0, sdl.HAT_UP),
+		sdl.SCANCODE_S: JoyHat(0, sdl.HAT_DOWN),
+		sdl.SCANCODE_A: JoyHat(0, sdl.HAT_LEFT),
+		sdl.SCANCODE_D: JoyHat(0, sdl.HAT_RIGHT),
+		sdl.SCANCODE_F: JoyButton(0),
+		sdl.SCANCODE_G: JoyButton(1),
+		sdl.SCANCODE_H: JoyButton(2),
+		sdl.SCANCODE_R: JoyButton(3),
+		sdl.SCANCODE_T: JoyButton(4),
+		sdl.SCANCODE_Y: JoyButton(5),
+		sdl.SCANCODE_Q: JoyButton(6),
+		sdl.SCANCODE_E: JoyButton(7),
+	}}
+}
+
+// RightKeys is the right half: the arrows move, J K L U I O are A B X Y
+// L1 R1, right shift is SELECT and return is START.
+func RightKeys() *KeyboardHalf {
+	return &KeyboardHalf{Name: "Keyboard right", Keys: map[sdl.Scancode]Control{
+		sdl.SCANCODE_UP:     JoyHat(0, sdl.HAT_UP),
+		sdl.SCANCODE_DOWN:   JoyHat(0, sdl.HAT_DOWN),
+		sdl.SCANCODE_LEFT:   JoyHat(0, sdl.HAT_LEFT),
+		sdl.SCANCODE_RIGHT:  JoyHat(0, sdl.HAT_RIGHT),
+		sdl.SCANCODE_J:      JoyButton(0),
+		sdl.SCANCODE_K:      JoyButton(1),
+		sdl.SCANCODE_L:      JoyButton(2),
+		sdl.SCANCODE_U:      JoyButton(3),
+		sdl.SCANCODE_I:      JoyButton(4),
+		sdl.SCANCODE_O:      JoyButton(5),
+		sdl.SCANCODE_RSHIFT: JoyButton(6),
+		sdl.SCANCODE_RETURN: JoyButton(7),
+	}}
+}
+
+// key returns the joystick event a key of the half stands for, or nil.
+func (h *KeyboardHalf) key(t *sdl.KeyboardEvent) sdl.Event {
+	c, ok := h.Keys[t.Keysym.Scancode]
+	if !ok {
+		return nil
+	}
+	v := c &^ controlKind
+	if c&controlKind == controlHat {
+		dir := uint8(v)
+		if t.State == sdl.PRESSED {
+			h.hat |= dir
+		} else {
+			h.hat &^= dir
+		}
+		return &sdl.JoyHatEvent{Type: sdl.JOYHATMOTION, Timestamp: t.Timestamp, Value: h.hat}
+	}
+	typ := uint32(sdl.JOYBUTTONUP)
+	if t.State == sdl.PRESSED {
+		typ = sdl.JOYBUTTONDOWN
+	}
+	return &sdl.JoyButtonEvent{Type: typ, Timestamp: t.Timestamp, Button: uint8(v), State: t.State}
+}
+
+// prefKey is the name a source is saved under in the slot preferences.
+func (h *KeyboardHalf) prefKey() string { return "keyboard:" + h.Name }
+
+// Slot is one player.
+type Slot struct {
+	Index   int           // 0 for player 1
+	Device  *Device       // joystick played with, nil when free or on the keyboard
+	Keys    *KeyboardHalf // keyboard half played with, nil when free or on a joystick
+	State   *State        // input of this player only
+	Waiting bool          // the joystick was unplugged, the game should pause
+
+	profile *Profile
+}
+
+// Joined reports whether the slot has a joystick or keyboard half.
+func (p *Slot) Joined() bool { return p.Device != nil || p.Keys != nil }
+
+// Name returns "Player 1" and so on.
+func (p *Slot) Name() string { return fmt.Sprintf("Player %d", p.Index+1) }
+
+// Source returns the name of the joystick or keyboard half, "" when free.
+func (p *Slot) Source() string {
+	switch {
+	case p.Device != nil:
+		return p.Device.Name
+	case p.Keys != nil:
+		return p.Keys.Name
+	}
+	return ""
+}
+
+// Profile returns the profile to read State with.
+func (p *Slot) Profile() *Profile {
+	if p.Device != nil && p.Device.Profile != nil {
+		return p.Device.Profile
+	}
+	return p.profile
+}
+
+// Button returns the control of a named button of the player's profile,
+// e.g. p.State.JustPressed(p.Button("A")).
+func (p *Slot) Button(name string) Control {
+	return JoyButton(p.Profile().Button(name))
+}
+
+// Players assigns joysticks and keyboard halves to player slots and
+// routes their input. A press on a free source joins the first free slot,
+// or the slot the source had last time. A player whose joystick is
+// unplugged waits for the same joystick (same GUID) to come back.
+type Players struct {
+	Registry *Registry
+	Slots    []*Slot
+	Halves   []*KeyboardHalf // keyboard halves that can join
+	Prefs    SlotPrefs       // preferred slot by joystick GUID or keyboard half
+	Joining  bool            // presses on free sources join, true by default
+}
+
+// NewPlayers returns n free slots whose states keep frames frames. The
+// keyboard is split in LeftKeys and RightKeys.
+func NewPlayers(registry *Registry, n, frames int) *Players {
+	ps := &Players{
+		Registry: registry,
+		Halves:   []*KeyboardHalf{LeftKeys(), RightKeys()},
+		Prefs:    make(SlotPrefs),
+		Joining:  true,
+	}
+	keyboard := GenericProfile()
+	if registry.Profiles != nil {
+		if p := registry.Profiles.Get("Keyboard"); p != nil {
+			keyboard = p
+		}
+	}
+	for i := 0; i < n; i++ {
+		ps.Slots = append(ps.Slots, &Slot{Index: i, State: NewState(frames), profile: keyboard})
+	}
+	return ps
+}
+
+// Slot returns slot i, or nil.
+func (ps *Players) Slot(i int) *Slot {
+	if i < 0 || i >= len(ps.Slots) {
+		return nil
+	}
+	return ps.Slots[i]
+}
+
+// Joined returns the players that have a source.
+func (ps *Players) Joined() []*Slot {
+	var list []*Slot
+	for _, p := range ps.Slots {
+		if p.Joined() {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// Waiting returns the players whose joystick is unplugged.
+func (ps *Players) Waiting() []*Slot {
+	var list []*Slot
+	for _, p := range ps.Slots {
+		if p.Waiting {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// Paused reports whether a player waits for their joystick.
+func (ps *Players) Paused() bool {
+	return len(ps.Waiting()) > 0
+}
+
+func (ps *Players) byDevice(d *Device) *Slot {
+	for _, p := range ps.Slots {
+		if p.Device == d {
+			return p
+		}
+	}
+	return nil
+}
+
+func (ps *Players) byKeys(h *KeyboardHalf) *Slot {
+	for _, p := range ps.Slots {
+		if p.Keys == h {
+			return p
+		}
+	}
+	return nil
+}
+
+// free returns the slot preferred by key if it is free, else the first
+// free slot, or nil.
+func (ps *Players) free(key string) *Slot {
+	if i, ok := ps.Prefs[key]; ok {
+		if p := ps.Slot(i); p != nil && !p.Joined() {
+			return p
+		}
+	}
+	for _, p := range ps.Slots {
+		if !p.Joined() {
+			return p
+		}
+	}
+	return nil
+}
+
+// JoinDevice puts d in slot i, which must be free, and remembers the
+// choice in Prefs.
+func (ps *Players) JoinDevice(i int, d *Device) *Slot {
+	p := ps.Slot(i)
+	if p == nil || p.Joined() || ps.byDevice(d) != nil {
+		return nil
+	}
+	p.Device, p.Waiting = d, !d.Connected
+	p.State.Joystick = d.ID
+	ps.Prefs[d.GUID] = i
+	return p
+}
+
+// JoinKeys puts keyboard half h in slot i, which must be free, and
+// remembers the choice in Prefs.
+func (ps *Players) JoinKeys(i int, h *KeyboardHalf) *Slot {
+	p := ps.Slot(i)
+	if p == nil || p.Joined() || ps.byKeys(h) != nil {
+		return nil
+	}
+	p.Keys = h
+	p.State.Joystick = AnyJoystick
+	ps.Prefs[h.prefKey()] = i
+	return p
+}
+
+// Leave frees slot i; its preference is kept.
+func (ps *Players) Leave(i int) {
+	p := ps.Slot(i)
+	if p == nil || !p.Joined() {
+		return
+	}
+	p.State.HandleEvent(&sdl.JoyDeviceRemovedEvent{Type: sdl.JOYDEVICEREMOVED, Which: p.State.Joystick})
+	if p.Keys != nil {
+		p.Keys.hat = sdl.HAT_CENTERED
+	}
+	p.Device, p.Keys, p.Waiting = nil, nil, false
+}
+
+// HandleEvent passes the event to the registry, then joins, reattaches or
+// routes it. It returns the player the event went to and Added when the
+// player just joined, Removed when their joystick was unplugged,
+// Reconnected when it came back, NoChange otherwise. Events of free
+// sources that don't join return nil. The press that joins is not passed
+// on to the new player's state.
+func (ps *Players) HandleEvent(event sdl.Event) (*Slot, Change) {
+	d, change := ps.Registry.HandleEvent(event)
+	switch t := event.(type) {
+	case *sdl.JoyDeviceAddedEvent:
+		if d == nil {
+			break
+		}
+		if p := ps.byDevice(d); p != nil {
+			// The same *Device comes back for the same GUID
+			p.Waiting = false
+			p.State.Joystick = d.ID
+			return p, Reconnected
+		}
+		if i, ok := ps.Prefs[d.GUID]; ok && change != NoChange {
+			if p := ps.Slot(i); p != nil && !p.Joined() {
+				return ps.JoinDevice(i, d), Added
+			}
+		}
+	case *sdl.JoyDeviceRemovedEvent:
+		if d == nil {
+			break
+		}
+		if p := ps.byDevice(d); p != nil {
+			p.State.HandleEvent(event)
+			p.Waiting = true
+			return p, Removed
+		}
+	case *sdl.JoyButtonEvent, *sdl.JoyAxisEvent, *sdl.JoyHatEvent, *sdl.JoyBallEvent:
+		if d == nil {
+			break
+		}
+		if p := ps.byDevice(d); p != nil {
+			p.State.HandleEvent(event)
+			return p, NoChange
+		}
+		if b, ok := t.(*sdl.JoyButtonEvent); ok && b.State == sdl.PRESSED && ps.Joining {
+			if p := ps.free(d.GUID); p != nil {
+				ps.JoinDevice(p.Index, d)
+				return p, Added
+			}
+		}
+	case *sdl.KeyboardEvent:
+		for _, h := range ps.Halves {
+			if _, ok := h.Keys[t.Keysym.Scancode]; !ok {
+				continue
+			}
+			if p := ps.byKeys(h); p != nil {
+				if e := h.key(t); e != nil && t.Repeat == 0 {
+					p.State.HandleEvent(e)
+				}
+				return p, NoChange
+			}
+			if t.State == sdl.PRESSED && t.Repeat == 0 && ps.Joining {
+				if p := ps.free(h.prefKey()); p != nil {
+					ps.JoinKeys(p.Index, h)
+					return p, Added
+				}
+			}
+			return nil, NoChange
+		}
+	}
+	return nil, NoChange
+}
+
+// NextFrame closes the frame of every player's state.
+func (ps *Players) NextFrame() {
+	for _, p := range ps.Slots {
+		p.State.NextFrame()
+	}
+}
+
+// SlotPrefs maps a joystick GUID, or "keyboard:" and a keyboard half name,
+// to the slot it joined last.
+type SlotPrefs map[string]int
+
+// LoadSlotPrefs reads preferences saved with Save. A missing file yields
+// an empty set.
+func LoadSlotPrefs(filename string) (SlotPrefs, error) {
+	prefs := make(SlotPrefs)
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return prefs, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &prefs); err != nil {
+		return nil, err
+	}
+	return prefs, nil
+}
+
+// Save writes the preferences as JSON.
+func (prefs SlotPrefs) Save(filename string) error {
+	data, err := json.MarshalIndent(prefs, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,157 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func key(code sdl.Scancode, down bool) *sdl.KeyboardEvent {
+	e := &sdl.KeyboardEvent{Type: sdl.KEYUP, Keysym: sdl.Keysym{Scancode: code}, State: sdl.RELEASED}
+	if down {
+		e.Type, e.State = sdl.KEYDOWN, sdl.PRESSED
+	}
+	return e
+}
+
+// plugPlayer is plug for a registry behind players.
+func plugPlayer(ps *Players, id sdl.JoystickID, guid string) (*Slot, Change) {
+	ps.Registry.Expect(DeviceInfo{Index: 0, ID: id, GUID: guid, Name: guid, Buttons: 4})
+	return ps.HandleEvent(&sdl.JoyDeviceAddedEvent{Type: sdl.JOYDEVICEADDED, Which: 0})
+}
+
+func pressOn(ps *Players, id sdl.JoystickID, b uint8, down bool) (*Slot, Change) {
+	e := button(b, down)
+	e.Which = id
+	return ps.HandleEvent(e)
+}
+
+func TestPlayersJoin(t *testing.T) {
+	ps := NewPlayers(NewRegistry(nil), 2, 8)
+	if p, _ := plugPlayer(ps, 1, "pad"); p != nil {
+		t.Error("plugging joined")
+	}
+	// Releases and unknown joysticks don't join
+	if p, _ := pressOn(ps, 1, 0, false); p != nil {
+		t.Error("release joined")
+	}
+	if p, _ := pressOn(ps, 9, 0, true); p != nil {
+		t.Error("unknown joystick joined")
+	}
+
+	p, change := pressOn(ps, 1, 2, true)
+	if p != ps.Slot(0) || change != Added || p.Device == nil || p.Device.ID != 1 {
+		t.Fatalf("press: %v %v", p, change)
+	}
+	if p.State.Held(JoyButton(2)) {
+		t.Error("the press that joined reached the state")
+	}
+	if q, change := pressOn(ps, 1, 2, false); q != p || change != NoChange || p.State.Held(JoyButton(2)) {
+		t.Errorf("release after joining: %v %v", q, change)
+	}
+	if q, _ := pressOn(ps, 1, 3, true); q != p || !p.State.Held(JoyButton(3)) {
+		t.Error("press of a joined player not routed")
+	}
+	if ps.Prefs["pad"] != 0 {
+		t.Errorf("prefs %v", ps.Prefs)
+	}
+
+	ps.Joining = false
+	plugPlayer(ps, 2, "stick")
+	if q, _ := pressOn(ps, 2, 0, true); q != nil {
+		t.Error("joined while joining is off")
+	}
+}
+
+func TestPlayersPreferredSlot(t *testing.T) {
+	ps := NewPlayers(NewRegistry(nil), 3, 8)
+	ps.Prefs = SlotPrefs{"pad": 2, "keyboard:Keyboard left": 1}
+
+	plugPlayer(ps, 1, "stick")
+	if p, _ := pressOn(ps, 1, 0, true); p != ps.Slot(0) {
+		t.Errorf("stick without preference in %v", p)
+	}
+	// A joystick that was here before joins its slot as soon as it is plugged
+	if p, change := plugPlayer(ps, 2, "pad"); p != ps.Slot(2) || change != Added {
+		t.Errorf("pad rejoined %v, %v", p, change)
+	}
+	if p, _ := ps.HandleEvent(key(sdl.SCANCODE_F, true)); p != ps.Slot(1) {
+		t.Errorf("left keys in %v", p)
+	}
+
+	// A taken preferred slot gives the first free one
+	ps.Leave(1)
+	ps.Leave(2)
+	ps.JoinKeys(2, ps.Halves[1])
+	if p, _ := pressOn(ps, 2, 0, true); p != ps.Slot(1) {
+		t.Errorf("pad in %v with its slot taken", p)
+	}
+}
+
+func TestPlayersReconnect(t *testing.T) {
+	ps := NewPlayers(NewRegistry(nil), 2, 8)
+	plugPlayer(ps, 1, "pad")
+	p, _ := pressOn(ps, 1, 0, true)
+	pressOn(ps, 1, 0, false)
+	pressOn(ps, 1, 1, true)
+	d := p.Device
+
+	q, change := ps.HandleEvent(&sdl.JoyDeviceRemovedEvent{Type: sdl.JOYDEVICEREMOVED, Which: 1})
+	if q != p || change != Removed || !p.Waiting || !ps.Paused() || p.State.Held(JoyButton(1)) {
+		t.Fatalf("removed: %v %v, waiting %v", q, change, p.Waiting)
+	}
+	// Another pad with another GUID doesn't take the place
+	plugPlayer(ps, 2, "stick")
+	if q, _ := pressOn(ps, 2, 0, true); q != ps.Slot(1) {
+		t.Errorf("other joystick in %v", q)
+	}
+
+	q, change = plugPlayer(ps, 3, "pad")
+	if q != p || change != Reconnected || p.Device != d || p.Waiting || ps.Paused() {
+		t.Fatalf("reconnected: %v %v", q, change)
+	}
+	if q, _ := pressOn(ps, 3, 1, true); q != p || !p.State.Held(JoyButton(1)) {
+		t.Error("press after reconnecting not routed")
+	}
+}
+
+func TestPlayersKeyboardHalves(t *testing.T) {
+	ps := NewPlayers(NewRegistry(nil), 2, 8)
+	left, _ := ps.HandleEvent(key(sdl.SCANCODE_F, true))
+	if left != ps.Slot(0) || left.Keys != ps.Halves[0] || left.State.Held(JoyButton(0)) {
+		t.Fatalf("left half joined %v", left)
+	}
+	ps.HandleEvent(key(sdl.SCANCODE_F, false))
+	right, change := ps.HandleEvent(key(sdl.SCANCODE_UP, true))
+	if right != ps.Slot(1) || change != Added || right.Keys != ps.Halves[1] {
+		t.Fatalf("right half joined %v", right)
+	}
+
+	ps.HandleEvent(key(sdl.SCANCODE_W, true))
+	ps.HandleEvent(key(sdl.SCANCODE_A, true))
+	ps.HandleEvent(key(sdl.SCANCODE_J, true))
+	if left.State.Hat(0) != sdl.HAT_LEFTUP || left.State.Held(JoyButton(0)) {
+		t.Errorf("left: hat %d, A %v", left.State.Hat(0), left.State.Held(JoyButton(0)))
+	}
+	if right.State.Hat(0) != sdl.HAT_CENTERED || !right.State.Held(JoyButton(0)) {
+		t.Errorf("right: hat %d, A %v", right.State.Hat(0), right.State.Held(JoyButton(0)))
+	}
+	if p, _ := ps.HandleEvent(key(sdl.SCANCODE_Z, true)); p != nil {
+		t.Error("key of no half routed")
+	}
+
+	// A joystick press doesn't reach the keyboard players
+	plugPlayer(ps, 1, "pad")
+	if p, _ := pressOn(ps, 1, 2, true); p != nil || left.State.Held(JoyButton(2)) || right.State.Held(JoyButton(2)) {
+		t.Error("joystick press reached a keyboard player")
+	}
+
+	// Leaving lets go of the held keys
+	ps.Leave(0)
+	if left.Joined() || left.State.Hat(0) != sdl.HAT_CENTERED {
+		t.Error("left half still held after leaving")
+	}
+	if p, _ := ps.HandleEvent(key(sdl.SCANCODE_W, false)); p != nil {
+		t.Error("release joined")
+	}
+}
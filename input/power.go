@@ -0,0 +1,116 @@
+package input
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// PowerName returns "empty", "low", "medium", "full", "wired" or "unknown".
+func PowerName(level sdl.JoystickPowerLevel) string {
+	switch level {
+	case sdl.JOYSTICK_POWER_EMPTY:
+		return "empty"
+	case sdl.JOYSTICK_POWER_LOW:
+		return "low"
+	case sdl.JOYSTICK_POWER_MEDIUM:
+		return "medium"
+	case sdl.JOYSTICK_POWER_FULL:
+		return "full"
+	case sdl.JOYSTICK_POWER_WIRED:
+		return "wired"
+	}
+	return "unknown"
+}
+
+// PowerLevel returns the battery level of the device. Devices without an
+// opened joystick, and drivers that can't tell, report
+// JOYSTICK_POWER_UNKNOWN.
+func (d *Device) PowerLevel() sdl.JoystickPowerLevel {
+	if d.Joystick == nil || !d.Connected {
+		return sdl.JOYSTICK_POWER_UNKNOWN
+	}
+	return d.Joystick.CurrentPowerLevel()
+}
+
+// BatteryChange is a change of a device's battery level.
+type BatteryChange struct {
+	Device   *Device
+	Level    sdl.JoystickPowerLevel
+	Previous sdl.JoystickPowerLevel
+}
+
+// Low reports whether the battery just ran low or empty.
+func (c BatteryChange) Low() bool {
+	low := func(l sdl.JoystickPowerLevel) bool {
+		return l == sdl.JOYSTICK_POWER_LOW || l == sdl.JOYSTICK_POWER_EMPTY
+	}
+	return low(c.Level) && (!low(c.Previous) || c.Level < c.Previous)
+}
+
+// Batteries watches the battery level of every connected device. SDL
+// before 2.0.24 sends no event when it changes, so it is polled.
+type Batteries struct {
+	Registry   *Registry
+	IntervalMS uint32 // time between polls
+
+	levels map[*Device]sdl.JoystickPowerLevel
+	last   uint32
+	polled bool
+}
+
+// NewBatteries polls every 5 seconds.
+func NewBatteries(registry *Registry) *Batteries {
+	return &Batteries{
+		Registry:   registry,
+		IntervalMS: 5000,
+		levels:     make(map[*Device]sdl.JoystickPowerLevel),
+	}
+}
+
+// Level returns the last polled level of d.
+func (b *Batteries) Level(d *Device) sdl.JoystickPowerLevel {
+	if level, ok := b.levels[d]; ok {
+		return level
+	}
+	return sdl.JOYSTICK_POWER_UNKNOWN
+}
+
+// Low returns the connected devices whose battery is low or empty.
+func (b *Batteries) Low() []*Device {
+	var list []*Device
+	for _, d := range b.Registry.Devices() {
+		if l := b.Level(d); l == sdl.JOYSTICK_POWER_LOW || l == sdl.JOYSTICK_POWER_EMPTY {
+			list = append(list, d)
+		}
+	}
+	return list
+}
+
+// Update polls the levels when IntervalMS has passed, and at once for
+// devices not seen before. It returns the changes; a device that connects
+// reports a change from JOYSTICK_POWER_UNKNOWN when its level is known.
+func (b *Batteries) Update(now uint32) []BatteryChange {
+	due := !b.polled || now-b.last >= b.IntervalMS
+	if due {
+		b.last, b.polled = now, true
+	}
+	var changes []BatteryChange
+	for _, d := range b.Registry.Devices() {
+		previous, seen := b.levels[d]
+		if !seen {
+			previous = sdl.JOYSTICK_POWER_UNKNOWN
+		} else if !due {
+			continue
+		}
+		level := d.PowerLevel()
+		b.levels[d] = level
+		if level != previous {
+			changes = append(changes, BatteryChange{Device: d, Level: level, Previous: previous})
+		}
+	}
+	for d := range b.levels {
+		if !d.Connected {
+			delete(b.levels, d)
+		}
+	}
+	return changes
+}
@@ -0,0 +1,249 @@
+// Package input collects the joystick and keyboard handling shared by the demos.
+package input
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Profile describes the controls and preferred settings of one device.
+// A joystick is matched against a profile by GUID first, then by USB
+// vendor/product ID and finally by a case-insensitive substring of its name.
+type Profile struct {
+	Name        string         `json:"name"`
+	GUIDs       []string       `json:"guids,omitempty"`
+	USBIDs      []USBID        `json:"usb_ids,omitempty"`
+	Names       []string       `json:"names,omitempty"`
+	Buttons     map[int]string `json:"buttons,omitempty"`
+	Axes        map[int]string `json:"axes,omitempty"`
+	Hats        map[int]string `json:"hats,omitempty"`
+	ExitChord   []int          `json:"exit_chord,omitempty"` // buttons held together to quit
+	Hotkeys     []Hotkey       `json:"hotkeys,omitempty"`    // replace DefaultButtonHotkeys
+	Width       int32          `json:"width,omitempty"`      // window size on the device's screen
+	Height      int32          `json:"height,omitempty"`
+	VideoDriver string         `json:"video_driver,omitempty"`
+	AudioDriver string         `json:"audio_driver,omitempty"`
+}
+
+// USBID is a vendor/product pair as reported by SDL.
+type USBID struct {
+	Vendor  int `json:"vendor"`
+	Product int `json:"product"`
+}
+
+// ButtonName returns the label of button i, or a generic one when the profile doesn't name it.
+func (p *Profile) ButtonName(i int) string {
+	if name, ok := p.Buttons[i]; ok {
+		return name
+	}
+	return fmt.Sprintf("B%d", i)
+}
+
+// AxisName returns the label of axis i.
+func (p *Profile) AxisName(i int) string {
+	if name, ok := p.Axes[i]; ok {
+		return name
+	}
+	return fmt.Sprintf("A%d", i)
+}
+
+// HatName returns the label of hat i.
+func (p *Profile) HatName(i int) string {
+	if name, ok := p.Hats[i]; ok {
+		return name
+	}
+	return fmt.Sprintf("H%d", i)
+}
+
+// Axis returns the index of the axis labelled name, or -1.
+func (p *Profile) Axis(name string) int {
+	for i, n := range p.Axes {
+		if strings.EqualFold(n, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Button returns the index of the button labelled name, or -1.
+func (p *Profile) Button(name string) int {
+	for i, n := range p.Buttons {
+		if strings.EqualFold(n, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ExitPressed reports whether every button of the exit chord is held.
+// pressed is asked for the state of a single button.
+func (p *Profile) ExitPressed(pressed func(button int) bool) bool {
+	if len(p.ExitChord) == 0 {
+		return false
+	}
+	for _, b := range p.ExitChord {
+		if !pressed(b) {
+			return false
+		}
+	}
+	return true
+}
+
+// ApplyHints selects the preferred video and audio drivers.
+// It only has an effect before the video and audio subsystems are initialized.
+func (p *Profile) ApplyHints() {
+	if p.VideoDriver != "" {
+		sdl.SetHint(sdl.HINT_VIDEODRIVER, p.VideoDriver)
+	}
+	if p.AudioDriver != "" {
+		sdl.SetHint("SDL_AUDIODRIVER", p.AudioDriver)
+	}
+}
+
+func (p *Profile) matchGUID(guid string) bool {
+	for _, g := range p.GUIDs {
+		if strings.EqualFold(g, guid) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Profile) matchUSB(vendor, product int) bool {
+	for _, id := range p.USBIDs {
+		if id.Vendor == vendor && id.Product == product {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Profile) matchName(name string) bool {
+	name = strings.ToLower(name)
+	for _, n := range p.Names {
+		if n != "" && strings.Contains(name, strings.ToLower(n)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Profiles is a registry of device profiles. Profiles added later take
+// precedence over earlier ones, so user files override the built-in set.
+type Profiles struct {
+	list     []*Profile
+	Fallback *Profile
+}
+
+// NewProfiles returns a registry holding the built-in profiles.
+func NewProfiles() *Profiles {
+	ps := &Profiles{Fallback: GenericProfile()}
+	for _, p := range builtinProfiles() {
+		ps.Add(p)
+	}
+	return ps
+}
+
+// Add registers p, replacing any profile with the same name.
+func (ps *Profiles) Add(p *Profile) {
+	for i, old := range ps.list {
+		if strings.EqualFold(old.Name, p.Name) {
+			ps.list = append(ps.list[:i], ps.list[i+1:]...)
+			break
+		}
+	}
+	ps.list = append(ps.list, p)
+}
+
+// Get returns the profile called name, or nil.
+func (ps *Profiles) Get(name string) *Profile {
+	for _, p := range ps.list {
+		if strings.EqualFold(p.Name, name) {
+			return p
+		}
+	}
+	return nil
+}
+
+// All returns the registered profiles.
+func (ps *Profiles) All() []*Profile {
+	return ps.list
+}
+
+// LoadFile reads a JSON array of profiles and adds them to the registry.
+// A profile with the name of an existing one replaces it.
+func (ps *Profiles) LoadFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	var list []*Profile
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("%v: %v", filename, err)
+	}
+	for i, p := range list {
+		if p.Name == "" {
+			return fmt.Errorf("%v: profile %d has no name", filename, i)
+		}
+		ps.Add(p)
+	}
+	return nil
+}
+
+// SaveFile writes every registered profile as JSON, ready to be edited and loaded back.
+func (ps *Profiles) SaveFile(filename string) error {
+	data, err := json.MarshalIndent(ps.list, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
+
+// Match returns the profile for a device, or Fallback when nothing matches.
+func (ps *Profiles) Match(guid, name string, vendor, product int) *Profile {
+	for i := len(ps.list) - 1; i >= 0; i-- {
+		if ps.list[i].matchGUID(guid) {
+			return ps.list[i]
+		}
+	}
+	for i := len(ps.list) - 1; i >= 0; i-- {
+		if ps.list[i].matchUSB(vendor, product) {
+			return ps.list[i]
+		}
+	}
+	for i := len(ps.list) - 1; i >= 0; i-- {
+		if ps.list[i].matchName(name) {
+			return ps.list[i]
+		}
+	}
+	return ps.Fallback
+}
+
+// MatchDevice returns the profile for the joystick at device index, as
+// found in JoyDeviceAddedEvent.Which.
+func (ps *Profiles) MatchDevice(index int) *Profile {
+	return ps.Match(sdl.JoystickGetGUIDString(sdl.JoystickGetDeviceGUID(index)),
+		sdl.JoystickNameForIndex(index),
+		sdl.JoystickGetDeviceVendor(index), sdl.JoystickGetDeviceProduct(index))
+}
+
+// MatchJoystick returns the profile for an opened joystick.
+func (ps *Profiles) MatchJoystick(joy *sdl.Joystick) *Profile {
+	return ps.Match(sdl.JoystickGetGUIDString(joy.GUID()), joy.Name(), joy.Vendor(), joy.Product())
+}
+
+// Detect returns the profile of the first attached joystick, or Fallback.
+// Call it after sdl.Init(sdl.INIT_JOYSTICK) and before the video subsystem
+// is started so that ApplyHints can still pick the drivers.
+func (ps *Profiles) Detect() *Profile {
+	for i := 0; i < sdl.NumJoysticks(); i++ {
+		if p := ps.MatchDevice(i); p != ps.Fallback {
+			return p
+		}
+	}
+	return ps.Fallback
+}
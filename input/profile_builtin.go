@@ -0,0 +1,92 @@
+package input
+
+// GenericProfile is used for devices no profile matches. Its layout is the
+// one the joystick demos have always assumed (XInput order, SELECT+START exit).
+func GenericProfile() *Profile {
+	return &Profile{
+		Name: "Generic",
+		Buttons: map[int]string{
+			0: "A", 1: "B", 2: "X", 3: "Y", 4: "L1", 5: "R1", 6: "SELECT", 7: "START",
+		},
+		Axes:      map[int]string{0: "LX", 1: "LY"},
+		Hats:      map[int]string{0: "DPAD"},
+		ExitChord: []int{6, 7},
+		Width:     640,
+		Height:    480,
+	}
+}
+
+func builtinProfiles() []*Profile {
+	return []*Profile{
+		{
+			// Anbernic RG35XX, stock and GarlicOS
+			Name:  "RG35XX",
+			Names: []string{"Deeplay-keys"},
+			Buttons: map[int]string{
+				0: "A", 1: "B", 2: "X", 3: "Y", 4: "L1", 5: "R1",
+				6: "SELECT", 7: "START", 8: "MENU", 9: "L2", 10: "R2",
+			},
+			Hats:        map[int]string{0: "DPAD"},
+			ExitChord:   []int{6, 7},
+			Width:       640,
+			Height:      480,
+			AudioDriver: "alsa",
+		},
+		{
+			// Anbernic RG353P running ArkOS/JELOS, the d-pad is reported as buttons
+			Name:   "RG353P",
+			GUIDs:  []string{"190000004b4800000111000000010000"},
+			USBIDs: []USBID{{Vendor: 0x484b, Product: 0x1101}},
+			Names:  []string{"retrogame_joypad"},
+			Buttons: map[int]string{
+				0: "B", 1: "A", 2: "X", 3: "Y", 4: "L1", 5: "R1", 6: "L2", 7: "R2",
+				8: "SELECT", 9: "START", 10: "MENU", 11: "L3", 12: "R3",
+				13: "UP", 14: "DOWN", 15: "LEFT", 16: "RIGHT",
+			},
+			Axes:        map[int]string{0: "LX", 1: "LY", 2: "RX", 3: "RY"},
+			ExitChord:   []int{8, 9},
+			Width:       640,
+			Height:      480,
+			VideoDriver: "KMSDRM",
+			AudioDriver: "alsa",
+		},
+		{
+			// Valve Steam Deck, either through hidapi or Steam's virtual gamepad
+			Name:   "Steam Deck",
+			GUIDs:  []string{KeyPadGUID},
+			USBIDs: []USBID{{Vendor: 0x28de, Product: 0x1205}, {Vendor: 0x28de, Product: 0x11ff}},
+			Names:  []string{"Steam Deck", "Steam Virtual Gamepad"},
+			Buttons: map[int]string{
+				0: "A", 1: "B", 2: "X", 3: "Y", 4: "L1", 5: "R1",
+				6: "SELECT", 7: "START", 8: "STEAM", 9: "L3", 10: "R3",
+			},
+			Axes:        map[int]string{0: "LX", 1: "LY", 2: "L2", 3: "RX", 4: "RY", 5: "R2"},
+			Hats:        map[int]string{0: "DPAD"},
+			ExitChord:   []int{6, 7},
+			Width:       1280,
+			Height:      800,
+			VideoDriver: "x11",
+			AudioDriver: "pulseaudio",
+		},
+		{
+			// On-screen gamepad of package vpad
+			Name:  "Touch Gamepad",
+			Names: []string{"Touch Gamepad"},
+			Buttons: map[int]string{
+				0: "A", 1: "B", 2: "X", 3: "Y", 4: "L1", 5: "R1", 6: "SELECT", 7: "START",
+			},
+			Axes:      map[int]string{0: "LX", 1: "LY", 2: "RX", 3: "RY"},
+			Hats:      map[int]string{0: "DPAD"},
+			ExitChord: []int{6, 7},
+		},
+		{
+			// Keyboard halves of a Players manager, only looked up by name
+			Name: "Keyboard",
+			Buttons: map[int]string{
+				0: "A", 1: "B", 2: "X", 3: "Y", 4: "L1", 5: "R1", 6: "SELECT", 7: "START",
+			},
+			Hats:      map[int]string{0: "DPAD"},
+			ExitChord: []int{6, 7},
+		},
+	}
+}
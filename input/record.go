@@ -0,0 +1,562 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Recordings are gzip streams starting with recordMagic and recordVersion
+// as a uvarint. Each record is the frame delta and the record kind as
+// uvarints, followed by the fields of the event in the order of
+// codec.event, or by a DeviceInfo for recordDevice. recordEnd closes the
+// stream and marks the number of recorded frames.
+const recordMagic = "GOSDLREC"
+
+// recordVersion changes whenever a record is encoded differently.
+// Version 1 held the memory of the event structs.
+const recordVersion = 2
+
+const (
+	recordDevice byte = iota + 1
+	recordKeyboard
+	recordTextInput
+	recordMouseMotion
+	recordMouseButton
+	recordMouseWheel
+	recordJoyAxis
+	recordJoyBall
+	recordJoyHat
+	recordJoyButton
+	recordJoyDeviceAdded
+	recordJoyDeviceRemoved
+	recordControllerAxis
+	recordControllerButton
+	recordControllerDevice
+	recordTouchFinger
+	recordMultiGesture
+	recordEnd
+)
+
+func recordKind(event sdl.Event) byte {
+	switch event.(type) {
+	case *sdl.KeyboardEvent:
+		return recordKeyboard
+	case *sdl.TextInputEvent:
+		return recordTextInput
+	case *sdl.MouseMotionEvent:
+		return recordMouseMotion
+	case *sdl.MouseButtonEvent:
+		return recordMouseButton
+	case *sdl.MouseWheelEvent:
+		return recordMouseWheel
+	case *sdl.JoyAxisEvent:
+		return recordJoyAxis
+	case *sdl.JoyBallEvent:
+		return recordJoyBall
+	case *sdl.JoyHatEvent:
+		return recordJoyHat
+	case *sdl.JoyButtonEvent:
+		return recordJoyButton
+	case *sdl.JoyDeviceAddedEvent:
+		return recordJoyDeviceAdded
+	case *sdl.JoyDeviceRemovedEvent:
+		return recordJoyDeviceRemoved
+	case *sdl.ControllerAxisEvent:
+		return recordControllerAxis
+	case *sdl.ControllerButtonEvent:
+		return recordControllerButton
+	case *sdl.ControllerDeviceEvent:
+		return recordControllerDevice
+	case *sdl.TouchFingerEvent:
+		return recordTouchFinger
+	case *sdl.MultiGestureEvent:
+		return recordMultiGesture
+	}
+	return 0
+}
+
+// codec reads or writes the fields of a record, so both directions share
+// one list of fields per event type. Integers are varints, floats their
+// bits. The first error stops it.
+type codec struct {
+	w   *bufio.Writer // nil when reading
+	r   *bufio.Reader
+	err error
+}
+
+func (c *codec) uvarint(v *uint64) {
+	if c.err != nil {
+		return
+	}
+	if c.w != nil {
+		var buf [binary.MaxVarintLen64]byte
+		_, c.err = c.w.Write(buf[:binary.PutUvarint(buf[:], *v)])
+	} else {
+		*v, c.err = binary.ReadUvarint(c.r)
+	}
+}
+
+func (c *codec) varint(v *int64) {
+	if c.err != nil {
+		return
+	}
+	if c.w != nil {
+		var buf [binary.MaxVarintLen64]byte
+		_, c.err = c.w.Write(buf[:binary.PutVarint(buf[:], *v)])
+	} else {
+		*v, c.err = binary.ReadVarint(c.r)
+	}
+}
+
+func unsigned[T ~uint8 | ~uint16 | ~uint32](c *codec, p *T) {
+	v := uint64(*p)
+	c.uvarint(&v)
+	if c.err == nil && uint64(T(v)) != v {
+		c.err = fmt.Errorf("value %d out of range", v)
+	}
+	*p = T(v)
+}
+
+func signed[T ~int16 | ~int32 | ~int64](c *codec, p *T) {
+	v := int64(*p)
+	c.varint(&v)
+	if c.err == nil && int64(T(v)) != v {
+		c.err = fmt.Errorf("value %d out of range", v)
+	}
+	*p = T(v)
+}
+
+func (c *codec) float(p *float32) {
+	v := math.Float32bits(*p)
+	unsigned(c, &v)
+	*p = math.Float32frombits(v)
+}
+
+// text handles a NUL terminated buffer like TextInputEvent.Text.
+func (c *codec) text(buf []byte) {
+	n := uint64(len(buf))
+	if i := bytes.IndexByte(buf, 0); i >= 0 {
+		n = uint64(i)
+	}
+	c.uvarint(&n)
+	if c.err != nil || c.w != nil {
+		if c.err == nil {
+			_, c.err = c.w.Write(buf[:n])
+		}
+		return
+	}
+	if n > uint64(len(buf)) {
+		c.err = fmt.Errorf("text of %d bytes", n)
+		return
+	}
+	_, c.err = io.ReadFull(c.r, buf[:n])
+}
+
+func (c *codec) string(s *string) {
+	n := uint64(len(*s))
+	c.uvarint(&n)
+	if c.err != nil {
+		return
+	}
+	if c.w != nil {
+		_, c.err = c.w.WriteString(*s)
+		return
+	}
+	if n > 1024 {
+		c.err = fmt.Errorf("invalid string length %d", n)
+		return
+	}
+	buf := make([]byte, n)
+	_, c.err = io.ReadFull(c.r, buf)
+	*s = string(buf)
+}
+
+// event handles the fields of an event returned by newRecordEvent. Padding
+// is left out.
+func (c *codec) event(event sdl.Event) {
+	switch t := event.(type) {
+	case *sdl.KeyboardEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		unsigned(c, &t.WindowID)
+		unsigned(c, &t.State)
+		unsigned(c, &t.Repeat)
+		unsigned(c, &t.Keysym.Scancode)
+		signed(c, &t.Keysym.Sym)
+		unsigned(c, &t.Keysym.Mod)
+	case *sdl.TextInputEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		unsigned(c, &t.WindowID)
+		c.text(t.Text[:])
+	case *sdl.MouseMotionEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		unsigned(c, &t.WindowID)
+		unsigned(c, &t.Which)
+		unsigned(c, &t.State)
+		signed(c, &t.X)
+		signed(c, &t.Y)
+		signed(c, &t.XRel)
+		signed(c, &t.YRel)
+	case *sdl.MouseButtonEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		unsigned(c, &t.WindowID)
+		unsigned(c, &t.Which)
+		unsigned(c, &t.Button)
+		unsigned(c, &t.State)
+		unsigned(c, &t.Clicks)
+		signed(c, &t.X)
+		signed(c, &t.Y)
+	case *sdl.MouseWheelEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		unsigned(c, &t.WindowID)
+		unsigned(c, &t.Which)
+		signed(c, &t.X)
+		signed(c, &t.Y)
+		unsigned(c, &t.Direction)
+		c.float(&t.PreciseX)
+		c.float(&t.PreciseY)
+	case *sdl.JoyAxisEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		signed(c, &t.Which)
+		unsigned(c, &t.Axis)
+		signed(c, &t.Value)
+	case *sdl.JoyBallEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		signed(c, &t.Which)
+		unsigned(c, &t.Ball)
+		signed(c, &t.XRel)
+		signed(c, &t.YRel)
+	case *sdl.JoyHatEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		signed(c, &t.Which)
+		unsigned(c, &t.Hat)
+		unsigned(c, &t.Value)
+	case *sdl.JoyButtonEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		signed(c, &t.Which)
+		unsigned(c, &t.Button)
+		unsigned(c, &t.State)
+	case *sdl.JoyDeviceAddedEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		signed(c, &t.Which)
+	case *sdl.JoyDeviceRemovedEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		signed(c, &t.Which)
+	case *sdl.ControllerAxisEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		signed(c, &t.Which)
+		unsigned(c, &t.Axis)
+		signed(c, &t.Value)
+	case *sdl.ControllerButtonEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		signed(c, &t.Which)
+		unsigned(c, &t.Button)
+		unsigned(c, &t.State)
+	case *sdl.ControllerDeviceEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		signed(c, &t.Which)
+	case *sdl.TouchFingerEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		signed(c, &t.TouchID)
+		signed(c, &t.FingerID)
+		c.float(&t.X)
+		c.float(&t.Y)
+		c.float(&t.DX)
+		c.float(&t.DY)
+		c.float(&t.Pressure)
+	case *sdl.MultiGestureEvent:
+		unsigned(c, &t.Type)
+		unsigned(c, &t.Timestamp)
+		signed(c, &t.TouchID)
+		c.float(&t.DTheta)
+		c.float(&t.DDist)
+		c.float(&t.X)
+		c.float(&t.Y)
+		unsigned(c, &t.NumFingers)
+	}
+}
+
+// device handles a DeviceInfo.
+func (c *codec) device(info *DeviceInfo) {
+	index, buttons, axes, hats := int64(info.Index), int64(info.Buttons), int64(info.Axes), int64(info.Hats)
+	c.varint(&index)
+	signed(c, &info.ID)
+	c.varint(&buttons)
+	c.varint(&axes)
+	c.varint(&hats)
+	c.string(&info.GUID)
+	c.string(&info.Name)
+	info.Index, info.Buttons, info.Axes, info.Hats = int(index), int(buttons), int(axes), int(hats)
+}
+
+func newRecordEvent(kind byte) sdl.Event {
+	switch kind {
+	case recordKeyboard:
+		return &sdl.KeyboardEvent{}
+	case recordTextInput:
+		return &sdl.TextInputEvent{}
+	case recordMouseMotion:
+		return &sdl.MouseMotionEvent{}
+	case recordMouseButton:
+		return &sdl.MouseButtonEvent{}
+	case recordMouseWheel:
+		return &sdl.MouseWheelEvent{}
+	case recordJoyAxis:
+		return &sdl.JoyAxisEvent{}
+	case recordJoyBall:
+		return &sdl.JoyBallEvent{}
+	case recordJoyHat:
+		return &sdl.JoyHatEvent{}
+	case recordJoyButton:
+		return &sdl.JoyButtonEvent{}
+	case recordJoyDeviceAdded:
+		return &sdl.JoyDeviceAddedEvent{}
+	case recordJoyDeviceRemoved:
+		return &sdl.JoyDeviceRemovedEvent{}
+	case recordControllerAxis:
+		return &sdl.ControllerAxisEvent{}
+	case recordControllerButton:
+		return &sdl.ControllerButtonEvent{}
+	case recordControllerDevice:
+		return &sdl.ControllerDeviceEvent{}
+	case recordTouchFinger:
+		return &sdl.TouchFingerEvent{}
+	case recordMultiGesture:
+		return &sdl.MultiGestureEvent{}
+	}
+	return nil
+}
+
+// Record is one recorded input event.
+type Record struct {
+	Frame  uint32
+	Event  sdl.Event
+	Device *DeviceInfo // set for the JoyDeviceAddedEvent of a known device
+}
+
+// Recorder writes input events with their frame numbers to a file.
+type Recorder struct {
+	Frame    uint32
+	Registry *Registry // optional, lets joysticks be recreated on replay
+
+	f         *os.File
+	gz        *gzip.Writer
+	c         codec
+	lastFrame uint32
+}
+
+// NewRecorder creates filename and starts recording at frame 0.
+func NewRecorder(filename string) (*Recorder, error) {
+	f, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	gz := gzip.NewWriter(f)
+	r := &Recorder{f: f, gz: gz, c: codec{w: bufio.NewWriter(gz)}}
+	_, r.c.err = r.c.w.WriteString(recordMagic)
+	version := uint64(recordVersion)
+	r.c.uvarint(&version)
+	if r.c.err != nil {
+		f.Close()
+		return nil, r.c.err
+	}
+	return r, nil
+}
+
+func (r *Recorder) header(kind byte) {
+	delta, k := uint64(r.Frame-r.lastFrame), uint64(kind)
+	r.c.uvarint(&delta)
+	r.c.uvarint(&k)
+	r.lastFrame = r.Frame
+}
+
+// Record stores event at the current frame. Events that are not input are
+// ignored. Call it after the registry has handled the event.
+func (r *Recorder) Record(event sdl.Event) error {
+	if r.c.err != nil {
+		return r.c.err
+	}
+	kind := recordKind(event)
+	if kind == 0 {
+		return nil
+	}
+	if t, ok := event.(*sdl.JoyDeviceAddedEvent); ok && r.Registry != nil {
+		if d := r.Registry.Device(sdl.JoystickGetDeviceInstanceID(int(t.Which))); d != nil {
+			info := d.Info()
+			info.Index = int(t.Which)
+			r.header(recordDevice)
+			r.c.device(&info)
+		}
+	}
+	r.header(kind)
+	r.c.event(event)
+	return r.c.err
+}
+
+// NextFrame advances the frame counter; call it once per game loop.
+func (r *Recorder) NextFrame() {
+	r.Frame++
+}
+
+// Close writes the frame count, flushes and closes the file.
+func (r *Recorder) Close() error {
+	if r.c.err == nil {
+		r.header(recordEnd)
+	}
+	err := r.c.err
+	if e := r.c.w.Flush(); err == nil {
+		err = e
+	}
+	if e := r.gz.Close(); err == nil {
+		err = e
+	}
+	if e := r.f.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+// cutShort reports whether err means the file ends early, as when the
+// program was killed while recording.
+func cutShort(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// LoadRecording reads every record of a file written by a Recorder and
+// the number of frames it covers. A recording that was cut short ends
+// with its last complete record.
+func LoadRecording(filename string) ([]Record, uint32, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%v: %v", filename, err)
+	}
+	c := &codec{r: bufio.NewReader(gz)}
+	magic := make([]byte, len(recordMagic))
+	if _, err := io.ReadFull(c.r, magic); err != nil || string(magic) != recordMagic {
+		return nil, 0, fmt.Errorf("%v: not an input recording", filename)
+	}
+	var version uint64
+	if c.uvarint(&version); c.err != nil || version != recordVersion {
+		return nil, 0, fmt.Errorf("%v: recording version %d, want %d", filename, version, recordVersion)
+	}
+	var records []Record
+	var frame uint32
+	var device *DeviceInfo
+	for {
+		var delta, kind uint64
+		c.uvarint(&delta)
+		c.uvarint(&kind)
+		var event sdl.Event
+		switch byte(kind) {
+		case recordEnd:
+			if c.err == nil {
+				return records, frame + uint32(delta), nil
+			}
+		case recordDevice:
+			device = &DeviceInfo{}
+			c.device(device)
+		default:
+			if event = newRecordEvent(byte(kind)); event == nil && c.err == nil {
+				return nil, 0, fmt.Errorf("%v: record %d: unknown kind %d", filename, len(records), kind)
+			}
+			c.event(event)
+		}
+		if cutShort(c.err) {
+			// Recording was cut short, the last event ends it
+			return records, frame + 1, nil
+		} else if c.err != nil {
+			return nil, 0, fmt.Errorf("%v: record %d: %v", filename, len(records), c.err)
+		}
+		frame += uint32(delta)
+		if event == nil {
+			continue
+		}
+		rec := Record{Frame: frame, Event: event}
+		if _, ok := event.(*sdl.JoyDeviceAddedEvent); ok {
+			rec.Device, device = device, nil
+		}
+		records = append(records, rec)
+	}
+}
+
+// Player replays records frame by frame.
+type Player struct {
+	Records  []Record
+	Frames   uint32 // length of the recording
+	Frame    uint32
+	Registry *Registry // optional, recreates the recorded joysticks
+
+	pos int
+}
+
+// NewPlayer loads filename for replay.
+func NewPlayer(filename string) (*Player, error) {
+	records, frames, err := LoadRecording(filename)
+	if err != nil {
+		return nil, err
+	}
+	return &Player{Records: records, Frames: frames}, nil
+}
+
+// Done reports whether every recorded frame was replayed.
+func (p *Player) Done() bool {
+	return p.pos >= len(p.Records) && p.Frame >= p.Frames
+}
+
+// Step hands every event of the current frame to handle and advances to
+// the next frame. Use Push as handle to go through the SDL event queue,
+// or pass the input layer directly.
+func (p *Player) Step(handle func(sdl.Event)) {
+	for ; p.pos < len(p.Records) && p.Records[p.pos].Frame <= p.Frame; p.pos++ {
+		rec := p.Records[p.pos]
+		if rec.Device != nil && p.Registry != nil {
+			p.Registry.Expect(*rec.Device)
+		}
+		handle(rec.Event)
+	}
+	p.Frame++
+}
+
+// IgnoreJoysticks keeps the joysticks of this machine out of a replay, so
+// that one plugged in can't take the place of a recorded device. SDL stops
+// queueing their events, those already queued included, while events given
+// to Push still arrive. Call it after sdl.Init instead of enabling joystick
+// events.
+func IgnoreJoysticks() {
+	sdl.JoystickEventState(sdl.IGNORE)
+}
+
+// Push adds event to the SDL event queue, reporting failures on stderr.
+func Push(event sdl.Event) {
+	if _, err := sdl.PushEvent(event); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to push event: %s\n", err)
+	}
+}
@@ -0,0 +1,106 @@
+package input
+
+import (
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func writeRecording(t *testing.T, filename string, events []sdl.Event) {
+	t.Helper()
+	r, err := NewRecorder(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range events {
+		if err := r.Record(event); err != nil {
+			t.Fatal(err)
+		}
+		r.NextFrame()
+	}
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordingRoundTrip(t *testing.T) {
+	text := &sdl.TextInputEvent{Type: sdl.TEXTINPUT, WindowID: 1}
+	copy(text.Text[:], "héllo")
+	events := []sdl.Event{
+		&sdl.KeyboardEvent{Type: sdl.KEYDOWN, Timestamp: 40, State: sdl.PRESSED, Keysym: sdl.Keysym{Scancode: sdl.SCANCODE_A, Sym: sdl.K_a, Mod: sdl.KMOD_LSHIFT}},
+		text,
+		&sdl.MouseWheelEvent{Type: sdl.MOUSEWHEEL, Y: -1, PreciseY: -1.5},
+		&sdl.JoyAxisEvent{Type: sdl.JOYAXISMOTION, Which: 2, Axis: 1, Value: -32768},
+		&sdl.JoyHatEvent{Type: sdl.JOYHATMOTION, Which: 2, Value: sdl.HAT_LEFTUP},
+		&sdl.TouchFingerEvent{Type: sdl.FINGERDOWN, TouchID: -1, FingerID: 1 << 40, X: 0.25, Pressure: 1},
+	}
+	filename := filepath.Join(t.TempDir(), "session.rec")
+	writeRecording(t, filename, events)
+	records, frames, err := LoadRecording(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(events) || frames != uint32(len(events)) {
+		t.Fatalf("%d records over %d frames, want %d", len(records), frames, len(events))
+	}
+	for i, rec := range records {
+		if rec.Frame != uint32(i) || !reflect.DeepEqual(rec.Event, events[i]) {
+			t.Errorf("record %d: frame %d %+v, want %+v", i, rec.Frame, rec.Event, events[i])
+		}
+	}
+}
+
+func TestRecordingCutShort(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "session.rec")
+	var events []sdl.Event
+	for i := 0; i < 50; i++ {
+		events = append(events, &sdl.JoyButtonEvent{Type: sdl.JOYBUTTONDOWN, Button: uint8(i)})
+	}
+	writeRecording(t, filename, events)
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Without the gzip trailer, and cut inside the compressed data
+	for _, n := range []int{len(data) - 8, len(data) / 2} {
+		cut := filepath.Join(dir, "cut.rec")
+		if err := os.WriteFile(cut, data[:n], 0644); err != nil {
+			t.Fatal(err)
+		}
+		records, frames, err := LoadRecording(cut)
+		if err != nil {
+			t.Errorf("%d bytes: %v", n, err)
+			continue
+		}
+		// Every event has its own frame, the recording ends after the last
+		if frames != uint32(len(records)) {
+			t.Errorf("%d bytes: %d records over %d frames", n, len(records), frames)
+		}
+		for i, rec := range records {
+			if !reflect.DeepEqual(rec.Event, events[i]) {
+				t.Errorf("%d bytes: record %d is %+v", n, i, rec.Event)
+			}
+		}
+	}
+}
+
+func TestRecordingVersion(t *testing.T) {
+	// Version 1 files start with the same magic
+	filename := filepath.Join(t.TempDir(), "old.rec")
+	f, err := os.Create(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gz := gzip.NewWriter(f)
+	gz.Write([]byte(recordMagic + "\x01\x00\x12"))
+	gz.Close()
+	f.Close()
+	if _, _, err := LoadRecording(filename); err == nil {
+		t.Error("version 1 recording loaded")
+	}
+}
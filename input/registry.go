@@ -0,0 +1,345 @@
+package input
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Change tells what a joystick event did to the registry.
+type Change int
+
+const (
+	NoChange Change = iota
+	Added
+	Reconnected
+	Removed
+)
+
+// Device is an opened joystick together with the last state it reported.
+type Device struct {
+	Joystick  *sdl.Joystick  // nil for devices recreated from a recording
+	ID        sdl.JoystickID // instance ID, valid while connected
+	GUID      string
+	Name      string
+	Profile   *Profile
+	Connected bool
+
+	buttons []uint8
+	axes    []int16
+	hats    []uint8
+	removed uint64 // order of the last removal
+}
+
+// Button reports whether button i is held. Unknown buttons are released.
+func (d *Device) Button(i int) bool {
+	return i >= 0 && i < len(d.buttons) && d.buttons[i] == sdl.PRESSED
+}
+
+// Axis returns the value of axis i, 0 for unknown axes.
+func (d *Device) Axis(i int) int16 {
+	if i < 0 || i >= len(d.axes) {
+		return 0
+	}
+	return d.axes[i]
+}
+
+// Hat returns the position of hat i, HAT_CENTERED for unknown hats.
+func (d *Device) Hat(i int) uint8 {
+	if i < 0 || i >= len(d.hats) {
+		return sdl.HAT_CENTERED
+	}
+	return d.hats[i]
+}
+
+// NumButtons returns how many buttons the device reported when opened.
+func (d *Device) NumButtons() int { return len(d.buttons) }
+
+// NumAxes returns how many axes the device reported when opened.
+func (d *Device) NumAxes() int { return len(d.axes) }
+
+// NumHats returns how many hats the device reported when opened.
+func (d *Device) NumHats() int { return len(d.hats) }
+
+// Info describes the device well enough to stand in for it during a replay.
+func (d *Device) Info() DeviceInfo {
+	return DeviceInfo{
+		ID:      d.ID,
+		GUID:    d.GUID,
+		Name:    d.Name,
+		Buttons: len(d.buttons),
+		Axes:    len(d.axes),
+		Hats:    len(d.hats),
+	}
+}
+
+// Snapshot returns a copy of the current state.
+func (d *Device) Snapshot() Snapshot {
+	return Snapshot{
+		ID:      d.ID,
+		Buttons: append([]uint8(nil), d.buttons...),
+		Axes:    append([]int16(nil), d.axes...),
+		Hats:    append([]uint8(nil), d.hats...),
+	}
+}
+
+// grow makes room for controls the device didn't announce when opened.
+func grow[T any](s []T, i int, zero T) []T {
+	for len(s) <= i {
+		s = append(s, zero)
+	}
+	return s
+}
+
+func (d *Device) open(joy *sdl.Joystick) {
+	d.Joystick = joy
+	d.ID = joy.InstanceID()
+	d.GUID = sdl.JoystickGetGUIDString(joy.GUID())
+	d.Name = joy.Name()
+	d.Connected = true
+	d.buttons = make([]uint8, joy.NumButtons())
+	for i := range d.buttons {
+		d.buttons[i] = joy.Button(i)
+	}
+	d.axes = make([]int16, joy.NumAxes())
+	for i := range d.axes {
+		d.axes[i] = joy.Axis(i)
+	}
+	d.hats = make([]uint8, joy.NumHats())
+	for i := range d.hats {
+		d.hats[i] = joy.Hat(i)
+	}
+}
+
+// DeviceInfo is what a recording keeps about a joystick.
+type DeviceInfo struct {
+	Index   int // device index of the JoyDeviceAddedEvent
+	ID      sdl.JoystickID
+	GUID    string
+	Name    string
+	Buttons int
+	Axes    int
+	Hats    int
+}
+
+// Snapshot is a frozen copy of a device state. Its accessors accept any
+// index, so callers never have to check the control counts.
+type Snapshot struct {
+	ID      sdl.JoystickID
+	Buttons []uint8
+	Axes    []int16
+	Hats    []uint8
+}
+
+// Button reports whether button i was held.
+func (s Snapshot) Button(i int) bool {
+	return i >= 0 && i < len(s.Buttons) && s.Buttons[i] == sdl.PRESSED
+}
+
+// Axis returns the value of axis i.
+func (s Snapshot) Axis(i int) int16 {
+	if i < 0 || i >= len(s.Axes) {
+		return 0
+	}
+	return s.Axes[i]
+}
+
+// Hat returns the position of hat i.
+func (s Snapshot) Hat(i int) uint8 {
+	if i < 0 || i >= len(s.Hats) {
+		return sdl.HAT_CENTERED
+	}
+	return s.Hats[i]
+}
+
+// Registry keeps every opened joystick by instance ID.
+//
+// JoyDeviceAddedEvent.Which is a device index while every other joystick
+// event carries an instance ID; the registry takes care of the difference.
+// A device that is unplugged is kept, and the same *Device is reused when
+// a joystick with the same GUID comes back. Identical pads share a GUID
+// and the SDL version this builds against reports no serial or path, so
+// the one removed last is taken.
+type Registry struct {
+	Profiles *Profiles // optional, Device.Profile is Generic without it
+
+	devices  map[sdl.JoystickID]*Device
+	all      []*Device
+	expected map[int]DeviceInfo
+	removals uint64
+}
+
+// NewRegistry returns an empty registry. profiles may be nil.
+func NewRegistry(profiles *Profiles) *Registry {
+	return &Registry{
+		Profiles: profiles,
+		devices:  make(map[sdl.JoystickID]*Device),
+	}
+}
+
+// Device returns the connected device with instance ID id, or nil.
+func (r *Registry) Device(id sdl.JoystickID) *Device {
+	return r.devices[id]
+}
+
+// Devices returns the connected devices in the order they were first seen.
+func (r *Registry) Devices() []*Device {
+	var list []*Device
+	for _, d := range r.all {
+		if d.Connected {
+			list = append(list, d)
+		}
+	}
+	return list
+}
+
+// First returns the earliest connected device, or nil.
+func (r *Registry) First() *Device {
+	for _, d := range r.all {
+		if d.Connected {
+			return d
+		}
+	}
+	return nil
+}
+
+// Expect makes the next JoyDeviceAddedEvent for info.Index register a
+// device without opening a joystick. Replays use it to recreate the
+// recorded devices on a machine that doesn't have them.
+func (r *Registry) Expect(info DeviceInfo) {
+	if r.expected == nil {
+		r.expected = make(map[int]DeviceInfo)
+	}
+	r.expected[info.Index] = info
+}
+
+func (r *Registry) openExpected(info DeviceInfo) (*Device, Change) {
+	delete(r.expected, info.Index)
+	if d, ok := r.devices[info.ID]; ok {
+		return d, NoChange
+	}
+	d, change := r.reuse(info.GUID)
+	*d = Device{
+		ID:        info.ID,
+		GUID:      info.GUID,
+		Name:      info.Name,
+		Connected: true,
+		buttons:   make([]uint8, info.Buttons),
+		axes:      make([]int16, info.Axes),
+		hats:      make([]uint8, info.Hats),
+	}
+	if r.Profiles != nil {
+		d.Profile = r.Profiles.Match(info.GUID, info.Name, 0, 0)
+	} else {
+		d.Profile = GenericProfile()
+	}
+	r.devices[d.ID] = d
+	return d, change
+}
+
+// Open opens the joystick at device index and registers it. Opening an
+// index that is already registered returns the existing device.
+func (r *Registry) Open(index int) (*Device, Change) {
+	if info, ok := r.expected[index]; ok {
+		return r.openExpected(info)
+	}
+	joy := sdl.JoystickOpen(index)
+	if joy == nil {
+		return nil, NoChange
+	}
+	if d, ok := r.devices[joy.InstanceID()]; ok {
+		// SDL reference counts opened joysticks
+		joy.Close()
+		return d, NoChange
+	}
+	d, change := r.reuse(sdl.JoystickGetGUIDString(joy.GUID()))
+	d.open(joy)
+	if r.Profiles != nil {
+		d.Profile = r.Profiles.MatchJoystick(joy)
+	} else {
+		d.Profile = GenericProfile()
+	}
+	r.devices[d.ID] = d
+	return d, change
+}
+
+// reuse returns the disconnected device with guid removed last, or a new
+// device.
+func (r *Registry) reuse(guid string) (*Device, Change) {
+	var d *Device
+	for _, old := range r.all {
+		if !old.Connected && old.GUID == guid && (d == nil || old.removed > d.removed) {
+			d = old
+		}
+	}
+	if d != nil {
+		return d, Reconnected
+	}
+	d = &Device{}
+	r.all = append(r.all, d)
+	return d, Added
+}
+
+// Remove closes the device with instance ID id. It stays known so that it
+// can be reconnected.
+func (r *Registry) Remove(id sdl.JoystickID) *Device {
+	d, ok := r.devices[id]
+	if !ok {
+		return nil
+	}
+	delete(r.devices, id)
+	if d.Joystick != nil {
+		d.Joystick.Close()
+		d.Joystick = nil
+	}
+	d.Connected = false
+	r.removals++
+	d.removed = r.removals
+	for i := range d.buttons {
+		d.buttons[i] = sdl.RELEASED
+	}
+	for i := range d.axes {
+		d.axes[i] = 0
+	}
+	for i := range d.hats {
+		d.hats[i] = sdl.HAT_CENTERED
+	}
+	return d
+}
+
+// HandleEvent updates the registry from a joystick event. It returns the
+// device the event belongs to, or nil for other events.
+func (r *Registry) HandleEvent(event sdl.Event) (*Device, Change) {
+	switch t := event.(type) {
+	case *sdl.JoyDeviceAddedEvent:
+		return r.Open(int(t.Which))
+	case *sdl.JoyDeviceRemovedEvent:
+		if d := r.Remove(t.Which); d != nil {
+			return d, Removed
+		}
+	case *sdl.JoyButtonEvent:
+		if d := r.devices[t.Which]; d != nil {
+			d.buttons = grow(d.buttons, int(t.Button), sdl.RELEASED)
+			d.buttons[t.Button] = t.State
+			return d, NoChange
+		}
+	case *sdl.JoyAxisEvent:
+		if d := r.devices[t.Which]; d != nil {
+			d.axes = grow(d.axes, int(t.Axis), 0)
+			d.axes[t.Axis] = t.Value
+			return d, NoChange
+		}
+	case *sdl.JoyHatEvent:
+		if d := r.devices[t.Which]; d != nil {
+			d.hats = grow(d.hats, int(t.Hat), sdl.HAT_CENTERED)
+			d.hats[t.Hat] = t.Value
+			return d, NoChange
+		}
+	}
+	return nil, NoChange
+}
+
+// Close closes every connected device.
+func (r *Registry) Close() {
+	for id := range r.devices {
+		r.Remove(id)
+	}
+}
@@ -0,0 +1,74 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// plug registers a recorded device without opening a joystick.
+func plug(r *Registry, id sdl.JoystickID, guid string) (*Device, Change) {
+	r.Expect(DeviceInfo{Index: 0, ID: id, GUID: guid, Name: guid, Buttons: 4})
+	return r.HandleEvent(&sdl.JoyDeviceAddedEvent{Type: sdl.JOYDEVICEADDED, Which: 0})
+}
+
+func unplug(r *Registry, id sdl.JoystickID) (*Device, Change) {
+	return r.HandleEvent(&sdl.JoyDeviceRemovedEvent{Type: sdl.JOYDEVICEREMOVED, Which: id})
+}
+
+func TestRegistryReconnect(t *testing.T) {
+	r := NewRegistry(nil)
+	a, _ := plug(r, 1, "pad")
+	b, _ := plug(r, 2, "pad")
+	other, _ := plug(r, 3, "stick")
+	if a == b || len(r.Devices()) != 3 {
+		t.Fatalf("%d devices", len(r.Devices()))
+	}
+
+	// Two identical pads: the one unplugged last comes back first
+	unplug(r, 2)
+	unplug(r, 1)
+	if d, change := plug(r, 4, "pad"); d != a || change != Reconnected {
+		t.Errorf("first back: %v, want the pad removed last", change)
+	}
+	if d, change := plug(r, 5, "pad"); d != b || change != Reconnected {
+		t.Errorf("second back: %v, want the other pad", change)
+	}
+	if d, change := plug(r, 6, "pad"); d == a || d == b || change != Added {
+		t.Errorf("third pad: %v, want a new device", change)
+	}
+	if d, change := unplug(r, 3); d != other || change != Removed || other.Connected {
+		t.Errorf("unplug: %v", change)
+	}
+	if got := r.First(); got != a || got.ID != 4 {
+		t.Errorf("first device %+v", got)
+	}
+}
+
+func TestRegistryKeyPad(t *testing.T) {
+	r := NewRegistry(nil)
+	k := NewKeyPad(r)
+	k.Enabled = true
+	handle := func(e sdl.Event) { r.HandleEvent(e) }
+	if !k.Update(handle) || k.Mode() != PadEmulated {
+		t.Fatalf("mode %v without joysticks", k.Mode())
+	}
+	d := r.Device(KeyPadID)
+	if d == nil || d.GUID != KeyPadGUID || d.NumButtons() != 11 || d.NumAxes() != 6 {
+		t.Fatalf("emulated device %+v", d)
+	}
+	// The triggers rest at the low end, the sticks in the middle
+	if d.Axis(2) != -32768 || d.Axis(5) != -32768 || d.Axis(0) != 0 {
+		t.Errorf("axes at rest %d %d %d", d.Axis(0), d.Axis(2), d.Axis(5))
+	}
+
+	// A real joystick takes over
+	plug(r, 1, "pad")
+	if !k.Update(handle) || k.Mode() != PadNative || r.Device(KeyPadID) != nil || d.Connected {
+		t.Errorf("mode %v with a joystick", k.Mode())
+	}
+	unplug(r, 1)
+	if !k.Update(handle) || r.Device(KeyPadID) != d || !d.Connected {
+		t.Error("emulated device not back")
+	}
+}
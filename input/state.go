@@ -0,0 +1,309 @@
+package input
+
+import (
+	"fmt"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Control identifies a digital input: a key, a mouse button, a joystick
+// button or one direction of a joystick hat.
+type Control uint32
+
+const (
+	controlKey Control = (iota + 1) << 24
+	controlMouse
+	controlButton
+	controlHat
+	controlKind Control = 0xff << 24
+)
+
+// Key returns the control of a keyboard key.
+func Key(code sdl.Scancode) Control { return controlKey | Control(code) }
+
+// MouseButton returns the control of a mouse button, e.g. sdl.BUTTON_LEFT.
+func MouseButton(button uint8) Control { return controlMouse | Control(button) }
+
+// JoyButton returns the control of a joystick button.
+func JoyButton(button int) Control { return controlButton | Control(button) }
+
+// JoyHat returns the control of one direction of a hat, e.g. sdl.HAT_UP.
+func JoyHat(hat int, dir uint8) Control { return controlHat | Control(hat)<<8 | Control(dir) }
+
+func (c Control) String() string {
+	v := c &^ controlKind
+	switch c & controlKind {
+	case controlKey:
+		return sdl.GetScancodeName(sdl.Scancode(v))
+	case controlMouse:
+		return fmt.Sprintf("Mouse %d", v)
+	case controlButton:
+		return fmt.Sprintf("B%d", v)
+	case controlHat:
+		name := map[Control]string{sdl.HAT_UP: "UP", sdl.HAT_RIGHT: "RIGHT", sdl.HAT_DOWN: "DOWN", sdl.HAT_LEFT: "LEFT"}[v&0xff]
+		return fmt.Sprintf("H%d %s", v>>8, name)
+	}
+	return fmt.Sprintf("Control %#x", uint32(c))
+}
+
+func (c Control) joystick() bool {
+	kind := c & controlKind
+	return kind == controlButton || kind == controlHat
+}
+
+// AnyJoystick makes a State follow every joystick.
+const AnyJoystick sdl.JoystickID = -1
+
+// digital is the history of one control, its slices are indexed by frame
+// modulo the history size.
+type digital struct {
+	down     bool
+	since    int // frame of the last press
+	total    int // presses since the start
+	presses  []uint16
+	releases []uint16
+	end      []bool // down at the end of the frame
+}
+
+// analog is the history of one axis.
+type analog struct {
+	value  int16
+	values []int16
+}
+
+// State keeps the digital and analog input of the last frames. Feed it
+// every event of the PollEvent loop and call NextFrame once per frame.
+// Presses are counted as they come, so a tap shorter than a frame is still
+// seen by JustPressed and Down.
+type State struct {
+	Joystick sdl.JoystickID // joystick to follow, AnyJoystick for all
+	Frame    int            // current frame, counted from 0
+
+	size    int
+	digital map[Control]*digital
+	axes    map[int]*analog
+}
+
+// NewState returns a state remembering the last frames frames. It follows
+// every joystick.
+func NewState(frames int) *State {
+	if frames < 2 {
+		frames = 2
+	}
+	return &State{
+		Joystick: AnyJoystick,
+		size:     frames,
+		digital:  make(map[Control]*digital),
+		axes:     make(map[int]*analog),
+	}
+}
+
+func (s *State) slot(frame int) int {
+	return frame % s.size
+}
+
+func (s *State) track(c Control) *digital {
+	d, ok := s.digital[c]
+	if !ok {
+		d = &digital{presses: make([]uint16, s.size), releases: make([]uint16, s.size), end: make([]bool, s.size)}
+		s.digital[c] = d
+	}
+	return d
+}
+
+func (s *State) axis(i int) *analog {
+	a, ok := s.axes[i]
+	if !ok {
+		a = &analog{values: make([]int16, s.size)}
+		s.axes[i] = a
+	}
+	return a
+}
+
+func (s *State) set(c Control, down bool) {
+	d := s.track(c)
+	if d.down == down {
+		return
+	}
+	d.down = down
+	cur := s.slot(s.Frame)
+	if down {
+		d.presses[cur]++
+		d.total++
+		d.since = s.Frame
+	} else {
+		d.releases[cur]++
+	}
+	d.end[cur] = down
+}
+
+func (s *State) follows(id sdl.JoystickID) bool {
+	return s.Joystick == AnyJoystick || s.Joystick == id
+}
+
+// HandleEvent updates the current frame from an input event.
+func (s *State) HandleEvent(event sdl.Event) {
+	switch t := event.(type) {
+	case *sdl.KeyboardEvent:
+		if t.Repeat == 0 {
+			s.set(Key(t.Keysym.Scancode), t.State == sdl.PRESSED)
+		}
+	case *sdl.MouseButtonEvent:
+		s.set(MouseButton(t.Button), t.State == sdl.PRESSED)
+	case *sdl.JoyButtonEvent:
+		if s.follows(t.Which) {
+			s.set(JoyButton(int(t.Button)), t.State == sdl.PRESSED)
+		}
+	case *sdl.JoyHatEvent:
+		if s.follows(t.Which) {
+			for _, dir := range []uint8{sdl.HAT_UP, sdl.HAT_RIGHT, sdl.HAT_DOWN, sdl.HAT_LEFT} {
+				s.set(JoyHat(int(t.Hat), dir), t.Value&dir != 0)
+			}
+		}
+	case *sdl.JoyAxisEvent:
+		if s.follows(t.Which) {
+			a := s.axis(int(t.Axis))
+			a.value = t.Value
+			a.values[s.slot(s.Frame)] = t.Value
+		}
+	case *sdl.JoyDeviceRemovedEvent:
+		// Nothing stays held on a joystick that is gone
+		if s.Joystick != AnyJoystick && s.Joystick != t.Which {
+			break
+		}
+		for c := range s.digital {
+			if c.joystick() {
+				s.set(c, false)
+			}
+		}
+		for _, a := range s.axes {
+			a.value = 0
+			a.values[s.slot(s.Frame)] = 0
+		}
+	}
+}
+
+// NextFrame closes the current frame; call it once per game loop.
+func (s *State) NextFrame() {
+	s.Frame++
+	cur := s.slot(s.Frame)
+	for _, d := range s.digital {
+		d.presses[cur] = 0
+		d.releases[cur] = 0
+		d.end[cur] = d.down
+	}
+	for _, a := range s.axes {
+		a.values[cur] = a.value
+	}
+}
+
+// Held reports whether c is down now.
+func (s *State) Held(c Control) bool {
+	d, ok := s.digital[c]
+	return ok && d.down
+}
+
+// Down reports whether c is down now or was tapped during this frame.
+func (s *State) Down(c Control) bool {
+	d, ok := s.digital[c]
+	return ok && (d.down || d.presses[s.slot(s.Frame)] > 0)
+}
+
+// JustPressed reports whether c went down during this frame.
+func (s *State) JustPressed(c Control) bool {
+	return s.Presses(c) > 0
+}
+
+// JustReleased reports whether c went up during this frame.
+func (s *State) JustReleased(c Control) bool {
+	d, ok := s.digital[c]
+	return ok && d.releases[s.slot(s.Frame)] > 0
+}
+
+// Presses returns how often c went down during this frame.
+func (s *State) Presses(c Control) int {
+	d, ok := s.digital[c]
+	if !ok {
+		return 0
+	}
+	return int(d.presses[s.slot(s.Frame)])
+}
+
+// PressCount returns how often c went down during the last frames frames,
+// this one included.
+func (s *State) PressCount(c Control, frames int) int {
+	d, ok := s.digital[c]
+	if !ok {
+		return 0
+	}
+	n := 0
+	for i := 0; i < frames && i < s.size && i <= s.Frame; i++ {
+		n += int(d.presses[s.slot(s.Frame-i)])
+	}
+	return n
+}
+
+// TotalPresses returns how often c went down since the state was created.
+func (s *State) TotalPresses(c Control) int {
+	if d, ok := s.digital[c]; ok {
+		return d.total
+	}
+	return 0
+}
+
+// HeldFrames returns for how many frames c has been down, this one
+// included, or 0 when it is up.
+func (s *State) HeldFrames(c Control) int {
+	d, ok := s.digital[c]
+	if !ok || !d.down {
+		return 0
+	}
+	return s.Frame - d.since + 1
+}
+
+// HeldFor reports whether c has been down for at least frames frames.
+func (s *State) HeldFor(c Control, frames int) bool {
+	return s.HeldFrames(c) >= frames
+}
+
+// DownAt reports whether c was down at some point of the frame ago frames
+// before this one. Frames older than the history are reported as up.
+func (s *State) DownAt(c Control, ago int) bool {
+	d, ok := s.digital[c]
+	if !ok || ago < 0 || ago >= s.size || ago > s.Frame {
+		return false
+	}
+	// A release means it was down before, held from an earlier frame or
+	// pressed in this one
+	i := s.slot(s.Frame - ago)
+	return d.end[i] || d.presses[i] > 0 || d.releases[i] > 0
+}
+
+// Hat returns the directions of hat i held now.
+func (s *State) Hat(i int) uint8 {
+	var v uint8
+	for _, dir := range []uint8{sdl.HAT_UP, sdl.HAT_RIGHT, sdl.HAT_DOWN, sdl.HAT_LEFT} {
+		if s.Held(JoyHat(i, dir)) {
+			v |= dir
+		}
+	}
+	return v
+}
+
+// Axis returns the current value of axis i.
+func (s *State) Axis(i int) int16 {
+	if a, ok := s.axes[i]; ok {
+		return a.value
+	}
+	return 0
+}
+
+// AxisAt returns the value axis i had at the end of the frame ago frames
+// before this one.
+func (s *State) AxisAt(i, ago int) int16 {
+	a, ok := s.axes[i]
+	if !ok || ago < 0 || ago >= s.size || ago > s.Frame {
+		return 0
+	}
+	return a.values[s.slot(s.Frame-ago)]
+}
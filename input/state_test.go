@@ -0,0 +1,113 @@
+package input
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func button(b uint8, down bool) *sdl.JoyButtonEvent {
+	e := &sdl.JoyButtonEvent{Type: sdl.JOYBUTTONUP, Button: b, State: sdl.RELEASED}
+	if down {
+		e.Type, e.State = sdl.JOYBUTTONDOWN, sdl.PRESSED
+	}
+	return e
+}
+
+func TestStateDownAt(t *testing.T) {
+	s := NewState(8)
+	a := JoyButton(0)
+	// frame 0: tapped, 1: pressed, 2: held, 3: released, 4: up
+	s.HandleEvent(button(0, true))
+	s.HandleEvent(button(0, false))
+	s.NextFrame()
+	s.HandleEvent(button(0, true))
+	s.NextFrame()
+	s.NextFrame()
+	s.HandleEvent(button(0, false))
+	s.NextFrame()
+	for ago, want := range []bool{false, true, true, true, true} {
+		if got := s.DownAt(a, ago); got != want {
+			t.Errorf("DownAt(%d) = %v, want %v", ago, got, want)
+		}
+	}
+	if s.DownAt(a, 8) || s.DownAt(a, -1) {
+		t.Error("down outside the history")
+	}
+}
+
+func TestStateFollow(t *testing.T) {
+	s := NewState(8)
+	s.Joystick = 1
+	other := button(1, true)
+	other.Which = 2
+	s.HandleEvent(other)
+	if s.Held(JoyButton(1)) {
+		t.Error("button of another joystick held")
+	}
+	held := button(1, true)
+	held.Which = 1
+	s.HandleEvent(held)
+	s.HandleEvent(&sdl.JoyDeviceRemovedEvent{Type: sdl.JOYDEVICEREMOVED, Which: 1})
+	if s.Held(JoyButton(1)) || !s.JustReleased(JoyButton(1)) {
+		t.Error("button of a removed joystick still held")
+	}
+}
+
+func TestStateKeyPad(t *testing.T) {
+	r := NewRegistry(nil)
+	k := NewKeyPad(r)
+	k.Enabled = true
+	s := NewState(8)
+	handle := func(e sdl.Event) {
+		r.HandleEvent(e)
+		s.HandleEvent(e)
+	}
+	k.Update(handle)
+	keypad := func(code sdl.Scancode, down bool) bool {
+		return k.HandleEvent(key(code, down), handle)
+	}
+	mouse := func(b uint8, down bool) bool {
+		e := &sdl.MouseButtonEvent{Type: sdl.MOUSEBUTTONUP, Button: b}
+		if down {
+			e.Type, e.State = sdl.MOUSEBUTTONDOWN, sdl.PRESSED
+		}
+		return k.HandleEvent(e, handle)
+	}
+
+	if keypad(sdl.SCANCODE_Q, true) {
+		t.Error("unmapped key used up")
+	}
+	keypad(sdl.SCANCODE_RETURN, true)
+	keypad(sdl.SCANCODE_UP, true)
+	keypad(sdl.SCANCODE_LEFT, true)
+	keypad(sdl.SCANCODE_A, true)
+	if !s.Held(JoyButton(0)) || s.Hat(0) != sdl.HAT_LEFTUP || s.Axis(0) != -32768 {
+		t.Errorf("A %v, hat %d, left stick %d", s.Held(JoyButton(0)), s.Hat(0), s.Axis(0))
+	}
+	keypad(sdl.SCANCODE_D, true)
+	if s.Axis(0) != 0 {
+		t.Errorf("left and right together: %d", s.Axis(0))
+	}
+
+	// L2 is the right mouse button, R2 the left one
+	if s.Axis(2) != -32768 || s.Axis(5) != -32768 {
+		t.Errorf("triggers at rest %d %d", s.Axis(2), s.Axis(5))
+	}
+	mouse(sdl.BUTTON_RIGHT, true)
+	if s.Axis(2) != 32767 || s.Axis(5) != -32768 {
+		t.Errorf("L2 pressed: %d %d", s.Axis(2), s.Axis(5))
+	}
+	mouse(sdl.BUTTON_RIGHT, false)
+	if s.Axis(2) != -32768 {
+		t.Errorf("L2 released: %d", s.Axis(2))
+	}
+	mouse(sdl.BUTTON_LEFT, true)
+
+	// Removing the emulated gamepad lets go of everything
+	plug(r, 1, "pad")
+	k.Update(handle)
+	if s.Held(JoyButton(0)) || s.Hat(0) != sdl.HAT_CENTERED || s.Axis(5) != 0 {
+		t.Errorf("still held after removal: A %v, hat %d, R2 %d", s.Held(JoyButton(0)), s.Hat(0), s.Axis(5))
+	}
+}
@@ -0,0 +1,255 @@
+package input
+
+import (
+	"os"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// PadMode tells where the gamepad of a KeyPad comes from.
+type PadMode int
+
+const (
+	PadNone     PadMode = iota // no gamepad
+	PadNative                  // a joystick is connected
+	PadEmulated                // built from the keyboard and mouse events of Steam
+)
+
+func (m PadMode) String() string {
+	switch m {
+	case PadNative:
+		return "native"
+	case PadEmulated:
+		return "emulated from keyboard"
+	}
+	return "none"
+}
+
+// KeyPadID is the instance ID of the emulated gamepad. SDL never hands out
+// negative IDs.
+const KeyPadID sdl.JoystickID = -2
+
+// KeyPadGUID is the GUID of the emulated gamepad, matched by the Steam
+// Deck profile.
+const KeyPadGUID = "keypad"
+
+// keyPadIndex is the device index announced for the emulated gamepad.
+const keyPadIndex = 1 << 16
+
+// SteamDesktopKeys is the keyboard layout Steam puts on the Steam Deck
+// controls for programs started outside of Steam: d-pad on the arrows, left
+// stick on WASD, A Enter, B Backspace, X Space, Y Shift, View Tab, Menu Esc.
+// Change it to match the layout set in Steam.
+func SteamDesktopKeys() map[sdl.Scancode]string {
+	return map[sdl.Scancode]string{
+		sdl.SCANCODE_UP: "UP", sdl.SCANCODE_DOWN: "DOWN", sdl.SCANCODE_LEFT: "LEFT", sdl.SCANCODE_RIGHT: "RIGHT",
+		sdl.SCANCODE_W: "LY-", sdl.SCANCODE_S: "LY+", sdl.SCANCODE_A: "LX-", sdl.SCANCODE_D: "LX+",
+		sdl.SCANCODE_RETURN: "A", sdl.SCANCODE_BACKSPACE: "B", sdl.SCANCODE_SPACE: "X", sdl.SCANCODE_LSHIFT: "Y",
+		sdl.SCANCODE_PAGEUP: "L1", sdl.SCANCODE_PAGEDOWN: "R1",
+		sdl.SCANCODE_TAB: "SELECT", sdl.SCANCODE_ESCAPE: "START",
+	}
+}
+
+// SteamDesktopMouse maps the trigger clicks of the Steam desktop layout:
+// R2 is the left mouse button, L2 the right one.
+func SteamDesktopMouse() map[uint8]string {
+	return map[uint8]string{sdl.BUTTON_LEFT: "R2", sdl.BUTTON_RIGHT: "L2"}
+}
+
+// IsSteamDeck reports whether the program runs on a Steam Deck.
+func IsSteamDeck() bool {
+	if os.Getenv("SteamDeck") == "1" {
+		return true
+	}
+	vendor, _ := os.ReadFile("/sys/class/dmi/id/sys_vendor")
+	product, _ := os.ReadFile("/sys/class/dmi/id/product_name")
+	name := strings.TrimSpace(string(product))
+	return strings.TrimSpace(string(vendor)) == "Valve" && (name == "Jupiter" || name == "Galileo")
+}
+
+// KeyPad gives the game a gamepad on the Steam Deck when Steam redirects
+// the controls as keyboard and mouse, as it does for programs started from
+// a console. While no joystick is connected it announces a "Steam Deck"
+// device and turns the mapped keys into its button, hat and axis events;
+// once a real joystick shows up the emulated one is removed again.
+type KeyPad struct {
+	Registry *Registry
+	Enabled  bool                    // emulate when no joystick is connected
+	Keys     map[sdl.Scancode]string // key to control, see SteamDesktopKeys
+	Mouse    map[uint8]string        // mouse button to control
+
+	mode    PadMode
+	profile *Profile
+	held    map[string]bool
+	hat     uint8
+}
+
+// NewKeyPad returns a keypad with Steam's desktop layout, enabled on a
+// Steam Deck.
+func NewKeyPad(registry *Registry) *KeyPad {
+	return &KeyPad{
+		Registry: registry,
+		Enabled:  IsSteamDeck(),
+		Keys:     SteamDesktopKeys(),
+		Mouse:    SteamDesktopMouse(),
+		held:     make(map[string]bool),
+	}
+}
+
+// Mode returns where the gamepad comes from.
+func (k *KeyPad) Mode() PadMode {
+	return k.mode
+}
+
+// Update attaches or removes the emulated gamepad depending on the
+// connected joysticks; call it once per frame. handle gets the device
+// events, e.g. Push or the input layer of the game. It reports whether the
+// mode changed.
+func (k *KeyPad) Update(handle func(sdl.Event)) bool {
+	native := false
+	for _, d := range k.Registry.Devices() {
+		if d.ID != KeyPadID {
+			native = true
+		}
+	}
+	mode := PadNone
+	switch {
+	case native:
+		mode = PadNative
+	case k.Enabled:
+		mode = PadEmulated
+	}
+	if mode == k.mode {
+		return false
+	}
+	if k.mode == PadEmulated {
+		k.release(handle)
+		handle(&sdl.JoyDeviceRemovedEvent{Type: sdl.JOYDEVICEREMOVED, Timestamp: sdl.GetTicks(), Which: KeyPadID})
+	}
+	if mode == PadEmulated {
+		k.Registry.Expect(DeviceInfo{Index: keyPadIndex, ID: KeyPadID, GUID: KeyPadGUID, Name: "Steam Deck (keyboard)",
+			Buttons: 11, Axes: 6, Hats: 1})
+		handle(&sdl.JoyDeviceAddedEvent{Type: sdl.JOYDEVICEADDED, Timestamp: sdl.GetTicks(), Which: keyPadIndex})
+		k.rest(handle)
+	}
+	k.mode = mode
+	return true
+}
+
+// HandleEvent turns a mapped key or mouse button into gamepad events while
+// the gamepad is emulated. It reports whether the event was used up; the
+// game should skip it then, so that e.g. Esc acts as START only.
+func (k *KeyPad) HandleEvent(event sdl.Event, handle func(sdl.Event)) bool {
+	if k.mode != PadEmulated {
+		return false
+	}
+	switch t := event.(type) {
+	case *sdl.KeyboardEvent:
+		name, ok := k.Keys[t.Keysym.Scancode]
+		if !ok {
+			return false
+		}
+		if t.Repeat == 0 {
+			k.set(name, t.State == sdl.PRESSED, handle)
+		}
+		return true
+	case *sdl.MouseButtonEvent:
+		name, ok := k.Mouse[t.Button]
+		if !ok {
+			return false
+		}
+		k.set(name, t.State == sdl.PRESSED, handle)
+		return true
+	}
+	return false
+}
+
+// set changes one control and sends the event of its button, hat or axis.
+func (k *KeyPad) set(name string, down bool, handle func(sdl.Event)) {
+	if k.held[name] == down {
+		return
+	}
+	k.held[name] = down
+	if k.profile == nil {
+		k.profile = k.deckProfile()
+	}
+	now := sdl.GetTicks()
+
+	switch name {
+	case "UP", "DOWN", "LEFT", "RIGHT":
+		k.hat = sdl.HAT_CENTERED
+		for dir, bit := range map[string]uint8{"UP": sdl.HAT_UP, "DOWN": sdl.HAT_DOWN, "LEFT": sdl.HAT_LEFT, "RIGHT": sdl.HAT_RIGHT} {
+			if k.held[dir] {
+				k.hat |= bit
+			}
+		}
+		handle(&sdl.JoyHatEvent{Type: sdl.JOYHATMOTION, Timestamp: now, Which: KeyPadID, Hat: 0, Value: k.hat})
+		return
+	case "LX-", "LX+", "LY-", "LY+":
+		axis := name[:2]
+		a := k.profile.Axis(axis)
+		if a < 0 {
+			// A profile without the stick
+			return
+		}
+		var value int16
+		switch {
+		case k.held[axis+"-"] && !k.held[axis+"+"]:
+			value = -32768
+		case k.held[axis+"+"] && !k.held[axis+"-"]:
+			value = 32767
+		}
+		handle(&sdl.JoyAxisEvent{Type: sdl.JOYAXISMOTION, Timestamp: now, Which: KeyPadID,
+			Axis: uint8(a), Value: value})
+		return
+	}
+	if b := k.profile.Button(name); b >= 0 {
+		e := &sdl.JoyButtonEvent{Type: sdl.JOYBUTTONUP, Timestamp: now, Which: KeyPadID, Button: uint8(b), State: sdl.RELEASED}
+		if down {
+			e.Type, e.State = sdl.JOYBUTTONDOWN, sdl.PRESSED
+		}
+		handle(e)
+	} else if a := k.profile.Axis(name); a >= 0 {
+		// A trigger, which rests at the low end of its axis
+		var value int16 = -32768
+		if down {
+			value = 32767
+		}
+		handle(&sdl.JoyAxisEvent{Type: sdl.JOYAXISMOTION, Timestamp: now, Which: KeyPadID, Axis: uint8(a), Value: value})
+	}
+}
+
+// rest moves the trigger axes to their released position; the emulated
+// gamepad is announced with every axis at 0.
+func (k *KeyPad) rest(handle func(sdl.Event)) {
+	if k.profile == nil {
+		k.profile = k.deckProfile()
+	}
+	for _, name := range []string{"L2", "R2"} {
+		if a := k.profile.Axis(name); a >= 0 && k.profile.Button(name) < 0 {
+			handle(&sdl.JoyAxisEvent{Type: sdl.JOYAXISMOTION, Timestamp: sdl.GetTicks(), Which: KeyPadID,
+				Axis: uint8(a), Value: -32768})
+		}
+	}
+}
+
+// release lets go of every held control.
+func (k *KeyPad) release(handle func(sdl.Event)) {
+	for name, down := range k.held {
+		if down {
+			k.set(name, false, handle)
+		}
+	}
+}
+
+// deckProfile returns the Steam Deck profile the emulated controls are
+// numbered by.
+func (k *KeyPad) deckProfile() *Profile {
+	if k.Registry != nil && k.Registry.Profiles != nil {
+		if p := k.Registry.Profiles.Get("Steam Deck"); p != nil {
+			return p
+		}
+	}
+	return NewProfiles().Get("Steam Deck")
+}
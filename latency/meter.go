@@ -0,0 +1,192 @@
+package latency
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Device holds the report intervals of one joystick. SDL timestamps are in
+// milliseconds, so rates above 500 Hz can't be told apart.
+//
+// Only axis and ball reports count: a moving stick is reported on every
+// poll while buttons only report when a person presses them. A gap longer
+// than idleFactor times the median interval, or than firstGap while there
+// are few intervals, means the stick rested and starts a new stream.
+type Device struct {
+	ID       sdl.JoystickID
+	Name     string
+	Interval Samples
+
+	last    uint32
+	started bool
+	maxGap  float64
+}
+
+const (
+	idleFactor = 4
+	firstGap   = 50 // ms, 20 Hz
+	gapEvery   = 64 // intervals between updates of the gap limit
+)
+
+// Rate returns the median report rate in Hz.
+func (d *Device) Rate() float64 {
+	st := d.Interval.Stats()
+	if st.Median == 0 {
+		return 0
+	}
+	return 1000 / st.Median
+}
+
+// Meter watches an event loop. Feed it every event, draw the flash when
+// HandleEvent reports a press, and call Presented right after
+// renderer.Present.
+type Meter struct {
+	EventDelay Samples // event timestamp to the loop handling it
+	Visible    Samples // event timestamp to the end of the present that shows it
+	Frames     Samples // presents from the press to the one showing it, 1 = the first after the press
+	FrameTime  Samples // time between presents
+
+	devices     map[sdl.JoystickID]*Device
+	pending     []uint32 // timestamps of presses not shown yet
+	presents    []uint32 // when the last presents ended, oldest first
+	lastPresent uint64
+}
+
+// maxPresents is how many presents back a press is looked for; older
+// presses count as this many frames.
+const maxPresents = 64
+
+// NewMeter returns an empty meter.
+func NewMeter() *Meter {
+	return &Meter{
+		EventDelay: Samples{Name: "event delay", Unit: "ms"},
+		Visible:    Samples{Name: "press to present", Unit: "ms"},
+		Frames:     Samples{Name: "press to present", Unit: "frames"},
+		FrameTime:  Samples{Name: "frame time", Unit: "ms"},
+		devices:    make(map[sdl.JoystickID]*Device),
+	}
+}
+
+// Device returns the intervals of joystick id, creating them on first use.
+func (m *Meter) Device(id sdl.JoystickID) *Device {
+	d, ok := m.devices[id]
+	if !ok {
+		d = &Device{ID: id, Name: fmt.Sprintf("joystick %d", id),
+			Interval: Samples{Name: "report interval", Unit: "ms"}}
+		m.devices[id] = d
+	}
+	return d
+}
+
+// Devices returns the joysticks seen, by instance ID.
+func (m *Meter) Devices() []*Device {
+	var list []*Device
+	for _, d := range m.devices {
+		list = append(list, d)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// report adds a joystick report; events with the same timestamp came in
+// the same report.
+func (m *Meter) report(id sdl.JoystickID, timestamp uint32) {
+	d := m.Device(id)
+	if d.started && timestamp > d.last {
+		if gap := float64(timestamp - d.last); gap <= d.gapLimit() {
+			d.Interval.Add(gap)
+			if d.Interval.Len()%gapEvery == 0 {
+				d.maxGap = idleFactor * d.Interval.Stats().Median
+			}
+		}
+	}
+	d.last, d.started = timestamp, true
+}
+
+// gapLimit returns the longest interval that still counts as continuous.
+func (d *Device) gapLimit() float64 {
+	if d.maxGap == 0 {
+		return firstGap
+	}
+	return d.maxGap
+}
+
+// HandleEvent measures an event. It reports whether it is a press the
+// caller should make visible, i.e. a key, mouse or joystick button going
+// down or a hat leaving the center.
+func (m *Meter) HandleEvent(event sdl.Event) bool {
+	now := sdl.GetTicks()
+	timestamp := event.GetTimestamp()
+	isPress := false
+	switch t := event.(type) {
+	case *sdl.KeyboardEvent:
+		isPress = t.State == sdl.PRESSED && t.Repeat == 0
+	case *sdl.MouseButtonEvent:
+		isPress = t.State == sdl.PRESSED
+	case *sdl.JoyButtonEvent:
+		isPress = t.State == sdl.PRESSED
+	case *sdl.JoyHatEvent:
+		isPress = t.Value != sdl.HAT_CENTERED
+	case *sdl.JoyAxisEvent:
+		m.report(t.Which, timestamp)
+	case *sdl.JoyBallEvent:
+		m.report(t.Which, timestamp)
+	case *sdl.ControllerButtonEvent, *sdl.ControllerAxisEvent, *sdl.MouseMotionEvent, *sdl.TouchFingerEvent:
+	default:
+		return false
+	}
+	if now >= timestamp {
+		m.EventDelay.Add(float64(now - timestamp))
+	}
+	if isPress {
+		m.pending = append(m.pending, timestamp)
+	}
+	return isPress
+}
+
+// Presented closes a frame; call it right after renderer.Present. The
+// presses handled before it count as shown by it. A press counts the
+// presents that ended after its event timestamp, this one included, so a
+// press that waited in the queue while frames went by counts those frames.
+// The time the display needs to scan the frame out comes on top and can't
+// be seen from here.
+func (m *Meter) Presented() {
+	now := sdl.GetTicks()
+	counter := sdl.GetPerformanceCounter()
+	if m.lastPresent != 0 {
+		m.FrameTime.Add(float64(counter-m.lastPresent) * 1000 / float64(sdl.GetPerformanceFrequency()))
+	}
+	m.lastPresent = counter
+	if len(m.presents) == maxPresents {
+		m.presents = append(m.presents[:0], m.presents[1:]...)
+	}
+	m.presents = append(m.presents, now)
+	for _, timestamp := range m.pending {
+		if now >= timestamp {
+			m.Visible.Add(float64(now - timestamp))
+		}
+		// This present shows it even when both fall in the same millisecond
+		frames := 1
+		for i := len(m.presents) - 2; i >= 0 && m.presents[i] > timestamp; i-- {
+			frames++
+		}
+		m.Frames.Add(float64(frames))
+	}
+	m.pending = m.pending[:0]
+}
+
+// Report writes the statistics, after the header lines.
+func (m *Meter) Report(w io.Writer, header ...string) {
+	for _, h := range header {
+		fmt.Fprintln(w, h)
+	}
+	fmt.Fprintln(w)
+	WriteTable(w, &m.EventDelay, &m.Visible, &m.Frames, &m.FrameTime)
+	for _, d := range m.Devices() {
+		fmt.Fprintf(w, "\n%s: %.0f Hz median\n", d.Name, d.Rate())
+		WriteTable(w, &d.Interval)
+	}
+}
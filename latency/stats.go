@@ -0,0 +1,126 @@
+// Package latency measures how long input takes through the event loop:
+// event delivery delay, joystick report intervals and the present on which
+// a press becomes visible.
+package latency
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// MaxValues is how many values Samples keeps for the percentiles. Count,
+// min, max, mean and standard deviation take every value into account.
+const MaxValues = 4096
+
+// Samples collects measurements of one quantity in bounded memory. Past
+// MaxValues values the percentiles come from a uniform random sample of
+// them.
+type Samples struct {
+	Name string
+	Unit string
+
+	n        int
+	min, max float64
+	mean, m2 float64 // running mean and sum of squared deviations
+	values   []float64
+	rng      uint64
+	stats    Stats
+	dirty    bool
+}
+
+// Add records a value.
+func (s *Samples) Add(v float64) {
+	s.n++
+	if s.n == 1 || v < s.min {
+		s.min = v
+	}
+	if s.n == 1 || v > s.max {
+		s.max = v
+	}
+	delta := v - s.mean
+	s.mean += delta / float64(s.n)
+	s.m2 += delta * (v - s.mean)
+	s.dirty = true
+
+	if len(s.values) < MaxValues {
+		s.values = append(s.values, v)
+		return
+	}
+	// Reservoir sampling: the n-th value replaces a kept one with
+	// probability MaxValues/n
+	if i := s.random(s.n); i < MaxValues {
+		s.values[i] = v
+	}
+}
+
+// random returns a number in [0, n) from a xorshift generator, without
+// touching the global one of math/rand.
+func (s *Samples) random(n int) int {
+	if s.rng == 0 {
+		s.rng = 0x9e3779b97f4a7c15
+	}
+	s.rng ^= s.rng << 13
+	s.rng ^= s.rng >> 7
+	s.rng ^= s.rng << 17
+	return int(s.rng % uint64(n))
+}
+
+// Len returns how many values were added.
+func (s *Samples) Len() int { return s.n }
+
+// Stats summarizes samples.
+type Stats struct {
+	N                     int
+	Min, Median, P99, Max float64
+	Mean, StdDev          float64
+}
+
+// percentile returns the nearest-rank percentile p (0 to 100) of sorted
+// values.
+func percentile(sorted []float64, p float64) float64 {
+	i := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// Stats computes the summary, all zero when there are no values. It is
+// kept until the next Add.
+func (s *Samples) Stats() Stats {
+	if s.n == 0 {
+		return Stats{}
+	}
+	if !s.dirty {
+		return s.stats
+	}
+	sorted := append([]float64(nil), s.values...)
+	sort.Float64s(sorted)
+	s.stats = Stats{
+		N:      s.n,
+		Min:    s.min,
+		Median: percentile(sorted, 50),
+		P99:    percentile(sorted, 99),
+		Max:    s.max,
+		Mean:   s.mean,
+		StdDev: math.Sqrt(s.m2 / float64(s.n)),
+	}
+	s.dirty = false
+	return s.stats
+}
+
+// WriteTable writes one line of statistics per sample set.
+func WriteTable(w io.Writer, samples ...*Samples) {
+	fmt.Fprintf(w, "%-32s %6s %9s %9s %9s %9s %9s %9s\n", "", "n", "min", "median", "p99", "max", "mean", "stddev")
+	for _, s := range samples {
+		st := s.Stats()
+		if st.N == 0 {
+			fmt.Fprintf(w, "%-32s %6d\n", s.Name+" ("+s.Unit+")", 0)
+			continue
+		}
+		fmt.Fprintf(w, "%-32s %6d %9.2f %9.2f %9.2f %9.2f %9.2f %9.2f\n", s.Name+" ("+s.Unit+")",
+			st.N, st.Min, st.Median, st.P99, st.Max, st.Mean, st.StdDev)
+	}
+}
@@ -0,0 +1,131 @@
+// Package leak finds SDL and GL objects that are never freed. Create and
+// free objects through its wrappers:
+//
+//	tex, err := leak.Texture(renderer.CreateTextureFromSurface(surface))
+//	...
+//	leak.DestroyTexture(tex)
+//
+// and GL objects with Track and Untrack. Built with -tags leakcheck, every
+// creation is recorded with its stack, Draw shows the live counts and
+// Report lists what is still alive with where it was created. Without the
+// tag the wrappers only create and free.
+package leak
+
+import (
+	"github.com/veandco/go-sdl2/mix"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+// Kind is the type of a tracked object.
+type Kind string
+
+const (
+	SDLTexture Kind = "texture"
+	SDLSurface Kind = "surface"
+	TTFFont    Kind = "font"
+	MixChunk   Kind = "chunk"
+	MixMusic   Kind = "music"
+	GLTexture  Kind = "gl texture"
+	GLBuffer   Kind = "gl buffer"
+	GLProgram  Kind = "gl program"
+)
+
+// Kinds lists the kinds in the order Draw and Report show them.
+var Kinds = []Kind{SDLTexture, SDLSurface, TTFFont, MixChunk, MixMusic, GLTexture, GLBuffer, GLProgram}
+
+// Texture records a texture returned with err by a create function.
+func Texture(t *sdl.Texture, err error) (*sdl.Texture, error) {
+	if err == nil && t != nil {
+		track(SDLTexture, t)
+	}
+	return t, err
+}
+
+// DestroyTexture destroys a texture.
+func DestroyTexture(t *sdl.Texture) {
+	if t != nil {
+		untrack(SDLTexture, t)
+		t.Destroy()
+	}
+}
+
+// Surface records a surface returned with err by a create, load or render
+// function.
+func Surface(s *sdl.Surface, err error) (*sdl.Surface, error) {
+	if err == nil && s != nil {
+		track(SDLSurface, s)
+	}
+	return s, err
+}
+
+// FreeSurface frees a surface.
+func FreeSurface(s *sdl.Surface) {
+	if s != nil {
+		untrack(SDLSurface, s)
+		s.Free()
+	}
+}
+
+// Font records a font returned with err by ttf.OpenFont and the like.
+func Font(f *ttf.Font, err error) (*ttf.Font, error) {
+	if err == nil && f != nil {
+		track(TTFFont, f)
+	}
+	return f, err
+}
+
+// CloseFont closes a font.
+func CloseFont(f *ttf.Font) {
+	if f != nil {
+		untrack(TTFFont, f)
+		f.Close()
+	}
+}
+
+// Chunk records a chunk returned with err by mix.LoadWAV and the like.
+func Chunk(c *mix.Chunk, err error) (*mix.Chunk, error) {
+	if err == nil && c != nil {
+		track(MixChunk, c)
+	}
+	return c, err
+}
+
+// FreeChunk frees a chunk.
+func FreeChunk(c *mix.Chunk) {
+	if c != nil {
+		untrack(MixChunk, c)
+		c.Free()
+	}
+}
+
+// Music records music returned with err by mix.LoadMUS and the like.
+func Music(m *mix.Music, err error) (*mix.Music, error) {
+	if err == nil && m != nil {
+		track(MixMusic, m)
+	}
+	return m, err
+}
+
+// FreeMusic frees music.
+func FreeMusic(m *mix.Music) {
+	if m != nil {
+		untrack(MixMusic, m)
+		m.Free()
+	}
+}
+
+// Track records a GL object after it was generated, such as the name
+// returned by gl.GenTextures.
+func Track(kind Kind, name uint32) {
+	if name != 0 {
+		track(kind, name)
+	}
+}
+
+// Untrack forgets a GL object before it is deleted.
+func Untrack(kind Kind, name uint32) {
+	if name != 0 {
+		untrack(kind, name)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !leakcheck
+
+package leak
+
+import (
+	"io"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Enabled reports whether the program was built with -tags leakcheck.
+const Enabled = false
+
+func track(kind Kind, id interface{}) {}
+
+func untrack(kind Kind, id interface{}) {}
+
+// Counts returns nil without -tags leakcheck.
+func Counts() map[Kind]int { return nil }
+
+// Live returns 0 without -tags leakcheck.
+func Live() int { return 0 }
+
+// Draw draws nothing without -tags leakcheck.
+func Draw(renderer *sdl.Renderer, x, y int32) {}
+
+// Report writes nothing without -tags leakcheck.
+func Report(w io.Writer) int { return 0 }
+
+// Check does nothing without -tags leakcheck.
+func Check() {}
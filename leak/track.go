@@ -0,0 +1,162 @@
+//go:build leakcheck
+
+package leak
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Enabled reports whether the program was built with -tags leakcheck.
+const Enabled = true
+
+type object struct {
+	kind Kind
+	id   interface{} // pointer, or GL name
+}
+
+var (
+	mu      sync.Mutex
+	live    = make(map[object][]uintptr) // creation stack
+	counts  = make(map[Kind]int)
+	created = make(map[Kind]int)
+	unknown = make(map[Kind]int) // freed without having been tracked
+)
+
+func track(kind Kind, id interface{}) {
+	pcs := make([]uintptr, 32)
+	// Skip runtime.Callers, track and the wrapper
+	pcs = pcs[:runtime.Callers(3, pcs)]
+	mu.Lock()
+	defer mu.Unlock()
+	o := object{kind, id}
+	if _, ok := live[o]; !ok {
+		counts[kind]++
+	}
+	live[o] = pcs
+	created[kind]++
+}
+
+func untrack(kind Kind, id interface{}) {
+	mu.Lock()
+	defer mu.Unlock()
+	o := object{kind, id}
+	if _, ok := live[o]; !ok {
+		unknown[kind]++
+		return
+	}
+	delete(live, o)
+	counts[kind]--
+}
+
+// Counts returns how many objects of each kind are alive.
+func Counts() map[Kind]int {
+	mu.Lock()
+	defer mu.Unlock()
+	c := make(map[Kind]int, len(counts))
+	for kind, n := range counts {
+		c[kind] = n
+	}
+	return c
+}
+
+// Live returns how many objects are alive.
+func Live() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(live)
+}
+
+// Draw shows the live and created counts of every kind seen, one line
+// each, from x, y.
+func Draw(renderer *sdl.Renderer, x, y int32) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, kind := range Kinds {
+		if created[kind] == 0 {
+			continue
+		}
+		line := fmt.Sprintf("%-10s %5d live %7d made", kind, counts[kind], created[kind])
+		gfx.StringRGBA(renderer, x, y, line, 255, 255, 0, 255)
+		y += 12
+	}
+}
+
+// frames formats a creation stack without the runtime frames. The first
+// line is the origin, the call to the wrapper.
+func frames(pcs []uintptr) []string {
+	var stack []string
+	fs := runtime.CallersFrames(pcs)
+	for more := true; more; {
+		var f runtime.Frame
+		f, more = fs.Next()
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			stack = append(stack, fmt.Sprintf("%s\n\t\t%s:%d", f.Function, f.File, f.Line))
+		}
+	}
+	return stack
+}
+
+// Report writes the objects still alive, grouped by kind and creation
+// stack, and returns how many there are.
+func Report(w io.Writer) int {
+	mu.Lock()
+	defer mu.Unlock()
+	type group struct {
+		kind  Kind
+		stack []string
+		n     int
+	}
+	groups := make(map[string]*group)
+	for o, pcs := range live {
+		stack := frames(pcs)
+		key := string(o.kind) + "\x00" + strings.Join(stack, "\n")
+		g, ok := groups[key]
+		if !ok {
+			g = &group{kind: o.kind, stack: stack}
+			groups[key] = g
+		}
+		g.n++
+	}
+	list := make([]*group, 0, len(groups))
+	for _, g := range groups {
+		list = append(list, g)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].n != list[j].n {
+			return list[i].n > list[j].n
+		}
+		return list[i].kind < list[j].kind
+	})
+	if len(live) == 0 {
+		fmt.Fprintln(w, "leak: no objects alive")
+	} else {
+		fmt.Fprintf(w, "leak: %d objects alive\n", len(live))
+	}
+	for _, g := range list {
+		fmt.Fprintf(w, "%d %s created at\n", g.n, g.kind)
+		for _, line := range g.stack {
+			fmt.Fprintf(w, "\t%s\n", line)
+		}
+	}
+	for _, kind := range Kinds {
+		if unknown[kind] > 0 {
+			fmt.Fprintf(w, "leak: %d %s freed without being tracked\n", unknown[kind], kind)
+		}
+	}
+	return len(live)
+}
+
+// Check reports to stderr, for a defer at the start of main after the
+// resources' own defers have run.
+func Check() {
+	Report(os.Stderr)
+}
@@ -0,0 +1,114 @@
+// Package led sets the player number and light bar color of controllers
+// that have them: the player LEDs of Xbox and Switch pads and the light bar
+// of DualShock 4 and DualSense pads.
+package led
+
+/*
+#cgo windows LDFLAGS: -lSDL2
+#cgo linux freebsd darwin openbsd pkg-config: sdl2
+#include "SDL.h"
+
+#if !SDL_VERSION_ATLEAST(2,0,12)
+static void SDL_JoystickSetPlayerIndex(SDL_Joystick *joystick, int player_index)
+{
+}
+#endif
+
+#if !SDL_VERSION_ATLEAST(2,0,14)
+static int SDL_JoystickSetLED(SDL_Joystick *joystick, Uint8 red, Uint8 green, Uint8 blue)
+{
+	return SDL_SetError("LEDs need SDL 2.0.14");
+}
+#endif
+
+#if !SDL_VERSION_ATLEAST(2,0,18)
+// Without SDL_JoystickHasLED trying is the only way to know
+static SDL_bool SDL_JoystickHasLED(SDL_Joystick *joystick)
+{
+	return SDL_VERSION_ATLEAST(2,0,14) ? SDL_TRUE : SDL_FALSE;
+}
+#endif
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+)
+
+// ErrNoLED is returned for joysticks without an LED.
+var ErrNoLED = errors.New("joystick has no LED")
+
+func cptr(joy *sdl.Joystick) *C.SDL_Joystick {
+	return (*C.SDL_Joystick)(unsafe.Pointer(joy))
+}
+
+// SetPlayerIndex shows player index+1 on the controller's player LEDs,
+// -1 turns them off. Controllers without player LEDs ignore it.
+func SetPlayerIndex(joy *sdl.Joystick, index int) {
+	C.SDL_JoystickSetPlayerIndex(cptr(joy), C.int(index))
+}
+
+// HasLED reports whether the joystick may have a color LED. Before SDL
+// 2.0.18 SDL can't tell and this reports true; SetColor then fails.
+func HasLED(joy *sdl.Joystick) bool {
+	return C.SDL_JoystickHasLED(cptr(joy)) == C.SDL_TRUE
+}
+
+// SetColor sets the color of the joystick's LED.
+func SetColor(joy *sdl.Joystick, c sdl.Color) error {
+	if !HasLED(joy) {
+		return ErrNoLED
+	}
+	if C.SDL_JoystickSetLED(cptr(joy), C.Uint8(c.R), C.Uint8(c.G), C.Uint8(c.B)) != 0 {
+		if err := sdl.GetError(); err != nil {
+			return err
+		}
+		return ErrNoLED
+	}
+	return nil
+}
+
+// SlotColors are the light bar colors of players 1 to 4, as on a
+// PlayStation.
+var SlotColors = []sdl.Color{
+	{R: 0, G: 0, B: 255, A: 255},
+	{R: 255, G: 0, B: 0, A: 255},
+	{R: 0, G: 255, B: 0, A: 255},
+	{R: 255, G: 0, B: 255, A: 255},
+}
+
+// Off is the color of an LED turned off.
+var Off = sdl.Color{A: 255}
+
+// ShowSlot shows the player of a slot on its joystick: the player number
+// on the player LEDs and the slot's color on the light bar. Keyboard and
+// free slots are skipped. The error is ErrNoLED or SDL's when the color
+// can't be set; the player number is set regardless.
+func ShowSlot(slot *input.Slot) error {
+	d := slot.Device
+	if d == nil || d.Joystick == nil || !d.Connected {
+		return nil
+	}
+	SetPlayerIndex(d.Joystick, slot.Index)
+	return SetColor(d.Joystick, SlotColors[slot.Index%len(SlotColors)])
+}
+
+// ShowSlots calls ShowSlot for every slot, ignoring controllers without
+// LEDs.
+func ShowSlots(players *input.Players) {
+	for _, slot := range players.Slots {
+		ShowSlot(slot)
+	}
+}
+
+// Clear turns off the player number and LED of a joystick, for a slot a
+// player left.
+func Clear(joy *sdl.Joystick) {
+	SetPlayerIndex(joy, -1)
+	SetColor(joy, Off)
+}
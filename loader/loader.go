@@ -0,0 +1,346 @@
+// Package loader loads images, fonts, SND banks and music in the
+// background so that a loading screen can render meanwhile. Files are read
+// and decoded on worker goroutines; the textures, fonts, chunks and music
+// are created on the main thread through package mainthread, so the event
+// loop must call mainthread.Process every frame while loading.
+package loader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/veandco/go-sdl2/img"
+	"github.com/veandco/go-sdl2/mix"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+
+	"go-sdl2/bus"
+	"go-sdl2/leak"
+	"go-sdl2/mainthread"
+	"go-sdl2/snd"
+)
+
+// Kind is the type of an asset.
+type Kind int
+
+const (
+	Image Kind = iota // a texture, or a surface without renderer
+	Font              // a TTF font at a point size
+	Snd               // a MUGEN SND bank
+	Music             // music for mix.Music.Play
+)
+
+var kindNames = [...]string{"image", "font", "snd", "music"}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Asset is a file to load and, once loaded, the object created from it.
+// Its fields are set on the main thread.
+type Asset struct {
+	Kind Kind
+	Path string
+	Size int // point size of fonts
+
+	Texture *sdl.Texture
+	Surface *sdl.Surface // images loaded without renderer
+	Font    *ttf.Font
+	Bank    *snd.Bank
+	Music   *mix.Music
+	Err     error
+	Loaded  bool
+
+	data []byte // fonts and music read from it as they are used
+}
+
+func (a *Asset) String() string {
+	if a.Kind == Font {
+		return fmt.Sprintf("%s %s:%d", a.Kind, a.Path, a.Size)
+	}
+	return fmt.Sprintf("%s %s", a.Kind, a.Path)
+}
+
+// Free frees the object of a loaded asset on the main thread.
+func (a *Asset) Free() {
+	mainthread.Call(func() {
+		leak.DestroyTexture(a.Texture)
+		leak.FreeSurface(a.Surface)
+		leak.CloseFont(a.Font)
+		if a.Bank != nil {
+			a.Bank.Free()
+		}
+		leak.FreeMusic(a.Music)
+		a.Texture, a.Surface, a.Font, a.Bank, a.Music = nil, nil, nil, nil, nil
+		a.data, a.Loaded = nil, false
+	})
+}
+
+// Progress is sent every time an asset has loaded or failed.
+type Progress struct {
+	Done, Total int
+	Asset       *Asset // the asset just finished
+}
+
+// Fraction returns how much has loaded, from 0 to 1.
+func (p Progress) Fraction() float64 {
+	if p.Total == 0 {
+		return 1
+	}
+	return float64(p.Done) / float64(p.Total)
+}
+
+// Loader loads a list of assets once.
+type Loader struct {
+	Renderer *sdl.Renderer // images become textures of it; surfaces when nil
+	Workers  int           // goroutines reading files, runtime.NumCPU by default
+	Bus      *bus.Bus      // if set, Progress is also pushed as a user event of type Event
+	Event    uint32
+
+	assets   []*Asset
+	progress chan Progress
+	done     int
+	started  bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	finished chan struct{}
+}
+
+// New returns a loader creating textures for renderer.
+func New(renderer *sdl.Renderer) *Loader {
+	return &Loader{Renderer: renderer, Workers: runtime.NumCPU()}
+}
+
+// Add adds a file to load before Start and returns its asset.
+func (l *Loader) Add(kind Kind, path string, size int) *Asset {
+	if l.started {
+		panic("loader: Add after Start")
+	}
+	a := &Asset{Kind: kind, Path: path, Size: size}
+	l.assets = append(l.assets, a)
+	return a
+}
+
+// Image adds an image file, in any format SDL_image reads.
+func (l *Loader) Image(path string) *Asset { return l.Add(Image, path, 0) }
+
+// Font adds a TTF font at a point size.
+func (l *Loader) Font(path string, size int) *Asset { return l.Add(Font, path, size) }
+
+// Snd adds an SND bank. The audio device must be open.
+func (l *Loader) Snd(path string) *Asset { return l.Add(Snd, path, 0) }
+
+// Music adds a music file. The audio device must be open.
+func (l *Loader) Music(path string) *Asset { return l.Add(Music, path, 0) }
+
+// Assets returns the assets in the order they were added.
+func (l *Loader) Assets() []*Asset { return l.assets }
+
+// Progress returns the channel Progress is sent on, one value per asset.
+// It is buffered, a loop may read it or not.
+func (l *Loader) Progress() <-chan Progress { return l.progress }
+
+// Done reports whether every asset has loaded or failed.
+func (l *Loader) Done() bool { return l.started && l.done == len(l.assets) }
+
+// Fraction returns how much has loaded, from 0 to 1.
+func (l *Loader) Fraction() float64 {
+	return Progress{Done: l.done, Total: len(l.assets)}.Fraction()
+}
+
+// Start starts loading in the background. Cancelling ctx stops it like
+// Cancel does.
+func (l *Loader) Start(ctx context.Context) {
+	if l.started {
+		return
+	}
+	l.started = true
+	l.ctx, l.cancel = context.WithCancel(ctx)
+	ctx = l.ctx
+	l.progress = make(chan Progress, len(l.assets))
+	l.finished = make(chan struct{})
+
+	jobs := make(chan *Asset)
+	workers := l.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range jobs {
+				l.load(ctx, a)
+			}
+		}()
+	}
+	go func() {
+		defer func() {
+			close(jobs)
+			wg.Wait()
+			close(l.finished)
+		}()
+		for _, a := range l.assets {
+			select {
+			case jobs <- a:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// decoded is what a worker prepared for the main thread.
+type decoded struct {
+	surface *sdl.Surface
+	data    []byte
+	snd     *snd.Data
+	bank    *snd.Bank
+}
+
+func (d *decoded) free() {
+	leak.FreeSurface(d.surface)
+	if d.bank != nil {
+		d.bank.Free()
+	}
+}
+
+// load reads and decodes an asset and then creates its object on the main
+// thread.
+func (l *Loader) load(ctx context.Context, a *Asset) {
+	var d decoded
+	var err error
+	switch a.Kind {
+	case Image:
+		d.surface, err = leak.Surface(img.Load(a.Path))
+	case Font, Music:
+		d.data, err = os.ReadFile(a.Path)
+	case Snd:
+		d.snd, err = snd.ReadFile(a.Path)
+	default:
+		err = fmt.Errorf("unknown asset kind %v", a.Kind)
+	}
+	if ctx.Err() != nil {
+		d.free()
+		return
+	}
+	if err == nil && a.Kind == Snd {
+		// Every chunk is its own main thread call
+		d.bank = d.snd.Bank()
+		if ctx.Err() != nil {
+			d.free()
+			return
+		}
+	}
+	mainthread.Call(func() {
+		if err == nil {
+			err = l.create(a, &d)
+		}
+		if err != nil {
+			a.Err = fmt.Errorf("%v: %w", a, err)
+		} else {
+			a.Loaded = true
+		}
+		l.done++
+		p := Progress{Done: l.done, Total: len(l.assets), Asset: a}
+		l.progress <- p
+		if l.Bus != nil && l.Event != 0 {
+			l.Bus.Push(l.Event, p)
+		}
+	})
+}
+
+// create makes the SDL object of an asset, on the main thread.
+func (l *Loader) create(a *Asset, d *decoded) error {
+	switch a.Kind {
+	case Image:
+		if l.Renderer == nil {
+			a.Surface = d.surface
+			return nil
+		}
+		defer leak.FreeSurface(d.surface)
+		tex, err := leak.Texture(l.Renderer.CreateTextureFromSurface(d.surface))
+		if err != nil {
+			return err
+		}
+		a.Texture = tex
+	case Font:
+		rw, err := sdl.RWFromMem(d.data)
+		if err != nil {
+			return err
+		}
+		font, err := leak.Font(ttf.OpenFontRW(rw, 1, a.Size))
+		if err != nil {
+			return err
+		}
+		// The font reads glyphs from data as long as it is open
+		a.Font, a.data = font, d.data
+	case Music:
+		rw, err := sdl.RWFromMem(d.data)
+		if err != nil {
+			return err
+		}
+		music, err := leak.Music(mix.LoadMUSRW(rw, 1))
+		if err != nil {
+			return err
+		}
+		// Music is decoded from data while it plays
+		a.Music, a.data = music, d.data
+	case Snd:
+		a.Bank = d.bank
+	}
+	return nil
+}
+
+// Wait waits until loading has finished or was cancelled, running the
+// main thread calls of the workers meanwhile. It must be called from the
+// main thread. It returns the error of the context if loading was
+// cancelled, the first error of an asset otherwise.
+func (l *Loader) Wait() error {
+	if !l.started {
+		return nil
+	}
+	for {
+		select {
+		case <-l.finished:
+			mainthread.Process()
+			if l.done < len(l.assets) {
+				return l.ctx.Err()
+			}
+			for _, a := range l.assets {
+				if a.Err != nil {
+					return a.Err
+				}
+			}
+			return nil
+		default:
+			mainthread.Process()
+			sdl.Delay(1)
+		}
+	}
+}
+
+// Cancel stops loading and frees what has loaded. It must be called from
+// the main thread.
+func (l *Loader) Cancel() {
+	if !l.started {
+		return
+	}
+	l.cancel()
+	l.Wait()
+	l.Free()
+}
+
+// Free frees every loaded asset.
+func (l *Loader) Free() {
+	for _, a := range l.assets {
+		a.Free()
+	}
+}
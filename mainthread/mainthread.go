@@ -0,0 +1,174 @@
+// Package mainthread runs functions on the main OS thread from any
+// goroutine. SDL video, the renderer, OpenGL and SDL_mixer have to be used
+// from the thread that initialized them, so background goroutines queue
+// that work here and the event loop runs it with Process once per frame.
+// Programs without an event loop pass their body to Run instead.
+//
+// Importing the package locks the main goroutine to the main thread.
+package mainthread
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+func init() {
+	runtime.LockOSThread()
+	mainID = goid()
+}
+
+var mainID uint64
+
+// QueueSize is how many calls can wait before Call and Post block.
+const QueueSize = 256
+
+type call struct {
+	fn   func() error
+	done chan error // nil for Post
+}
+
+var queue = make(chan call, QueueSize)
+
+// PanicError is a panic of a queued function, with the stack where it
+// happened. Call panics with it in the caller, CallErr returns it.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic on main thread: %v\n%s", e.Value, e.Stack)
+}
+
+// goid returns the ID of the calling goroutine, from the first line of its
+// stack trace: "goroutine 1 [running]:".
+func goid() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	b = b[:bytes.IndexByte(b, ' ')]
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
+
+// OnMain reports whether the caller runs on the main goroutine, and so on
+// the main thread.
+func OnMain() bool {
+	return goid() == mainID
+}
+
+// invoke runs fn and turns a panic into a PanicError.
+func invoke(fn func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			buf := make([]byte, 16<<10)
+			err = &PanicError{Value: v, Stack: buf[:runtime.Stack(buf, false)]}
+		}
+	}()
+	return fn()
+}
+
+func (c call) run() {
+	err := invoke(c.fn)
+	if c.done != nil {
+		c.done <- err
+	} else if pe, ok := err.(*PanicError); ok {
+		// Nobody waits for a Post, the main loop gets the panic
+		panic(pe)
+	}
+}
+
+// CallErr runs fn on the main thread and returns its error, or a
+// *PanicError if it panicked. Called from the main thread it runs fn at
+// once; otherwise it blocks until the main loop calls Process.
+func CallErr(fn func() error) error {
+	if OnMain() {
+		return invoke(fn)
+	}
+	done := make(chan error, 1)
+	queue <- call{fn: fn, done: done}
+	return <-done
+}
+
+// Call runs fn on the main thread like CallErr. A panic in fn panics the
+// caller with a *PanicError.
+func Call(fn func()) {
+	if OnMain() {
+		fn()
+		return
+	}
+	err := CallErr(func() error {
+		fn()
+		return nil
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Post queues fn for the main thread without waiting for it. A panic in
+// fn panics the main loop in Process.
+func Post(fn func()) {
+	queue <- call{fn: func() error {
+		fn()
+		return nil
+	}}
+}
+
+// Pending returns how many calls are waiting.
+func Pending() int {
+	return len(queue)
+}
+
+// Process runs the calls queued so far and returns how many ran. Calls
+// queued meanwhile wait for the next Process, so a goroutine flooding the
+// queue can't stall the frame. It must be called from the main thread.
+func Process() int {
+	return ProcessFor(0)
+}
+
+// ProcessFor is Process with a time budget: it stops once budget has
+// passed, leaving the rest for the next frame, so that many texture
+// uploads at once don't drop frames. At least one call runs. A budget of 0
+// has no limit.
+func ProcessFor(budget time.Duration) int {
+	if !OnMain() {
+		panic("mainthread: Process called outside the main thread")
+	}
+	start := time.Now()
+	n := 0
+	for max := len(queue); n < max; n++ {
+		if budget > 0 && n > 0 && time.Since(start) >= budget {
+			break
+		}
+		(<-queue).run()
+	}
+	return n
+}
+
+// Run runs body on a new goroutine and the queued calls on the main
+// thread until body returns, for programs that block in their main
+// function instead of running an event loop. It must be called from the
+// main thread.
+func Run(body func()) {
+	if !OnMain() {
+		panic("mainthread: Run called outside the main thread")
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		body()
+	}()
+	for {
+		select {
+		case c := <-queue:
+			c.run()
+		case <-done:
+			Process()
+			return
+		}
+	}
+}
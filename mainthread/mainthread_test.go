@@ -0,0 +1,146 @@
+package mainthread
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// mainFuncs is the event loop of the test binary: TestMain runs on the main
+// goroutine and the tests, on their own goroutines, hand it work.
+var mainFuncs = make(chan func())
+
+func TestMain(m *testing.M) {
+	done := make(chan int)
+	go func() { done <- m.Run() }()
+	for {
+		select {
+		case fn := <-mainFuncs:
+			fn()
+		case code := <-done:
+			os.Exit(code)
+		}
+	}
+}
+
+// onMain runs fn on the main thread and waits for it.
+func onMain(fn func()) {
+	done := make(chan struct{})
+	mainFuncs <- func() {
+		defer close(done)
+		fn()
+	}
+	<-done
+}
+
+func TestRun(t *testing.T) {
+	if OnMain() {
+		t.Fatal("test goroutine on main")
+	}
+	var onMainInside, onMainOutside bool
+	var err error
+	onMain(func() {
+		onMainOutside = OnMain()
+		Run(func() {
+			err = CallErr(func() error {
+				onMainInside = OnMain()
+				return errors.New("failed")
+			})
+		})
+	})
+	if !onMainOutside || !onMainInside {
+		t.Errorf("on main: %v in the loop, %v in the call", onMainOutside, onMainInside)
+	}
+	if err == nil || err.Error() != "failed" {
+		t.Errorf("error %v", err)
+	}
+	if Pending() != 0 {
+		t.Errorf("%d calls left", Pending())
+	}
+}
+
+func TestPanic(t *testing.T) {
+	var err error
+	var recovered interface{}
+	onMain(func() {
+		Run(func() {
+			err = CallErr(func() error { panic("boom") })
+			func() {
+				defer func() { recovered = recover() }()
+				Call(func() { panic("bang") })
+			}()
+		})
+	})
+	if pe, ok := err.(*PanicError); !ok || pe.Value != "boom" || len(pe.Stack) == 0 {
+		t.Errorf("CallErr returned %#v", err)
+	}
+	if pe, ok := recovered.(*PanicError); !ok || pe.Value != "bang" {
+		t.Errorf("Call panicked with %#v", recovered)
+	}
+
+	// Nobody waits for a Post, so the panic comes out of Process
+	Post(func() { panic("posted") })
+	onMain(func() {
+		defer func() { recovered = recover() }()
+		Process()
+	})
+	if pe, ok := recovered.(*PanicError); !ok || pe.Value != "posted" {
+		t.Errorf("Process panicked with %#v", recovered)
+	}
+}
+
+func TestProcessLater(t *testing.T) {
+	var order []int
+	Post(func() {
+		order = append(order, 1)
+		Post(func() { order = append(order, 3) })
+		// On the main thread CallErr runs at once
+		CallErr(func() error {
+			order = append(order, 2)
+			return nil
+		})
+	})
+	var n int
+	onMain(func() { n = Process() })
+	if n != 1 || Pending() != 1 || len(order) != 2 {
+		t.Fatalf("first Process ran %d, order %v, %d pending", n, order, Pending())
+	}
+	onMain(func() { n = Process() })
+	if n != 1 || Pending() != 0 || len(order) != 3 || order[2] != 3 {
+		t.Errorf("second Process ran %d, order %v", n, order)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Process ran outside the main thread")
+		}
+	}()
+	Process()
+}
+
+func TestProcessFor(t *testing.T) {
+	const calls = 10
+	ran := 0
+	for i := 0; i < calls; i++ {
+		Post(func() {
+			ran++
+			time.Sleep(5 * time.Millisecond)
+		})
+	}
+	var n int
+	onMain(func() { n = ProcessFor(12 * time.Millisecond) })
+	if n < 1 || n >= calls || n != ran || Pending() != calls-n {
+		t.Errorf("ran %d of %d within the budget, %d pending", n, calls, Pending())
+	}
+	// At least one call runs, however short the budget
+	before := ran
+	onMain(func() { n = ProcessFor(time.Nanosecond) })
+	if n != 1 || ran != before+1 {
+		t.Errorf("ran %d with a tiny budget", n)
+	}
+	onMain(func() { n = ProcessFor(0) })
+	if ran != calls || Pending() != 0 {
+		t.Errorf("ran %d of %d without a budget", ran, calls)
+	}
+}
@@ -0,0 +1,118 @@
+package motion
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Controller is a game controller with its motion sensors. The gyro turns
+// into mouse movement through Aim; RatchetButton held stops it.
+type Controller struct {
+	GameController *sdl.GameController
+	ID             sdl.JoystickID
+	Gyro           *Gyro
+	Accel          *Accel
+	Aim            *Aim
+	RatchetButton  sdl.GameControllerButton // sdl.CONTROLLER_BUTTON_INVALID for none
+
+	dx, dy   int32
+	last     uint32
+	started  bool
+	gyroRate float32
+}
+
+// Open opens the game controller at device index and turns on its gyro
+// and accelerometer. A controller without sensors opens fine, HasGyro and
+// HasAccel tell.
+func Open(index int) (*Controller, error) {
+	ctrl := sdl.GameControllerOpen(index)
+	if ctrl == nil {
+		return nil, sdl.GetError()
+	}
+	c := &Controller{
+		GameController: ctrl,
+		ID:             ctrl.Joystick().InstanceID(),
+		Gyro:           NewGyro(),
+		Accel:          NewAccel(),
+		Aim:            NewAim(),
+		RatchetButton:  sdl.CONTROLLER_BUTTON_LEFTSHOULDER,
+	}
+	for _, typ := range []sdl.SensorType{sdl.SENSOR_GYRO, sdl.SENSOR_ACCEL} {
+		if HasSensor(ctrl, typ) {
+			SetSensorEnabled(ctrl, typ, true)
+		}
+	}
+	c.gyroRate = ctrl.SensorDataRate(sdl.SENSOR_GYRO)
+	return c, nil
+}
+
+// Close turns the sensors off and closes the controller.
+func (c *Controller) Close() {
+	c.Enable(false)
+	c.GameController.Close()
+}
+
+// HasGyro reports whether the controller has a gyroscope.
+func (c *Controller) HasGyro() bool { return HasSensor(c.GameController, sdl.SENSOR_GYRO) }
+
+// HasAccel reports whether the controller has an accelerometer.
+func (c *Controller) HasAccel() bool { return HasSensor(c.GameController, sdl.SENSOR_ACCEL) }
+
+// Enabled reports whether the gyro reports.
+func (c *Controller) Enabled() bool { return SensorEnabled(c.GameController, sdl.SENSOR_GYRO) }
+
+// Enable turns both sensors on or off.
+func (c *Controller) Enable(on bool) error {
+	var err error
+	for _, typ := range []sdl.SensorType{sdl.SENSOR_GYRO, sdl.SENSOR_ACCEL} {
+		if HasSensor(c.GameController, typ) {
+			if e := SetSensorEnabled(c.GameController, typ, on); e != nil {
+				err = e
+			}
+		}
+	}
+	if !on {
+		c.started = false
+	}
+	return err
+}
+
+// DataRate returns the gyro reports per second, 0 when SDL doesn't know.
+func (c *Controller) DataRate() float32 { return c.gyroRate }
+
+// HandleEvent takes the sensor and ratchet button events of this
+// controller and reports whether the event was one.
+func (c *Controller) HandleEvent(event sdl.Event) bool {
+	if t, ok := event.(*sdl.ControllerButtonEvent); ok {
+		if t.Which != c.ID || sdl.GameControllerButton(t.Button) != c.RatchetButton {
+			return false
+		}
+		c.Aim.Ratchet = t.State == sdl.PRESSED
+		return true
+	}
+	e, ok := Decode(event)
+	if !ok || e.Which != c.ID {
+		return false
+	}
+	switch e.Sensor {
+	case sdl.SENSOR_GYRO:
+		rate := c.Gyro.Update(e.Data, e.Timestamp)
+		// Several reports can share a millisecond, the sum of the steps
+		// still matches the time passed.
+		if c.started {
+			dx, dy := c.Aim.Update(rate, float64(e.Timestamp-c.last)/1000)
+			c.dx += dx
+			c.dy += dy
+		}
+		c.last, c.started = e.Timestamp, true
+	case sdl.SENSOR_ACCEL:
+		c.Accel.Update(e.Data)
+	}
+	return true
+}
+
+// Motion returns the mouse movement since the last call.
+func (c *Controller) Motion() (dx, dy int32) {
+	dx, dy = c.dx, c.dy
+	c.dx, c.dy = 0, 0
+	return dx, dy
+}
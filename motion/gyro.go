@@ -0,0 +1,162 @@
+package motion
+
+import (
+	"math"
+)
+
+// Gyro removes the bias of a gyroscope. Calibrate it by holding the
+// controller still between StartCalibration and FinishCalibration; after
+// that the bias follows slow drift whenever the controller lies still.
+type Gyro struct {
+	Bias           [3]float64 // rad/s subtracted from every sample
+	StillThreshold float64    // rad/s around the bias that counts as still
+	StillMS        uint32     // how long it has to be still before drift is followed
+	DriftRate      float64    // share of the difference the bias moves per sample
+
+	Rate [3]float64 // last sample without the bias
+
+	calibrating bool
+	sum         [3]float64
+	n           int
+	stillSince  uint32
+	still       bool
+}
+
+// NewGyro returns a gyro with no bias and drift compensation after half a
+// second of stillness.
+func NewGyro() *Gyro {
+	return &Gyro{StillThreshold: 0.03, StillMS: 500, DriftRate: 0.002}
+}
+
+// StartCalibration starts averaging samples into the bias.
+func (g *Gyro) StartCalibration() {
+	g.calibrating = true
+	g.sum = [3]float64{}
+	g.n = 0
+}
+
+// Calibrating reports whether samples are being averaged.
+func (g *Gyro) Calibrating() bool { return g.calibrating }
+
+// FinishCalibration sets the bias to the average of the samples since
+// StartCalibration. It reports false, keeping the old bias, when there
+// were none.
+func (g *Gyro) FinishCalibration() bool {
+	g.calibrating = false
+	if g.n == 0 {
+		return false
+	}
+	for i := range g.Bias {
+		g.Bias[i] = g.sum[i] / float64(g.n)
+	}
+	return true
+}
+
+// Still reports whether the controller has been still for StillMS.
+func (g *Gyro) Still() bool { return g.still }
+
+// Update takes a sample and returns it without the bias. While
+// calibrating the result is zero.
+func (g *Gyro) Update(data [3]float32, timestamp uint32) [3]float64 {
+	var raw [3]float64
+	for i, v := range data {
+		raw[i] = float64(v)
+	}
+	if g.calibrating {
+		for i := range raw {
+			g.sum[i] += raw[i]
+		}
+		g.n++
+		g.Rate = [3]float64{}
+		return g.Rate
+	}
+	moving := false
+	for i := range raw {
+		if math.Abs(raw[i]-g.Bias[i]) > g.StillThreshold {
+			moving = true
+		}
+	}
+	switch {
+	case moving:
+		g.stillSince, g.still = 0, false
+	case g.stillSince == 0:
+		g.stillSince = timestamp
+	case timestamp-g.stillSince >= g.StillMS:
+		g.still = true
+	}
+	if g.still {
+		for i := range raw {
+			g.Bias[i] += (raw[i] - g.Bias[i]) * g.DriftRate
+		}
+	}
+	for i := range raw {
+		g.Rate[i] = raw[i] - g.Bias[i]
+	}
+	return g.Rate
+}
+
+// Accel smooths the accelerometer into the direction of gravity.
+type Accel struct {
+	Gravity   [3]float64 // m/s², smoothed
+	Smoothing float64    // 0 follows every sample, closer to 1 is smoother
+}
+
+// NewAccel returns an accelerometer filter lying flat.
+func NewAccel() *Accel {
+	return &Accel{Gravity: [3]float64{0, 9.80665, 0}, Smoothing: 0.8}
+}
+
+// Update takes a sample.
+func (a *Accel) Update(data [3]float32) {
+	for i, v := range data {
+		a.Gravity[i] = a.Gravity[i]*a.Smoothing + float64(v)*(1-a.Smoothing)
+	}
+}
+
+// Tilt returns how far the controller is tipped forward (pitch) and to
+// the right (roll), in degrees.
+func (a *Accel) Tilt() (pitch, roll float64) {
+	x, y, z := a.Gravity[0], a.Gravity[1], a.Gravity[2]
+	pitch = math.Atan2(z, math.Hypot(x, y)) * 180 / math.Pi
+	roll = math.Atan2(x, math.Hypot(y, z)) * 180 / math.Pi
+	return pitch, roll
+}
+
+// Aim turns turning the controller into mouse movement. Yaw moves the
+// cursor sideways and pitch up and down.
+type Aim struct {
+	Sensitivity float64 // pixels per degree turned
+	Deadzone    float64 // rad/s of shaking ignored
+	InvertY     bool
+	Ratchet     bool // held: turning doesn't move, to re-center like lifting a mouse
+
+	rx, ry float64 // fractions of pixels not moved yet
+}
+
+// NewAim returns an aim of 10 pixels per degree.
+func NewAim() *Aim {
+	return &Aim{Sensitivity: 10, Deadzone: 0.01}
+}
+
+// Update turns rate, held for dt seconds, into whole pixels; the
+// fractions are kept for the next update.
+func (a *Aim) Update(rate [3]float64, dt float64) (dx, dy int32) {
+	if a.Ratchet || dt <= 0 {
+		return 0, 0
+	}
+	yaw, pitch := rate[1], rate[0]
+	if math.Hypot(yaw, pitch) < a.Deadzone {
+		return 0, 0
+	}
+	scale := a.Sensitivity * 180 / math.Pi * dt
+	a.rx -= yaw * scale
+	if a.InvertY {
+		a.ry += pitch * scale
+	} else {
+		a.ry -= pitch * scale
+	}
+	dx, dy = int32(a.rx), int32(a.ry)
+	a.rx -= float64(dx)
+	a.ry -= float64(dy)
+	return dx, dy
+}
@@ -0,0 +1,123 @@
+// Package motion reads the gyroscope and accelerometer of game controllers
+// and turns them into aiming: calibration, drift compensation and
+// gyro-to-mouse with a ratchet button.
+package motion
+
+/*
+#cgo windows LDFLAGS: -lSDL2
+#cgo linux freebsd darwin openbsd pkg-config: sdl2
+#include "SDL.h"
+
+#if !SDL_VERSION_ATLEAST(2,0,14)
+static SDL_bool SDL_GameControllerHasSensor(SDL_GameController *gamecontroller, SDL_SensorType type)
+{
+	return SDL_FALSE;
+}
+
+static int SDL_GameControllerSetSensorEnabled(SDL_GameController *gamecontroller, SDL_SensorType type, SDL_bool enabled)
+{
+	return SDL_SetError("controller sensors need SDL 2.0.14");
+}
+
+static SDL_bool SDL_GameControllerIsSensorEnabled(SDL_GameController *gamecontroller, SDL_SensorType type)
+{
+	return SDL_FALSE;
+}
+
+static int SDL_GameControllerGetSensorData(SDL_GameController *gamecontroller, SDL_SensorType type, float *data, int num_values)
+{
+	return SDL_SetError("controller sensors need SDL 2.0.14");
+}
+#endif
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// ControllerSensorUpdate is SDL_CONTROLLERSENSORUPDATE, which go-sdl2 v0.4
+// doesn't decode; PollEvent returns it as an *sdl.CommonEvent.
+const ControllerSensorUpdate = 0x659
+
+// Event is a decoded SDL_ControllerSensorEvent. Gyroscope data is in
+// radians per second around the X (pitch), Y (yaw) and Z (roll) axes,
+// accelerometer data in m/s² along them.
+type Event struct {
+	Timestamp uint32
+	Which     sdl.JoystickID
+	Sensor    sdl.SensorType
+	Data      [3]float32
+}
+
+// cEvent has the layout of SDL_ControllerSensorEvent.
+type cEvent struct {
+	Type      uint32
+	Timestamp uint32
+	Which     int32
+	Sensor    int32
+	Data      [3]float32
+}
+
+// Decode returns the sensor event in event, which must come straight from
+// sdl.PollEvent: the CommonEvent it returns points into SDL's whole event.
+func Decode(event sdl.Event) (Event, bool) {
+	common, ok := event.(*sdl.CommonEvent)
+	if !ok || common.Type != ControllerSensorUpdate {
+		return Event{}, false
+	}
+	c := (*cEvent)(unsafe.Pointer(common))
+	return Event{
+		Timestamp: c.Timestamp,
+		Which:     sdl.JoystickID(c.Which),
+		Sensor:    sdl.SensorType(c.Sensor),
+		Data:      c.Data,
+	}, true
+}
+
+func cptr(ctrl *sdl.GameController) *C.SDL_GameController {
+	return (*C.SDL_GameController)(unsafe.Pointer(ctrl))
+}
+
+func sdlError(fallback string) error {
+	if err := sdl.GetError(); err != nil {
+		return err
+	}
+	return errors.New(fallback)
+}
+
+// HasSensor reports whether the controller has a sensor of type typ.
+func HasSensor(ctrl *sdl.GameController, typ sdl.SensorType) bool {
+	return C.SDL_GameControllerHasSensor(cptr(ctrl), C.SDL_SensorType(typ)) == C.SDL_TRUE
+}
+
+// SetSensorEnabled turns the reports of a sensor on or off. Sensors are
+// off when a controller is opened.
+func SetSensorEnabled(ctrl *sdl.GameController, typ sdl.SensorType, enabled bool) error {
+	on := C.SDL_bool(C.SDL_FALSE)
+	if enabled {
+		on = C.SDL_TRUE
+	}
+	if C.SDL_GameControllerSetSensorEnabled(cptr(ctrl), C.SDL_SensorType(typ), on) != 0 {
+		return sdlError("sensor not supported")
+	}
+	return nil
+}
+
+// SensorEnabled reports whether a sensor reports.
+func SensorEnabled(ctrl *sdl.GameController, typ sdl.SensorType) bool {
+	return C.SDL_GameControllerIsSensorEnabled(cptr(ctrl), C.SDL_SensorType(typ)) == C.SDL_TRUE
+}
+
+// SensorData returns the last values of a sensor, for polling instead of
+// events.
+func SensorData(ctrl *sdl.GameController, typ sdl.SensorType) ([3]float32, error) {
+	var data [3]float32
+	if C.SDL_GameControllerGetSensorData(cptr(ctrl), C.SDL_SensorType(typ), (*C.float)(unsafe.Pointer(&data[0])), 3) != 0 {
+		return data, sdlError("sensor not supported")
+	}
+	return data, nil
+}
@@ -0,0 +1,214 @@
+package mugen
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// CmdFile holds the commands of a MUGEN .cmd file.
+type CmdFile struct {
+	Remap      map[Keys]Keys // [Remap], button pressed to button seen, 0 disables it
+	Time       int           // [Defaults] command.time
+	BufferTime int           // [Defaults] command.buffer.time
+	Commands   []*Command
+}
+
+// CmdError is a syntax error with its position.
+type CmdError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *CmdError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *CmdError) Unwrap() error {
+	return e.Err
+}
+
+// LoadCmd reads a .cmd file.
+func LoadCmd(filename string) (*CmdFile, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseCmd(f, filename)
+}
+
+// pendingCommand is a [Command] section being read.
+type pendingCommand struct {
+	line                  int
+	name, command         string
+	time, bufferTime      int
+	nameLine, commandLine int
+}
+
+// ParseCmd reads the [Remap], [Defaults] and [Command] sections of a .cmd
+// file. The [Statedef] and [State] sections that usually follow are
+// skipped, any other section is an error. filename is only used in error
+// messages.
+func ParseCmd(r io.Reader, filename string) (*CmdFile, error) {
+	f := &CmdFile{Time: DefaultTime, BufferTime: DefaultBufferTime}
+	var pending []*pendingCommand
+	var cur *pendingCommand
+	section := ""
+	fail := func(line int, format string, args ...interface{}) error {
+		return &CmdError{File: filename, Line: line, Err: fmt.Errorf(format, args...)}
+	}
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if n == 1 {
+			line = strings.TrimPrefix(line, "\ufeff")
+		}
+		if i := strings.IndexByte(line, ';'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return nil, fail(n, "missing ] in section header %q", line)
+			}
+			section = strings.ToLower(strings.TrimSpace(line[1:end]))
+			cur = nil
+			switch {
+			case section == "command":
+				cur = &pendingCommand{line: n}
+				pending = append(pending, cur)
+			case section == "remap", section == "defaults":
+			case strings.HasPrefix(section, "statedef"), strings.HasPrefix(section, "state "):
+			default:
+				return nil, fail(n, "unknown section %s", line[:end+1])
+			}
+			continue
+		}
+
+		if section != "remap" && section != "defaults" && section != "command" {
+			continue
+		}
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			return nil, fail(n, "expected key = value, got %q", line)
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:eq]))
+		value := strings.TrimSpace(line[eq+1:])
+
+		switch section {
+		case "remap":
+			from, ok := buttonKeys(key)
+			if !ok {
+				return nil, fail(n, "unknown button %q", key)
+			}
+			to := Keys(0)
+			if value != "" {
+				if to, ok = buttonKeys(strings.ToLower(value)); !ok {
+					return nil, fail(n, "unknown button %q", value)
+				}
+			}
+			if f.Remap == nil {
+				f.Remap = make(map[Keys]Keys)
+			}
+			f.Remap[from] = to
+		case "defaults":
+			if key != "command.time" && key != "command.buffer.time" {
+				break
+			}
+			v, err := strconv.Atoi(value)
+			if err != nil || v < 1 {
+				return nil, fail(n, "%s must be a positive number, got %q", key, value)
+			}
+			if key == "command.time" {
+				f.Time = v
+			} else {
+				f.BufferTime = v
+			}
+		case "command":
+			switch key {
+			case "name":
+				if cur.nameLine > 0 {
+					return nil, fail(n, "name already set on line %d", cur.nameLine)
+				}
+				if len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+					return nil, fail(n, "name must be quoted, got %s", value)
+				}
+				cur.name, cur.nameLine = value[1:len(value)-1], n
+			case "command":
+				if cur.commandLine > 0 {
+					return nil, fail(n, "command already set on line %d", cur.commandLine)
+				}
+				if value == "" {
+					return nil, fail(n, "empty command")
+				}
+				cur.command, cur.commandLine = value, n
+			case "time", "buffer.time":
+				v, err := strconv.Atoi(value)
+				if err != nil || v < 1 {
+					return nil, fail(n, "%s must be a positive number, got %q", key, value)
+				}
+				if key == "time" {
+					cur.time = v
+				} else {
+					cur.bufferTime = v
+				}
+			}
+			// Other keys of MUGEN 1.1 (buffer.hitpause, ...) are ignored
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", filename, err)
+	}
+
+	// [Defaults] may come after the commands, so they are built last
+	for _, p := range pending {
+		if p.nameLine == 0 {
+			return nil, fail(p.line, "[Command] without name")
+		}
+		if p.commandLine == 0 {
+			return nil, fail(p.line, "[Command] %q without command", p.name)
+		}
+		time, bufferTime := p.time, p.bufferTime
+		if time == 0 {
+			time = f.Time
+		}
+		if bufferTime == 0 {
+			bufferTime = f.BufferTime
+		}
+		c, err := ParseCommand(p.name, p.command, time, bufferTime)
+		if err != nil {
+			return nil, fail(p.commandLine, "%v", err)
+		}
+		f.Commands = append(f.Commands, c)
+	}
+	return f, nil
+}
+
+// buttonKeys returns the button named name, a b c x y z s d w.
+func buttonKeys(name string) (Keys, bool) {
+	for _, b := range buttonNames {
+		if b.name == name {
+			return b.keys, true
+		}
+	}
+	return 0, false
+}
+
+// Recognizer returns a recognizer for the commands of the file with its
+// button remapping.
+func (f *CmdFile) Recognizer() *Recognizer {
+	r := NewRecognizer(f.Commands...)
+	r.Remap = f.Remap
+	return r
+}
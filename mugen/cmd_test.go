@@ -0,0 +1,114 @@
+package mugen
+
+import (
+	"strings"
+	"testing"
+)
+
+const testCmd = "\ufeff; test commands\n" + `
+[Remap]
+x = y
+y = x
+s =
+
+[Command]
+name = "Hadouken"
+command = ~D, DF, F, x
+
+[Command]
+name = "Sonic Boom"
+command = ~30$B, F, x
+time = 10 ; charge moves are given less time
+
+[Command]
+name = "a+b"
+command = a+b, >/F
+buffer.time = 4
+
+[Defaults]
+command.time = 20
+command.buffer.time = 2
+
+[Statedef -1]
+
+[State -1, Hadouken]
+type = ChangeState
+trigger1 = command = "Hadouken"
+`
+
+func TestParseCmd(t *testing.T) {
+	f, err := ParseCmd(strings.NewReader(testCmd), "test.cmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Time != 20 || f.BufferTime != 2 {
+		t.Errorf("defaults time %d, buffer.time %d", f.Time, f.BufferTime)
+	}
+	if len(f.Remap) != 3 || f.Remap[ButtonX] != ButtonY || f.Remap[ButtonY] != ButtonX || f.Remap[ButtonS] != 0 {
+		t.Errorf("remap %v", f.Remap)
+	}
+
+	tests := []struct {
+		name       string
+		steps      []Step
+		time       int
+		bufferTime int
+	}{
+		{"Hadouken", []Step{
+			{Elements: []Element{{Keys: DirD, Mode: Release}}},
+			{Elements: []Element{{Keys: DirD | DirF}}},
+			{Elements: []Element{{Keys: DirF}}},
+			{Elements: []Element{{Keys: ButtonX}}},
+		}, 20, 2},
+		{"Sonic Boom", []Step{
+			{Elements: []Element{{Keys: DirB, Mode: Release, Loose: true, Charge: 30}}},
+			{Elements: []Element{{Keys: DirF}}},
+			{Elements: []Element{{Keys: ButtonX}}},
+		}, 10, 2},
+		{"a+b", []Step{
+			{Elements: []Element{{Keys: ButtonA}, {Keys: ButtonB}}},
+			{Elements: []Element{{Keys: DirF, Mode: Hold}}, Greater: true},
+		}, 20, 4},
+	}
+	if len(f.Commands) != len(tests) {
+		t.Fatalf("%d commands, want %d", len(f.Commands), len(tests))
+	}
+	for i, tt := range tests {
+		c := f.Commands[i]
+		if c.Name != tt.name || c.Time != tt.time || c.BufferTime != tt.bufferTime {
+			t.Errorf("command %d: %q time %d, buffer.time %d", i, c.Name, c.Time, c.BufferTime)
+		}
+		if len(c.Steps) != len(tt.steps) {
+			t.Errorf("%s: %d steps, want %d", c.Name, len(c.Steps), len(tt.steps))
+			continue
+		}
+		for j, s := range c.Steps {
+			if !s.equal(tt.steps[j]) {
+				t.Errorf("%s: step %d is %+v, want %+v", c.Name, j, s, tt.steps[j])
+			}
+		}
+	}
+}
+
+func TestParseCmdErrors(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want string
+	}{
+		{"[Command]\nname = \"bad\"\ncommand = D, DF, F, q\n", `test.cmd:3: command "bad": unknown key "q"`},
+		{"[Command]\nname = \"bad\"\ncommand = $x\n", `test.cmd:3: command "bad": "$x": $ only applies to directions`},
+		{"[Command]\nname = \"bad\"\ncommand =\n", "test.cmd:3: empty command"},
+		{"[Command]\nname = \"slow\"\ncommand = x\ntime = soon\n", `test.cmd:4: time must be a positive number, got "soon"`},
+		{"[Command]\nname = \"slow\"\ncommand = x\n\n\nbuffer.time = 0\n", `test.cmd:6: buffer.time must be a positive number, got "0"`},
+		{"[Defaults]\ncommand.time = -1\n", `test.cmd:2: command.time must be a positive number, got "-1"`},
+		{"[Remap]\nx = y\n\n[Commands]\nname = \"x\"\n", "test.cmd:4: unknown section [Commands]"},
+		{"[Command\n", `test.cmd:1: missing ] in section header "[Command"`},
+		{"[Command]\ncommand = x\n", "test.cmd:1: [Command] without name"},
+	}
+	for _, tt := range tests {
+		_, err := ParseCmd(strings.NewReader(tt.cmd), "test.cmd")
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%q: error %v, want %s", tt.cmd, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Package mugen recognizes fighting game commands written in the syntax of
+// Elecbyte MUGEN .cmd files, e.g. "~D, DF, F, x" for a quarter circle
+// forward and punch.
+package mugen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Keys is a set of held directions and buttons. Directions are relative to
+// the facing: DirB is back and DirF is forward.
+type Keys uint16
+
+const (
+	DirU Keys = 1 << iota
+	DirD
+	DirB
+	DirF
+	ButtonA
+	ButtonB
+	ButtonC
+	ButtonX
+	ButtonY
+	ButtonZ
+	ButtonS
+	ButtonD
+	ButtonW
+)
+
+const dirMask = DirU | DirD | DirB | DirF
+
+var dirNames = []struct {
+	name string
+	keys Keys
+}{
+	{"B", DirB}, {"DB", DirD | DirB}, {"D", DirD}, {"DF", DirD | DirF},
+	{"F", DirF}, {"UF", DirU | DirF}, {"U", DirU}, {"UB", DirU | DirB},
+}
+
+var buttonNames = []struct {
+	name string
+	keys Keys
+}{
+	{"a", ButtonA}, {"b", ButtonB}, {"c", ButtonC}, {"x", ButtonX}, {"y", ButtonY},
+	{"z", ButtonZ}, {"s", ButtonS}, {"d", ButtonD}, {"w", ButtonW},
+}
+
+// Dir returns the direction part of k.
+func (k Keys) Dir() Keys {
+	return k & dirMask
+}
+
+// Flip swaps back and forward, turning screen directions into the
+// directions of a player facing left.
+func (k Keys) Flip() Keys {
+	f := k &^ (DirB | DirF)
+	if k&DirB != 0 {
+		f |= DirF
+	}
+	if k&DirF != 0 {
+		f |= DirB
+	}
+	return f
+}
+
+// clean cancels opposite directions held together.
+func (k Keys) clean() Keys {
+	if k&(DirB|DirF) == DirB|DirF {
+		k &^= DirB | DirF
+	}
+	if k&(DirU|DirD) == DirU|DirD {
+		k &^= DirU | DirD
+	}
+	return k
+}
+
+// String returns k in command syntax, e.g. "DF+x+y".
+func (k Keys) String() string {
+	var parts []string
+	for _, d := range dirNames {
+		if k.Dir() == d.keys {
+			parts = append(parts, d.name)
+		}
+	}
+	for _, b := range buttonNames {
+		if k&b.keys != 0 {
+			parts = append(parts, b.name)
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, "+")
+}
+
+// Mode tells what an element waits for.
+type Mode int
+
+const (
+	Press   Mode = iota // the key goes down
+	Release             // ~ the key goes up
+	Hold                // / the key is down
+)
+
+// Element is one key of a command step.
+type Element struct {
+	Keys   Keys // a direction or a single button
+	Mode   Mode
+	Loose  bool // $ a direction also matches the diagonals containing it
+	Charge int  // ~30 the key was held at least this many ticks before release
+}
+
+func (e Element) isDir() bool {
+	return e.Keys&dirMask != 0
+}
+
+// held reports whether the element's key is down in k.
+func (e Element) held(k Keys) bool {
+	switch {
+	case !e.isDir():
+		return k&e.Keys != 0
+	case e.Loose:
+		return k.Dir()&e.Keys == e.Keys
+	}
+	return k.Dir() == e.Keys
+}
+
+// Step is one comma separated part of a command. Several elements joined
+// with + must happen together.
+type Step struct {
+	Elements []Element
+	Greater  bool // > no other key may change between the previous step and this one
+}
+
+// mask returns the keys that may change around the step without breaking a
+// > condition.
+func (s Step) mask() Keys {
+	var m Keys
+	for _, e := range s.Elements {
+		if e.isDir() {
+			m |= dirMask
+		} else {
+			m |= e.Keys
+		}
+	}
+	return m
+}
+
+func (s Step) equal(o Step) bool {
+	if s.Greater != o.Greater || len(s.Elements) != len(o.Elements) {
+		return false
+	}
+	for i := range s.Elements {
+		if s.Elements[i] != o.Elements[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Command is a named sequence of steps.
+type Command struct {
+	Name       string
+	Source     string // the command as written
+	Steps      []Step
+	Time       int // ticks allowed from the first to the last step
+	BufferTime int // ticks the command stays active once completed
+}
+
+// Default command timing of MUGEN.
+const (
+	DefaultTime       = 15
+	DefaultBufferTime = 1
+)
+
+// ParseCommand parses a command string such as "~30$B, F, x" or "x+y".
+// time and bufferTime of 0 use the MUGEN defaults.
+func ParseCommand(name, command string, time, bufferTime int) (*Command, error) {
+	c := &Command{Name: name, Source: command, Time: time, BufferTime: bufferTime}
+	if c.Time <= 0 {
+		c.Time = DefaultTime
+	}
+	if c.BufferTime <= 0 {
+		c.BufferTime = DefaultBufferTime
+	}
+	for _, field := range strings.Split(command, ",") {
+		var step Step
+		field = strings.TrimSpace(field)
+		if strings.HasPrefix(field, ">") {
+			step.Greater = true
+			field = strings.TrimSpace(field[1:])
+		}
+		for _, part := range strings.Split(field, "+") {
+			e, err := parseElement(strings.TrimSpace(part))
+			if err != nil {
+				return nil, fmt.Errorf("command %q: %v", name, err)
+			}
+			step.Elements = append(step.Elements, e)
+		}
+		c.Steps = append(c.Steps, step)
+	}
+	return c, nil
+}
+
+func parseElement(s string) (Element, error) {
+	var e Element
+	orig := s
+	for len(s) > 0 {
+		switch s[0] {
+		case '~':
+			e.Mode = Release
+			s = s[1:]
+			n := 0
+			for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+				n++
+			}
+			if n > 0 {
+				e.Charge, _ = strconv.Atoi(s[:n])
+				s = s[n:]
+			}
+			continue
+		case '/':
+			e.Mode = Hold
+			s = s[1:]
+			continue
+		case '$':
+			e.Loose = true
+			s = s[1:]
+			continue
+		}
+		break
+	}
+	for _, d := range dirNames {
+		if s == d.name {
+			e.Keys = d.keys
+			return e, nil
+		}
+	}
+	for _, b := range buttonNames {
+		if s == b.name {
+			if e.Loose {
+				return e, fmt.Errorf("%q: $ only applies to directions", orig)
+			}
+			e.Keys = b.keys
+			return e, nil
+		}
+	}
+	return e, fmt.Errorf("unknown key %q", orig)
+}
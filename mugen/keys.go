@@ -0,0 +1,133 @@
+package mugen
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+)
+
+// Mapping turns joystick and keyboard state into Keys in screen space,
+// DirB being left and DirF right.
+type Mapping struct {
+	Buttons  map[string]Keys // profile button name to keys
+	Keyboard map[sdl.Scancode]Keys
+	Stick    *input.Stick // optional, drives the directions like the d-pad
+}
+
+// DefaultMapping puts x y z on the top row (X Y R1) and a b c on the
+// bottom row (A B L1) of a pad, and uses the MUGEN default keyboard layout.
+func DefaultMapping() *Mapping {
+	return &Mapping{
+		Buttons: map[string]Keys{
+			"UP": DirU, "DOWN": DirD, "LEFT": DirB, "RIGHT": DirF,
+			"X": ButtonX, "Y": ButtonY, "R1": ButtonZ,
+			"A": ButtonA, "B": ButtonB, "L1": ButtonC,
+			"START": ButtonS,
+		},
+		Keyboard: map[sdl.Scancode]Keys{
+			sdl.SCANCODE_UP: DirU, sdl.SCANCODE_DOWN: DirD, sdl.SCANCODE_LEFT: DirB, sdl.SCANCODE_RIGHT: DirF,
+			sdl.SCANCODE_A: ButtonX, sdl.SCANCODE_S: ButtonY, sdl.SCANCODE_D: ButtonZ,
+			sdl.SCANCODE_Z: ButtonA, sdl.SCANCODE_X: ButtonB, sdl.SCANCODE_C: ButtonC,
+			sdl.SCANCODE_RETURN: ButtonS,
+		},
+		Stick: input.NewStick(0, 1),
+	}
+}
+
+// HatKeys converts a hat position to directions.
+func HatKeys(hat uint8) Keys {
+	var k Keys
+	if hat&sdl.HAT_UP != 0 {
+		k |= DirU
+	}
+	if hat&sdl.HAT_DOWN != 0 {
+		k |= DirD
+	}
+	if hat&sdl.HAT_LEFT != 0 {
+		k |= DirB
+	}
+	if hat&sdl.HAT_RIGHT != 0 {
+		k |= DirF
+	}
+	return k
+}
+
+// Device reads hat 0, the stick and the named buttons of a device. cal may
+// be nil.
+func (m *Mapping) Device(d *input.Device, cal *input.Calibration) Keys {
+	if d == nil {
+		return 0
+	}
+	k := HatKeys(d.Hat(0))
+	if m.Stick != nil {
+		_, _, hat := m.Stick.UpdateDevice(cal, d)
+		k |= HatKeys(hat)
+	}
+	for name, keys := range m.Buttons {
+		if d.Button(d.Profile.Button(name)) {
+			k |= keys
+		}
+	}
+	return k
+}
+
+// KeyboardState reads the keys from sdl.GetKeyboardState().
+func (m *Mapping) KeyboardState(state []uint8) Keys {
+	var k Keys
+	for code, keys := range m.Keyboard {
+		if int(code) < len(state) && state[code] != 0 {
+			k |= keys
+		}
+	}
+	return k
+}
+
+// Input collects the keys of one player from SDL events. A button that
+// goes down and up again between two ticks is still seen for one tick,
+// which polling the state would miss. Directions are taken as they are.
+type Input struct {
+	Mapping     *Mapping
+	Keyboard    bool               // read the keyboard
+	Device      *input.Device      // joystick of the player, may be nil
+	Calibration *input.Calibration // optional
+
+	keyboard Keys // held on the keyboard
+	latched  Keys // buttons pressed since the last tick
+}
+
+// NewInput returns an input reading the keyboard with the default mapping.
+func NewInput() *Input {
+	return &Input{Mapping: DefaultMapping(), Keyboard: true}
+}
+
+// HandleEvent records key and button presses. Call it for every event,
+// after the registry has handled it.
+func (in *Input) HandleEvent(event sdl.Event) {
+	switch t := event.(type) {
+	case *sdl.KeyboardEvent:
+		if !in.Keyboard || t.Repeat != 0 {
+			break
+		}
+		k := in.Mapping.Keyboard[t.Keysym.Scancode]
+		if t.State == sdl.PRESSED {
+			in.keyboard |= k
+			in.latched |= k &^ dirMask
+		} else {
+			in.keyboard &^= k
+		}
+	case *sdl.JoyButtonEvent:
+		if in.Device != nil && in.Device.Connected && t.Which == in.Device.ID && t.State == sdl.PRESSED {
+			in.latched |= in.Mapping.Buttons[in.Device.Profile.ButtonName(int(t.Button))] &^ dirMask
+		}
+	}
+}
+
+// Tick returns the keys of this tick in screen space and starts the next.
+func (in *Input) Tick() Keys {
+	k := in.keyboard | in.latched
+	if in.Device != nil && in.Device.Connected {
+		k |= in.Mapping.Device(in.Device, in.Calibration)
+	}
+	in.latched = 0
+	return k
+}
@@ -0,0 +1,233 @@
+package mugen
+
+// Leniency is how many ticks apart the keys of a + step may go down and
+// still count as pressed together.
+const Leniency = 1
+
+// Recognizer keeps an input history of one player and detects commands in
+// it. Call Update once per game tick.
+type Recognizer struct {
+	Commands []*Command
+	Remap    map[Keys]Keys // optional, see CmdFile.Remap
+
+	tick   int
+	hist   []Keys // ring buffer indexed by tick
+	active []int  // remaining buffer ticks per command
+}
+
+// NewRecognizer returns a recognizer for commands.
+func NewRecognizer(commands ...*Command) *Recognizer {
+	r := &Recognizer{hist: make([]Keys, 64)}
+	for _, c := range commands {
+		r.Add(c)
+	}
+	return r
+}
+
+// Add appends a command. Several commands may share a name, Active then
+// reports whether any of them completed.
+func (r *Recognizer) Add(c *Command) {
+	r.Commands = append(r.Commands, c)
+	r.active = append(r.active, 0)
+
+	// The history must reach back over the time window and the longest charge
+	need := c.Time + Leniency + 2
+	for _, s := range c.Steps {
+		for _, e := range s.Elements {
+			if n := c.Time + e.Charge + Leniency + 2; n > need {
+				need = n
+			}
+		}
+	}
+	if need > len(r.hist) {
+		hist := make([]Keys, need)
+		for t := r.tick - len(r.hist) + 1; t <= r.tick; t++ {
+			if t > 0 {
+				hist[t%need] = r.keys(t)
+			}
+		}
+		r.hist = hist
+	}
+}
+
+// keys returns the keys held at tick t, none for ticks out of the history.
+func (r *Recognizer) keys(t int) Keys {
+	if t <= 0 || t > r.tick || t <= r.tick-len(r.hist) {
+		return 0
+	}
+	return r.hist[t%len(r.hist)]
+}
+
+// Keys returns the keys of the last tick, relative to the facing.
+func (r *Recognizer) Keys() Keys {
+	return r.keys(r.tick)
+}
+
+// Update records the keys held this tick and checks every command. keys are
+// in screen space, DirB being left and DirF right; facing is 1 when the
+// player faces right and -1 when facing left.
+func (r *Recognizer) Update(keys Keys, facing int) {
+	if r.Remap != nil {
+		keys = r.remap(keys)
+	}
+	if facing < 0 {
+		keys = keys.Flip()
+	}
+	r.tick++
+	r.hist[r.tick%len(r.hist)] = keys.clean()
+	for i, c := range r.Commands {
+		if r.active[i] > 0 {
+			r.active[i]--
+		}
+		if r.match(c) {
+			r.active[i] = c.BufferTime
+		}
+	}
+}
+
+// remap replaces the buttons of keys according to Remap.
+func (r *Recognizer) remap(keys Keys) Keys {
+	out := keys.Dir()
+	for _, b := range buttonNames {
+		if keys&b.keys == 0 {
+			continue
+		}
+		if to, ok := r.Remap[b.keys]; ok {
+			out |= to
+		} else {
+			out |= b.keys
+		}
+	}
+	return out
+}
+
+// Active reports whether a command named name completed within its buffer
+// time.
+func (r *Recognizer) Active(name string) bool {
+	for i, c := range r.Commands {
+		if c.Name == name && r.active[i] > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveNames returns the names of the active commands.
+func (r *Recognizer) ActiveNames() []string {
+	var names []string
+	seen := make(map[string]bool)
+	for i, c := range r.Commands {
+		if r.active[i] > 0 && !seen[c.Name] {
+			seen[c.Name] = true
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// Reset clears the history and every active command.
+func (r *Recognizer) Reset() {
+	for i := range r.hist {
+		r.hist[i] = 0
+	}
+	for i := range r.active {
+		r.active[i] = 0
+	}
+}
+
+// heldFor counts the ticks e was held up to and including tick t.
+func (r *Recognizer) heldFor(e Element, t int) int {
+	n := 0
+	for ; t > r.tick-len(r.hist) && t > 0 && e.held(r.keys(t)); t-- {
+		n++
+	}
+	return n
+}
+
+// stepAt reports whether step s happens at tick t. A step happens on the
+// tick one of its keys changes, or on every tick when it only holds keys.
+func (r *Recognizer) stepAt(s Step, t int) bool {
+	cur := r.keys(t)
+	happens, holdOnly := false, true
+	for _, e := range s.Elements {
+		switch e.Mode {
+		case Hold:
+			if !e.held(cur) {
+				return false
+			}
+			continue
+		case Press:
+			if !e.held(cur) {
+				return false
+			}
+		case Release:
+			if e.held(cur) {
+				return false
+			}
+		}
+		holdOnly = false
+		// The key changed at t, or a little before for keys pressed together
+		edge := -1
+		for u := t; u >= t-Leniency && (u == t || len(s.Elements) > 1); u-- {
+			now, before := e.held(r.keys(u)), e.held(r.keys(u-1))
+			if e.Mode == Press && now && !before {
+				edge = u
+				break
+			}
+			if e.Mode == Release && !now && before && r.heldFor(e, u-1) >= e.Charge {
+				edge = u
+				break
+			}
+			if e.Mode == Press && !now || e.Mode == Release && now {
+				break
+			}
+		}
+		if edge < 0 {
+			return false
+		}
+		if edge == t {
+			happens = true
+		}
+	}
+	return happens || holdOnly
+}
+
+// match reports whether c completes on the current tick. The steps are
+// searched backwards from the last one, each taking the latest tick it
+// happened, which leaves the most time for the steps before it.
+func (r *Recognizer) match(c *Command) bool {
+	last := len(c.Steps) - 1
+	if last < 0 || !r.stepAt(c.Steps[last], r.tick) {
+		return false
+	}
+	oldest := r.tick - c.Time
+	if o := r.tick - len(r.hist) + 1; o > oldest {
+		oldest = o
+	}
+	t := r.tick
+	for i := last - 1; i >= 0; i-- {
+		step, next := c.Steps[i], c.Steps[i+1]
+		// Different steps may happen on the same tick, as the release and
+		// the press of "~D, DF"; a repeated step needs a tick of its own
+		u := t
+		if step.equal(next) {
+			u--
+		}
+		found := -1
+		for ; u >= oldest; u-- {
+			// > forbids other keys changing between the steps
+			if next.Greater && u+1 < t && (r.keys(u+1)^r.keys(u))&^(step.mask()|next.mask()) != 0 {
+				break
+			}
+			if r.stepAt(step, u) {
+				found = u
+				break
+			}
+		}
+		if found < 0 {
+			return false
+		}
+		t = found
+	}
+	return true
+}
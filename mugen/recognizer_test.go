@@ -0,0 +1,120 @@
+package mugen
+
+import "testing"
+
+// hold repeats k for n ticks.
+func hold(k Keys, n int) []Keys {
+	seq := make([]Keys, n)
+	for i := range seq {
+		seq[i] = k
+	}
+	return seq
+}
+
+// seq joins ticks and runs of ticks into one input sequence.
+func seq(parts ...interface{}) []Keys {
+	var s []Keys
+	for _, p := range parts {
+		switch p := p.(type) {
+		case Keys:
+			s = append(s, p)
+		case []Keys:
+			s = append(s, p...)
+		}
+	}
+	return s
+}
+
+func TestRecognizer(t *testing.T) {
+	const (
+		U, D, B, F = DirU, DirD, DirB, DirF
+		a, b, c, x = ButtonA, ButtonB, ButtonC, ButtonX
+	)
+	tests := []struct {
+		name    string
+		command string
+		time    int
+		facing  int
+		input   []Keys
+		want    bool
+	}{
+		{"qcf", "~D, DF, F, x", 0, 1, seq(Keys(0), D, D|F, F, F|x), true},
+		{"qcf held x", "~D, DF, F, x", 0, 1, seq(Keys(0), D, D|F, F|x, F|x), false},
+		{"qcf without DF", "~D, DF, F, x", 0, 1, seq(Keys(0), D, F, F|x), false},
+		{"qcf too slow", "~D, DF, F, x", 0, 1, seq(Keys(0), D, D|F, hold(F, 15), F|x), false},
+		{"qcf longer time", "~D, DF, F, x", 30, 1, seq(Keys(0), D, D|F, hold(F, 15), F|x), true},
+
+		{"charge", "~30$B, F, x", 0, 1, seq(hold(B, 30), F, F|x), true},
+		{"charge down-back", "~30$B, F, x", 0, 1, seq(hold(D|B, 40), F, F|x), true},
+		{"charge too short", "~30$B, F, x", 0, 1, seq(hold(B, 20), F, F|x), false},
+		{"charge strict dir", "~30B, F, x", 0, 1, seq(hold(D|B, 40), F, F|x), false},
+
+		{"hold", "/F, a", 0, 1, seq(Keys(0), F, F|a), true},
+		{"hold on the same tick", "/F+a", 0, 1, seq(F, F|a), true},
+		{"hold missing", "/F+a", 0, 1, seq(Keys(0), a), false},
+
+		{"loose dir", "$D, x", 0, 1, seq(Keys(0), D|F, D|F|x), true},
+		{"strict dir", "D, x", 0, 1, seq(Keys(0), D|F, D|F|x), false},
+		{"loose up", "$U, x", 0, 1, seq(Keys(0), U|B, x), true},
+
+		{"in order", "a, b", 0, 1, seq(a, Keys(0), c, Keys(0), b), true},
+		{"strict order", "a, >b", 0, 1, seq(a, Keys(0), b), true},
+		{"strict order broken", "a, >b", 0, 1, seq(a, Keys(0), c, Keys(0), b), false},
+		{"strict order with dirs", "a, >b", 0, 1, seq(a, Keys(0), F, Keys(0), b), false},
+
+		{"together", "a+b", 0, 1, seq(Keys(0), a|b), true},
+		{"together a tick apart", "a+b", 0, 1, seq(Keys(0), a, a|b), true},
+		{"together too far apart", "a+b", 0, 1, seq(Keys(0), a, a, a|b), false},
+		{"only one", "a+b", 0, 1, seq(Keys(0), a), false},
+
+		{"in time", "a, b", 5, 1, seq(a, hold(0, 3), b), true},
+		{"out of time", "a, b", 5, 1, seq(a, hold(0, 10), b), false},
+
+		{"facing right", "F, x", 0, 1, seq(Keys(0), F, F|x), true},
+		{"facing left", "F, x", 0, -1, seq(Keys(0), B, B|x), true},
+		{"facing left wrong way", "F, x", 0, -1, seq(Keys(0), F, F|x), false},
+		{"opposite dirs cancel", "F, x", 0, 1, seq(Keys(0), F|B, F|B|x), false},
+	}
+	for _, tt := range tests {
+		cmd, err := ParseCommand(tt.name, tt.command, tt.time, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := NewRecognizer(cmd)
+		for _, k := range tt.input {
+			r.Update(k, tt.facing)
+		}
+		if got := r.Active(tt.name); got != tt.want {
+			t.Errorf("%s: %q active %v, want %v", tt.name, tt.command, got, tt.want)
+		}
+	}
+}
+
+func TestRecognizerBufferTime(t *testing.T) {
+	cmd, err := ParseCommand("x", "x", 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewRecognizer(cmd)
+	input := seq(Keys(0), ButtonX, ButtonX, ButtonX, ButtonX, Keys(0))
+	want := []bool{false, true, true, true, false, false}
+	for i, k := range input {
+		r.Update(k, 1)
+		if got := r.Active("x"); got != want[i] {
+			t.Errorf("tick %d: active %v, want %v", i, got, want[i])
+		}
+	}
+
+	// Commands sharing a name are active when either completed
+	x, _ := ParseCommand("punch", "x", 0, 0)
+	y, _ := ParseCommand("punch", "y", 0, 0)
+	r = NewRecognizer(x, y)
+	r.Update(ButtonY, 1)
+	if names := r.ActiveNames(); len(names) != 1 || names[0] != "punch" {
+		t.Errorf("active names %v", names)
+	}
+	r.Reset()
+	if r.Active("punch") {
+		t.Error("active after Reset")
+	}
+}
@@ -0,0 +1,138 @@
+package rumble
+
+/*
+#cgo windows LDFLAGS: -lSDL2
+#cgo linux freebsd darwin openbsd pkg-config: sdl2
+#include "SDL.h"
+
+#if !SDL_VERSION_ATLEAST(2,0,14)
+static int SDL_JoystickRumbleTriggers(SDL_Joystick *joystick, Uint16 left_rumble, Uint16 right_rumble, Uint32 duration_ms)
+{
+	return SDL_SetError("trigger rumble needs SDL 2.0.14");
+}
+#endif
+*/
+import "C"
+
+import (
+	"errors"
+	"unsafe"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Device is something that rumbles. *sdl.Joystick and *sdl.GameController
+// are devices.
+type Device interface {
+	Rumble(low, high uint16, durationMS uint32) error
+}
+
+// TriggerDevice is a device with motors in the triggers, like Xbox One pads.
+type TriggerDevice interface {
+	Device
+	RumbleTriggers(left, right uint16, durationMS uint32) error
+}
+
+// joystick rumbles through SDL_JoystickRumble. go-sdl2 v0.4 doesn't wrap
+// SDL_JoystickRumbleTriggers, so it is called here directly.
+type joystick struct {
+	*sdl.Joystick
+}
+
+func (j joystick) RumbleTriggers(left, right uint16, durationMS uint32) error {
+	ret := C.SDL_JoystickRumbleTriggers((*C.SDL_Joystick)(unsafe.Pointer(j.Joystick)),
+		C.Uint16(left), C.Uint16(right), C.Uint32(durationMS))
+	if ret == 0 {
+		return nil
+	}
+	if err := sdl.GetError(); err != nil {
+		return err
+	}
+	return errors.New("trigger rumble not supported")
+}
+
+// haptic rumbles through the haptic subsystem, for drivers that only
+// support that. It has a single strength, the stronger motor wins.
+type haptic struct {
+	*sdl.Haptic
+}
+
+func (h haptic) Rumble(low, high uint16, durationMS uint32) error {
+	if high > low {
+		low = high
+	}
+	if low == 0 {
+		return h.RumbleStop()
+	}
+	return h.RumblePlay(float32(low)/0xffff, durationMS)
+}
+
+// Close closes the haptic device.
+func (h haptic) Close() error {
+	h.Haptic.Close()
+	return nil
+}
+
+// Open returns the rumble device of a joystick: the joystick itself when it
+// supports SDL_JoystickRumble, else its haptic device. It returns nil when
+// the joystick can't rumble at all; a service handles nil as silent.
+// The haptic subsystem has to be initialized for the second case.
+func Open(joy *sdl.Joystick) Device {
+	if joy == nil {
+		return nil
+	}
+	if joy.Rumble(0, 0, 0) == nil {
+		return joystick{joy}
+	}
+	h, err := sdl.HapticOpenFromJoystick(joy)
+	if err != nil {
+		return nil
+	}
+	if ok, err := h.RumbleSupported(); !ok || err != nil {
+		h.Close()
+		return nil
+	}
+	if err := h.RumbleInit(); err != nil {
+		h.Close()
+		return nil
+	}
+	return haptic{h}
+}
+
+// Call is one request made to a Fake device.
+type Call struct {
+	Low, High  uint16 // or left and right for trigger calls
+	DurationMS uint32
+	Triggers   bool
+}
+
+// Fake is a device that records what it is asked to play, to test pattern
+// playback without a pad.
+type Fake struct {
+	Calls       []Call
+	Low, High   uint16 // motors now
+	Left, Right uint16
+	Err         error // returned by Rumble when set, like a pad without motors
+	NoTriggers  bool  // RumbleTriggers fails
+}
+
+func (f *Fake) Rumble(low, high uint16, durationMS uint32) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	f.Calls = append(f.Calls, Call{Low: low, High: high, DurationMS: durationMS})
+	f.Low, f.High = low, high
+	return nil
+}
+
+func (f *Fake) RumbleTriggers(left, right uint16, durationMS uint32) error {
+	if f.Err != nil {
+		return f.Err
+	}
+	if f.NoTriggers {
+		return errors.New("no trigger motors")
+	}
+	f.Calls = append(f.Calls, Call{Low: left, High: right, DurationMS: durationMS, Triggers: true})
+	f.Left, f.Right = left, right
+	return nil
+}
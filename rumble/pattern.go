@@ -0,0 +1,90 @@
+// Package rumble plays force feedback patterns on joysticks, scaled per
+// player. Devices without rumble are accepted and stay silent.
+package rumble
+
+// Point is the intensity of the motors at one time of a pattern.
+// Intensities go from 0 (off) to 1 (full).
+type Point struct {
+	At    uint32  // milliseconds from the start of the pattern
+	Low   float64 // low frequency motor, left handle
+	High  float64 // high frequency motor, right handle
+	Left  float64 // left trigger motor, only on pads that have one
+	Right float64 // right trigger motor
+}
+
+// Pattern is an intensity curve, linear between its points. The motors
+// stop after the last point.
+type Pattern struct {
+	Name     string
+	Priority int // a pattern doesn't interrupt one with a higher priority
+	Points   []Point
+}
+
+// Duration returns the length of the pattern in milliseconds.
+func (p *Pattern) Duration() uint32 {
+	if len(p.Points) == 0 {
+		return 0
+	}
+	return p.Points[len(p.Points)-1].At
+}
+
+// At returns the intensities t milliseconds after the start, ok is false
+// once the pattern is over.
+func (p *Pattern) At(t uint32) (pt Point, ok bool) {
+	if len(p.Points) == 0 || t > p.Duration() {
+		return Point{At: t}, false
+	}
+	if t <= p.Points[0].At {
+		pt = p.Points[0]
+		pt.At = t
+		return pt, true
+	}
+	for i := 1; i < len(p.Points); i++ {
+		b := p.Points[i]
+		if t > b.At {
+			continue
+		}
+		a := p.Points[i-1]
+		f := 0.0
+		if b.At > a.At {
+			f = float64(t-a.At) / float64(b.At-a.At)
+		}
+		lerp := func(x, y float64) float64 { return x + (y-x)*f }
+		return Point{At: t, Low: lerp(a.Low, b.Low), High: lerp(a.High, b.High),
+			Left: lerp(a.Left, b.Left), Right: lerp(a.Right, b.Right)}, true
+	}
+	return Point{At: t}, false
+}
+
+// Hit is a short buzz of the light motor.
+var Hit = &Pattern{Name: "hit", Priority: 1, Points: []Point{
+	{At: 0, Low: 0.2, High: 0.8},
+	{At: 60, Low: 0.2, High: 0.8},
+	{At: 120, Low: 0, High: 0},
+}}
+
+// HeavyHit kicks both motors and fades out.
+var HeavyHit = &Pattern{Name: "heavy hit", Priority: 2, Points: []Point{
+	{At: 0, Low: 1, High: 0.6, Right: 0.5},
+	{At: 80, Low: 1, High: 0.4, Right: 0.3},
+	{At: 300, Low: 0, High: 0},
+}}
+
+// KO pulses three times and rumbles out over a second.
+var KO = &Pattern{Name: "ko", Priority: 3, Points: []Point{
+	{At: 0, Low: 1, High: 1, Left: 0.6, Right: 0.6},
+	{At: 120, Low: 1, High: 1, Left: 0.6, Right: 0.6},
+	{At: 160, Low: 0, High: 0},
+	{At: 260, Low: 0, High: 0},
+	{At: 300, Low: 0.8, High: 0.8},
+	{At: 400, Low: 0.8, High: 0.8},
+	{At: 440, Low: 0, High: 0},
+	{At: 540, Low: 0, High: 0},
+	{At: 580, Low: 1, High: 0.5},
+	{At: 1400, Low: 0, High: 0},
+}}
+
+// Patterns returns the built-in patterns by name.
+func Patterns() map[string]*Pattern {
+	return map[string]*Pattern{Hit.Name: Hit, HeavyHit.Name: HeavyHit, KO.Name: KO}
+}
@@ -0,0 +1,248 @@
+package rumble
+
+import (
+	"errors"
+	"testing"
+)
+
+// ramp crosses the two motors over 100 ms, with the trigger motors on
+// half.
+var ramp = &Pattern{Name: "ramp", Priority: 1, Points: []Point{
+	{At: 0, Low: 0, High: 1, Left: 0.5, Right: 0.5},
+	{At: 100, Low: 1, High: 0, Left: 0.5, Right: 0.5},
+}}
+
+func newService() *Service {
+	s := NewService()
+	s.Patterns[ramp.Name] = ramp
+	return s
+}
+
+func TestPatternAt(t *testing.T) {
+	tests := []struct {
+		t         uint32
+		low, high float64
+		ok        bool
+	}{
+		{0, 0.2, 0.8, true},
+		{30, 0.2, 0.8, true},
+		{60, 0.2, 0.8, true},
+		{90, 0.1, 0.4, true},
+		{120, 0, 0, true},
+		{121, 0, 0, false},
+	}
+	for _, tt := range tests {
+		pt, ok := Hit.At(tt.t)
+		if ok != tt.ok || pt.Low != tt.low || pt.High != tt.high || pt.At != tt.t {
+			t.Errorf("Hit.At(%d) = %+v, %v, want low %v high %v, %v", tt.t, pt, ok, tt.low, tt.high, tt.ok)
+		}
+	}
+	if _, ok := (&Pattern{}).At(0); ok {
+		t.Error("an empty pattern plays")
+	}
+}
+
+func TestServicePlayback(t *testing.T) {
+	s := newService()
+	f := &Fake{}
+	s.SetDevice(0, f)
+	if err := s.Play(0, "ramp"); err != nil {
+		t.Fatal(err)
+	}
+	// The pattern starts on the first Update, whatever its time
+	start := uint32(1000)
+	ticks := []struct {
+		t         uint32
+		low, high uint16
+	}{
+		{0, 0, 0xffff},
+		{25, 16383, 49151},
+		{50, 32767, 32767},
+		{75, 49151, 16383},
+		{100, 0xffff, 0},
+	}
+	for _, tick := range ticks {
+		s.Update(start + tick.t)
+		if f.Low != tick.low || f.High != tick.high {
+			t.Errorf("%d ms: motors %d, %d, want %d, %d", tick.t, f.Low, f.High, tick.low, tick.high)
+		}
+		if f.Left != 32767 || f.Right != 32767 {
+			t.Errorf("%d ms: trigger motors %d, %d, want half", tick.t, f.Left, f.Right)
+		}
+		last := f.Calls[len(f.Calls)-1]
+		if last.DurationMS != 2*Refresh {
+			t.Errorf("%d ms: duration %d, want %d", tick.t, last.DurationMS, 2*Refresh)
+		}
+	}
+	if got := s.Playing(0); got != "ramp" {
+		t.Errorf("Playing = %q, want ramp", got)
+	}
+
+	// Past the end the motors are stopped once
+	calls := len(f.Calls)
+	s.Update(start + 125)
+	s.Update(start + 150)
+	if f.Low != 0 || f.High != 0 || f.Left != 0 || f.Right != 0 {
+		t.Errorf("after the end: %+v", f)
+	}
+	if got := len(f.Calls) - calls; got != 2 {
+		t.Errorf("%d calls to stop, want 1 Rumble and 1 RumbleTriggers", got)
+	}
+	if got := s.Playing(0); got != "" {
+		t.Errorf("Playing = %q after the end", got)
+	}
+}
+
+func TestServiceRefresh(t *testing.T) {
+	s := NewService()
+	f := &Fake{}
+	s.SetDevice(0, f)
+	s.Play(0, "hit") // flat from 0 to 60 ms
+	for now := uint32(0); now <= 60; now += 10 {
+		s.Update(now)
+	}
+	// Unchanged motors are only asked again every Refresh ms
+	n := 0
+	for _, c := range f.Calls {
+		if !c.Triggers {
+			n++
+		}
+	}
+	if n != 2 {
+		t.Errorf("%d calls while flat, want 2: %+v", n, f.Calls)
+	}
+}
+
+func TestServiceScaling(t *testing.T) {
+	s := newService()
+	full, half, off := &Fake{}, &Fake{}, &Fake{}
+	s.SetDevice(1, full)
+	s.SetDevice(2, half)
+	s.SetDevice(3, off)
+	s.SetIntensity(2, 0.5)
+	s.SetIntensity(3, 0)
+	s.SetIntensity(4, 7)
+	if got := s.Intensity(4); got != 1 {
+		t.Errorf("intensity 7 is kept as %v, want 1", got)
+	}
+	for player := 1; player <= 3; player++ {
+		s.Play(player, "ramp")
+	}
+	s.Update(0)
+	s.Update(50)
+	tests := []struct {
+		player    int
+		f         *Fake
+		low, high uint16
+		level     float64
+	}{
+		{1, full, 32767, 32767, 0.5},
+		{2, half, 16383, 16383, 0.25},
+		{3, off, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if tt.f.Low != tt.low || tt.f.High != tt.high {
+			t.Errorf("player %d: motors %d, %d, want %d, %d", tt.player, tt.f.Low, tt.f.High, tt.low, tt.high)
+		}
+		if got := s.Level(tt.player); got.Low != tt.level || got.High != tt.level {
+			t.Errorf("player %d: level %+v, want %v", tt.player, got, tt.level)
+		}
+	}
+}
+
+func TestServicePriority(t *testing.T) {
+	s := NewService()
+	f := &Fake{}
+	s.SetDevice(0, f)
+	s.Play(0, "ko")
+	s.Update(0)
+	s.Play(0, "hit")
+	s.Update(10)
+	if got := s.Playing(0); got != "ko" {
+		t.Errorf("hit interrupted ko, playing %q", got)
+	}
+	s.Stop(0)
+	s.Play(0, "hit")
+	s.Update(20)
+	if got := s.Playing(0); got != "hit" {
+		t.Errorf("playing %q after Stop, want hit", got)
+	}
+	if err := s.Play(0, "nope"); err == nil {
+		t.Error("unknown pattern played")
+	}
+}
+
+func TestServiceWithoutRumble(t *testing.T) {
+	s := newService()
+
+	// A pad whose motors fail is asked once and then left alone
+	f := &Fake{Err: errors.New("no motors")}
+	s.SetDevice(0, f)
+	if !s.Supported(0) {
+		t.Error("untried device not supported")
+	}
+	s.Play(0, "ramp")
+	s.Update(0)
+	if s.Supported(0) {
+		t.Error("failing device still supported")
+	}
+	f.Err = nil
+	s.Update(50)
+	if len(f.Calls) != 0 {
+		t.Errorf("failing device asked again: %+v", f.Calls)
+	}
+	// The game still sees what would be played
+	if got := s.Level(0); got.Low != 0.5 || got.High != 0.5 {
+		t.Errorf("level %+v without rumble, want 0.5", got)
+	}
+
+	// No device at all
+	s.SetDevice(1, nil)
+	s.Play(1, "ramp")
+	s.Update(100)
+	s.Update(150)
+	if s.Supported(1) || s.Playing(1) != "ramp" {
+		t.Errorf("nil device: supported %v, playing %q", s.Supported(1), s.Playing(1))
+	}
+
+	// Trigger motors that fail are dropped, the handle motors go on
+	g := &Fake{NoTriggers: true}
+	s.SetDevice(2, g)
+	s.Play(2, "ramp")
+	s.Update(200)
+	s.Update(250)
+	for _, c := range g.Calls {
+		if c.Triggers {
+			t.Errorf("trigger call recorded: %+v", c)
+		}
+	}
+	if len(g.Calls) != 2 || g.Low != 32767 {
+		t.Errorf("handle motors: %+v", g.Calls)
+	}
+}
+
+// closer is a fake device that must be closed when replaced.
+type closer struct {
+	Fake
+	closed bool
+}
+
+func (c *closer) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestServiceSetDevice(t *testing.T) {
+	s := newService()
+	old := &closer{}
+	s.SetDevice(0, old)
+	s.Play(0, "ramp")
+	s.Update(0)
+	s.SetDevice(0, &Fake{})
+	if !old.closed {
+		t.Error("replaced device not closed")
+	}
+	if last := old.Calls[len(old.Calls)-1]; last.Low != 0 || last.High != 0 || last.DurationMS != 0 {
+		t.Errorf("replaced device not stopped: %+v", last)
+	}
+}
@@ -0,0 +1,197 @@
+package rumble
+
+import (
+	"fmt"
+	"io"
+)
+
+// Refresh is how often the motors are updated while a pattern plays, in
+// milliseconds. Each request lasts twice as long, so the motors stop by
+// themselves when the game stops calling Update.
+const Refresh = 50
+
+// channel is the rumble state of one player.
+type channel struct {
+	device    Device
+	intensity float64
+	pattern   *Pattern
+	start     uint32
+	pending   bool // started on the next Update
+	silent    bool // no device, or it failed and is not asked again
+	triggers  bool // the trigger motors work
+	sent      Point
+	sentAt    uint32
+	playing   bool // the motors may be running
+}
+
+// Service plays patterns for each player.
+type Service struct {
+	Patterns map[string]*Pattern
+
+	players map[int]*channel
+	now     uint32
+}
+
+// NewService returns a service with the built-in patterns.
+func NewService() *Service {
+	return &Service{Patterns: Patterns(), players: make(map[int]*channel)}
+}
+
+func (s *Service) channel(player int) *channel {
+	c, ok := s.players[player]
+	if !ok {
+		c = &channel{intensity: 1, silent: true}
+		s.players[player] = c
+	}
+	return c
+}
+
+// SetDevice sets the device of a player, nil when it has none. The previous
+// device is stopped, and closed if it is an io.Closer.
+func (s *Service) SetDevice(player int, d Device) {
+	c := s.channel(player)
+	if c.device == d {
+		return
+	}
+	if c.device != nil {
+		s.off(c)
+		if closer, ok := c.device.(io.Closer); ok {
+			closer.Close()
+		}
+	}
+	c.device = d
+	c.silent = d == nil
+	_, c.triggers = d.(TriggerDevice)
+}
+
+// Supported reports whether the device of a player rumbles, as far as is
+// known: a device is only found silent once it fails.
+func (s *Service) Supported(player int) bool {
+	c, ok := s.players[player]
+	return ok && !c.silent
+}
+
+// SetIntensity scales the patterns of a player, 0 turns rumble off.
+func (s *Service) SetIntensity(player int, scale float64) {
+	if scale < 0 {
+		scale = 0
+	} else if scale > 1 {
+		scale = 1
+	}
+	s.channel(player).intensity = scale
+}
+
+// Intensity returns the scale of a player.
+func (s *Service) Intensity(player int) float64 {
+	return s.channel(player).intensity
+}
+
+// Play starts the named pattern for a player on the next Update. A pattern
+// with a lower priority than the one playing is dropped.
+func (s *Service) Play(player int, name string) error {
+	p, ok := s.Patterns[name]
+	if !ok {
+		return fmt.Errorf("unknown rumble pattern %q", name)
+	}
+	c := s.channel(player)
+	if c.pattern != nil && c.pattern.Priority > p.Priority {
+		return nil
+	}
+	c.pattern = p
+	c.pending = true
+	return nil
+}
+
+// Playing returns the name of the pattern a player feels, "" for none.
+func (s *Service) Playing(player int) string {
+	if c, ok := s.players[player]; ok && c.pattern != nil {
+		return c.pattern.Name
+	}
+	return ""
+}
+
+// Level returns the motor intensities last sent to a player, after scaling.
+func (s *Service) Level(player int) Point {
+	if c, ok := s.players[player]; ok {
+		return c.sent
+	}
+	return Point{}
+}
+
+// Stop stops the pattern of a player.
+func (s *Service) Stop(player int) {
+	if c, ok := s.players[player]; ok {
+		c.pattern = nil
+		s.off(c)
+	}
+}
+
+// StopAll stops every player, e.g. when the game pauses.
+func (s *Service) StopAll() {
+	for player := range s.players {
+		s.Stop(player)
+	}
+}
+
+// Update drives the motors; call it once per frame with the time in
+// milliseconds, e.g. sdl.GetTicks().
+func (s *Service) Update(now uint32) {
+	s.now = now
+	for _, c := range s.players {
+		if c.pattern == nil {
+			continue
+		}
+		if c.pending {
+			c.start, c.pending = now, false
+		}
+		pt, ok := c.pattern.At(now - c.start)
+		if !ok {
+			c.pattern = nil
+			s.off(c)
+			continue
+		}
+		pt.Low *= c.intensity
+		pt.High *= c.intensity
+		pt.Left *= c.intensity
+		pt.Right *= c.intensity
+		if c.playing && pt.Low == c.sent.Low && pt.High == c.sent.High &&
+			pt.Left == c.sent.Left && pt.Right == c.sent.Right && now-c.sentAt < Refresh {
+			continue
+		}
+		s.send(c, pt, 2*Refresh)
+	}
+}
+
+func (s *Service) off(c *channel) {
+	if c.playing {
+		s.send(c, Point{}, 0)
+	}
+	c.sent = Point{}
+	c.playing = false
+}
+
+func (s *Service) send(c *channel, pt Point, durationMS uint32) {
+	c.sent, c.sentAt = pt, s.now
+	if c.silent || c.device == nil {
+		return
+	}
+	if err := c.device.Rumble(motor(pt.Low), motor(pt.High), durationMS); err != nil {
+		c.silent = true
+		return
+	}
+	c.playing = true
+	if c.triggers && c.device.(TriggerDevice).RumbleTriggers(motor(pt.Left), motor(pt.Right), durationMS) != nil {
+		c.triggers = false
+	}
+}
+
+// motor converts an intensity to the range of SDL.
+func motor(v float64) uint16 {
+	if v <= 0 {
+		return 0
+	}
+	if v >= 1 {
+		return 0xffff
+	}
+	return uint16(v * 0xffff)
+}
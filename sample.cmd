@@ -0,0 +1,86 @@
+; Commands for test_mugen_command, in the format of MUGEN .cmd files
+
+[Remap]
+x = x
+y = y
+z = z
+a = a
+b = b
+c = c
+s = s
+
+[Defaults]
+command.time = 15
+command.buffer.time = 1
+
+;-| Super Motions |--------------------------------------------------------
+[Command]
+name = "Super"
+command = ~D, DF, F, D, DF, F, x+y
+time = 30
+
+;-| Special Motions |------------------------------------------------------
+[Command]
+name = "Hadouken"
+command = ~D, DF, F, x
+
+[Command]
+name = "Tatsumaki"
+command = ~D, DB, B, a
+
+[Command]
+name = "Shoryuken"
+command = ~F, D, DF, x
+
+[Command]
+name = "Sonic Boom"
+command = ~30$B, F, x
+time = 10
+
+[Command]
+name = "Flash Kick"
+command = ~30$D, U, a
+time = 10
+
+;-| Dir + Button |---------------------------------------------------------
+[Command]
+name = "Throw"
+command = /F, x+a
+time = 1
+
+;-| Double Tap |-----------------------------------------------------------
+[Command]
+name = "Dash"
+command = F, F
+time = 10
+
+[Command]
+name = "Back Dash"
+command = B, B
+time = 10
+
+;-| Single Button |--------------------------------------------------------
+[Command]
+name = "Taunt"
+command = s
+time = 1
+
+;-| Hold Dir |-------------------------------------------------------------
+[Command]
+name = "holdfwd"
+command = /$F
+time = 1
+
+[Command]
+name = "holdback"
+command = /$B
+time = 1
+
+;---------------------------------------------------------------------------
+[Statedef -1]
+
+[State -1, Hadouken]
+type = ChangeState
+value = 1000
+triggerall = command = "Hadouken"
+trigger1 = statetype = S
@@ -0,0 +1,175 @@
+// Package snd reads the sound banks of MUGEN characters and stages (.snd
+// files) into SDL_mixer chunks. Reading the file needs no SDL and can run
+// on any goroutine; the chunks are created on the main thread.
+package snd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+
+	"github.com/veandco/go-sdl2/mix"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/leak"
+	"go-sdl2/mainthread"
+)
+
+// ErrHeader is returned for files that are not SND files.
+var ErrHeader = errors.New("unrecognized SND file, invalid header")
+
+// Data is an SND file read into memory: the WAV file of every sound by
+// group and number.
+type Data struct {
+	Ver, Ver2 uint16
+	Waves     map[[2]int32][]byte
+}
+
+// Read reads the sounds for which keep returns true. If max > 0 it returns
+// as soon as a matching sound is found, and gives up after max
+// non-matching ones.
+func Read(r io.ReadSeeker, keep func([2]int32) bool, max uint32) (*Data, error) {
+	d := &Data{Waves: make(map[[2]int32][]byte)}
+	buf := make([]byte, 12)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	if string(buf) != "ElecbyteSnd\x00" {
+		return nil, ErrHeader
+	}
+	read := func(x interface{}) error {
+		return binary.Read(r, binary.LittleEndian, x)
+	}
+	var numberOfSounds, subHeaderOffset uint32
+	for _, x := range []interface{}{&d.Ver, &d.Ver2, &numberOfSounds, &subHeaderOffset} {
+		if err := read(x); err != nil {
+			return nil, err
+		}
+	}
+	loops := numberOfSounds
+	if max > 0 && max < numberOfSounds {
+		loops = max
+	}
+	for i := uint32(0); i < loops; i++ {
+		if _, err := r.Seek(int64(subHeaderOffset), io.SeekStart); err != nil {
+			return nil, err
+		}
+		var nextSubHeaderOffset, subFileLength uint32
+		var num [2]int32
+		for _, x := range []interface{}{&nextSubHeaderOffset, &subFileLength, &num} {
+			if err := read(x); err != nil {
+				return nil, err
+			}
+		}
+		if _, ok := d.Waves[num]; !ok && keep(num) {
+			if subFileLength < 128 {
+				err := fmt.Errorf("sound %v,%v: wav size is too small", num[0], num[1])
+				if max > 0 {
+					return nil, err
+				}
+				log.Print(err)
+			} else {
+				wav := make([]byte, subFileLength)
+				if _, err := io.ReadFull(r, wav); err != nil {
+					return nil, err
+				}
+				d.Waves[num] = wav
+				if max > 0 {
+					break
+				}
+			}
+		}
+		subHeaderOffset = nextSubHeaderOffset
+	}
+	return d, nil
+}
+
+// ReadFile reads every sound of an SND file with a group and number of 0
+// or more.
+func ReadFile(filename string) (*Data, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f, func(gn [2]int32) bool { return gn[0] >= 0 && gn[1] >= 0 }, 0)
+}
+
+// Bank is an SND file with its sounds as mixer chunks.
+type Bank struct {
+	Ver, Ver2 uint16
+	Chunks    map[[2]int32]*mix.Chunk
+}
+
+// Bank creates the chunks, each in its own call on the main thread so that
+// a large bank doesn't hold up a frame. The audio device must be open.
+// Sounds SDL_mixer can't decode are logged and left out.
+func (d *Data) Bank() *Bank {
+	b := &Bank{Ver: d.Ver, Ver2: d.Ver2, Chunks: make(map[[2]int32]*mix.Chunk)}
+	for _, key := range keys(d.Waves) {
+		wav := d.Waves[key]
+		var chunk *mix.Chunk
+		err := mainthread.CallErr(func() error {
+			rw, err := sdl.RWFromMem(wav)
+			if err != nil {
+				return err
+			}
+			// The chunk is converted to the output format, wav isn't kept
+			chunk, err = leak.Chunk(mix.LoadWAVRW(rw, true))
+			return err
+		})
+		if err != nil {
+			log.Printf("sound %v,%v can't be played: %v", key[0], key[1], err)
+			continue
+		}
+		b.Chunks[key] = chunk
+	}
+	return b
+}
+
+// Load reads an SND file and creates its chunks.
+func Load(filename string) (*Bank, error) {
+	d, err := ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return d.Bank(), nil
+}
+
+// Get returns the chunk of a sound, nil if the bank doesn't have it.
+func (b *Bank) Get(group, number int32) *mix.Chunk {
+	return b.Chunks[[2]int32{group, number}]
+}
+
+// Keys returns the group and number of every sound, in order.
+func (b *Bank) Keys() [][2]int32 {
+	return keys(b.Chunks)
+}
+
+// Free frees the chunks on the main thread.
+func (b *Bank) Free() {
+	mainthread.Call(func() {
+		for key, chunk := range b.Chunks {
+			leak.FreeChunk(chunk)
+			delete(b.Chunks, key)
+		}
+	})
+}
+
+func keys[T any](m map[[2]int32]T) [][2]int32 {
+	list := make([][2]int32, 0, len(m))
+	for key := range m {
+		list = append(list, key)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i][0] != list[j][0] {
+			return list[i][0] < list[j][0]
+		}
+		return list[i][1] < list[j][1]
+	})
+	return list
+}
@@ -0,0 +1,265 @@
+// Event bus
+// The main loop has no type switch: a menu, a debug overlay and the game
+// each register their own handlers. ESC opens the menu, which swallows the
+// keys and buttons the game would see; arrows, the d-pad or the first hat
+// move the square; F1 shows the event counters; a goroutine pushes a user
+// event every second.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/bus"
+	"go-sdl2/input"
+)
+
+var winTitle string = "Go-SDL2 Event Bus"
+var winWidth, winHeight int32 = 640, 480
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var err error
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	registry := input.NewRegistry(input.NewProfiles())
+	defer registry.Close()
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN|sdl.WINDOW_RESIZABLE)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	sdl.JoystickEventState(sdl.ENABLE)
+
+	events := bus.New()
+	defer events.Close()
+	running := true
+
+	// Seen by the watch as they are queued, even when consumed
+	var watched int64
+	events.Watch(func(sdl.Event) { atomic.AddInt64(&watched, 1) })
+
+	// A user event from another goroutine
+	tick, err := events.Register("tick")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to register event: %s\n", err)
+		return 3
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case t := <-ticker.C:
+				events.Push(tick, t)
+			case <-stop:
+				return
+			}
+		}
+	}()
+	var lastTick time.Time
+	events.OnUser(bus.Default, tick, func(e *sdl.UserEvent, payload interface{}) bool {
+		lastTick = payload.(time.Time)
+		return true
+	})
+
+	events.OnQuit(bus.Default, func(*sdl.QuitEvent) bool {
+		running = false
+		return true
+	})
+	events.OnJoyDevice(bus.Overlay, func(e sdl.Event) bool {
+		registry.HandleEvent(e)
+		return false
+	})
+
+	// Overlay: counts everything, consumes only F1
+	showCounts := false
+	counts := make(map[string]int)
+	total := 0
+	events.On(bus.Overlay, func(e sdl.Event) bool {
+		total++
+		counts[fmt.Sprintf("%T", e)[5:]]++
+		return false
+	})
+	events.OnKey(bus.Overlay, func(e *sdl.KeyboardEvent) bool {
+		if e.Keysym.Sym != sdl.K_F1 {
+			return false
+		}
+		if e.State == sdl.PRESSED && e.Repeat == 0 {
+			showCounts = !showCounts
+		}
+		return true
+	})
+
+	// Menu: while open it swallows every key and button
+	menu := false
+	items := []string{"Resume", "Quit"}
+	selected := 0
+	choose := func() {
+		if items[selected] == "Quit" {
+			running = false
+		}
+		menu = false
+	}
+	move := func(hat uint8) {
+		if hat&sdl.HAT_UP != 0 && selected > 0 {
+			selected--
+		} else if hat&sdl.HAT_DOWN != 0 && selected < len(items)-1 {
+			selected++
+		}
+	}
+	events.OnKey(bus.UI, func(e *sdl.KeyboardEvent) bool {
+		if e.State == sdl.PRESSED && e.Keysym.Sym == sdl.K_ESCAPE {
+			menu, selected = !menu, 0
+			return true
+		}
+		if !menu || e.State != sdl.PRESSED {
+			return menu
+		}
+		switch e.Keysym.Sym {
+		case sdl.K_UP:
+			move(sdl.HAT_UP)
+		case sdl.K_DOWN:
+			move(sdl.HAT_DOWN)
+		case sdl.K_RETURN:
+			choose()
+		}
+		return true
+	})
+	events.OnJoyButton(bus.UI, func(e *sdl.JoyButtonEvent) bool {
+		d := registry.Device(e.Which)
+		if d == nil || e.State != sdl.PRESSED {
+			return menu
+		}
+		switch int(e.Button) {
+		case d.Profile.Button("START"):
+			menu, selected = !menu, 0
+			return true
+		case d.Profile.Button("A"):
+			if menu {
+				choose()
+			}
+		}
+		return menu
+	})
+	events.OnJoyHat(bus.UI, func(e *sdl.JoyHatEvent) bool {
+		if menu {
+			move(e.Value)
+		}
+		return menu
+	})
+
+	// Game: a square moved by the arrows or the hat
+	var x, y int32 = winWidth / 2, winHeight / 2
+	var keys, hat uint8
+	events.OnKey(bus.Gameplay, func(e *sdl.KeyboardEvent) bool {
+		dirs := map[sdl.Keycode]uint8{sdl.K_UP: sdl.HAT_UP, sdl.K_DOWN: sdl.HAT_DOWN, sdl.K_LEFT: sdl.HAT_LEFT, sdl.K_RIGHT: sdl.HAT_RIGHT}
+		dir, ok := dirs[e.Keysym.Sym]
+		if !ok {
+			return false
+		}
+		if e.State == sdl.PRESSED {
+			keys |= dir
+		} else {
+			keys &^= dir
+		}
+		return true
+	})
+	events.OnJoyHat(bus.Gameplay, func(e *sdl.JoyHatEvent) bool {
+		hat = e.Value
+		return true
+	})
+	events.OnWindow(bus.Gameplay, func(e *sdl.WindowEvent) bool {
+		if e.Event == sdl.WINDOWEVENT_FOCUS_LOST {
+			keys, hat = 0, 0
+		}
+		return false
+	})
+
+	for running {
+		events.Poll()
+		if menu {
+			keys, hat = 0, 0
+		}
+		dir := keys | hat
+		if dir&sdl.HAT_LEFT != 0 && x > 0 {
+			x -= 4
+		}
+		if dir&sdl.HAT_RIGHT != 0 && x < winWidth-32 {
+			x += 4
+		}
+		if dir&sdl.HAT_UP != 0 && y > 0 {
+			y -= 4
+		}
+		if dir&sdl.HAT_DOWN != 0 && y < winHeight-32 {
+			y += 4
+		}
+
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+		renderer.SetDrawColor(0, 255, 0, 255)
+		renderer.FillRect(&sdl.Rect{x, y, 32, 32})
+		gfx.StringRGBA(renderer, 10, 10, "ESC/START: menu  arrows/hat: move  F1: counters", 255, 255, 255, 255)
+		if !lastTick.IsZero() {
+			gfx.StringRGBA(renderer, 10, 26, "Tick from goroutine: "+lastTick.Format("15:04:05"), 255, 255, 255, 255)
+		}
+		if showCounts {
+			gfx.StringRGBA(renderer, 10, 50, fmt.Sprintf("Dispatched %d, watched %d", total, atomic.LoadInt64(&watched)), 255, 255, 0, 255)
+			names := make([]string, 0, len(counts))
+			for name := range counts {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for i, name := range names {
+				gfx.StringRGBA(renderer, 10, 66+int32(i)*16, fmt.Sprintf("%-22s %d", name, counts[name]), 255, 255, 0, 255)
+			}
+		}
+		if menu {
+			renderer.SetDrawColor(40, 40, 40, 255)
+			renderer.FillRect(&sdl.Rect{220, 180, 200, 100})
+			renderer.SetDrawColor(255, 255, 255, 255)
+			renderer.DrawRect(&sdl.Rect{220, 180, 200, 100})
+			for i, item := range items {
+				prefix := "  "
+				if i == selected {
+					prefix = "> "
+				}
+				gfx.StringRGBA(renderer, 250, 210+int32(i)*20, prefix+item, 255, 255, 255, 255)
+			}
+		}
+		renderer.Present()
+
+		sdl.Delay(16)
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
@@ -1,16 +1,27 @@
 // author: Jacky Boen
+// Logs every SDL event, as text or as JSON Lines.
+// Usage: ./test_event [-json] [filter]
+// filter is a comma separated list of event types or categories (window,
+// display, keyboard, text, mouse, joystick, controller, audio, touch, drop,
+// sensor, render, clipboard, app, user); a leading minus excludes, e.g.
+// ./test_event -json joystick,controller or ./test_event -mouse
+// U pushes a user event.
 
 package main
 
 import (
 	"fmt"
 	"github.com/veandco/go-sdl2/sdl"
+	"go-sdl2/eventlog"
+	"go-sdl2/input"
+	"go-sdl2/motion"
 	"os"
 )
 
 var winTitle string = "Go-SDL2 Events"
 var winWidth, winHeight int32 = 320, 200
-var joysticks [16]*sdl.Joystick
+var registry = input.NewRegistry(nil)
+var controllers = make(map[sdl.JoystickID]*sdl.GameController)
 
 func run() int {
 	var window *sdl.Window
@@ -18,8 +29,25 @@ func run() int {
 	var running bool
 	var err error
 
+	logger := eventlog.New(os.Stdout)
+	for _, arg := range os.Args[1:] {
+		if arg == "-json" {
+			logger.Format = eventlog.JSON
+		} else {
+			include, exclude := eventlog.ParseFilter(arg)
+			logger.Include = append(logger.Include, include...)
+			logger.Exclude = append(logger.Exclude, exclude...)
+		}
+	}
+
 	sdl.Init(sdl.INIT_EVERYTHING)
 	defer sdl.Quit()
+	defer registry.Close()
+	defer func() {
+		for _, ctrl := range controllers {
+			ctrl.Close()
+		}
+	}()
 
 	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
 		winWidth, winHeight, sdl.WINDOW_SHOWN)
@@ -30,52 +58,48 @@ func run() int {
 	defer window.Destroy()
 
 	sdl.JoystickEventState(sdl.ENABLE)
+	sdl.GameControllerEventState(sdl.ENABLE)
+	sdl.EventState(sdl.DROPFILE, sdl.ENABLE)
+	sdl.EventState(sdl.DROPTEXT, sdl.ENABLE)
+	userEvent := sdl.RegisterEvents(1)
 
 	running = true
 	for running {
 		for event = sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			if err := logger.Log(event); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to log event: %s\n", err)
+				return 2
+			}
+			device, _ := registry.HandleEvent(event)
 			switch t := event.(type) {
 			case *sdl.QuitEvent:
 				running = false
-			case *sdl.MouseMotionEvent:
-				fmt.Printf("[%d ms] MouseMotion type:%d id:%d x:%d y:%d xrel:%d yrel:%d\n",
-					t.Timestamp, t.Type, t.Which, t.X, t.Y, t.XRel, t.YRel)
-			case *sdl.MouseButtonEvent:
-				// t.State == sdl.PRESSED
-				fmt.Printf("[%d ms] MouseButton type:%d id:%d x:%d y:%d button:%d state:%d\n",
-					t.Timestamp, t.Type, t.Which, t.X, t.Y, t.Button, t.State)
-			case *sdl.MouseWheelEvent:
-				fmt.Printf("[%d ms] MouseWheel type:%d id:%d x:%d y:%d\n",
-					t.Timestamp, t.Type, t.Which, t.X, t.Y)
 			case *sdl.KeyboardEvent:
-				fmt.Printf("[%d ms] Keyboard type:%d sym:%d scancode:%d modifiers:%d state:%d repeat:%d\n",
-					t.Timestamp, t.Type, t.Keysym.Sym, t.Keysym.Scancode, t.Keysym.Mod, t.State, t.Repeat)
-			case *sdl.JoyAxisEvent:
-				fmt.Printf("[%d ms] JoyAxis type:%d which:%c axis:%d value:%d\n",
-					t.Timestamp, t.Type, t.Which, t.Axis, t.Value)
-			case *sdl.JoyBallEvent:
-				fmt.Printf("[%d ms] JoyBall type:%d which:%d ball:%d xrel:%d yrel:%d\n",
-					t.Timestamp, t.Type, t.Which, t.Ball, t.XRel, t.YRel)
-			case *sdl.JoyButtonEvent:
-				fmt.Printf("[%d ms] JoyButton type:%d which:%d button:%d state:%d\n",
-					t.Timestamp, t.Type, t.Which, t.Button, t.State)
-			case *sdl.JoyHatEvent:
-				// t.Value = sdl.HAT_RIGHT sdl.HAT_UP sdl.HAT_DOWN sdl.HAT_LEFT sdl.HAT_CENTERED sdl.HAT_LEFTUP sdl.HAT_RIGHTUP sdl.HAT_RIGHTDOWN sdl.HAT_LEFTDOWN
-				fmt.Printf("[%d ms] JoyHat type:%d which:%d hat:%d value:%d\n",
-					t.Timestamp, t.Type, t.Which, t.Hat, t.Value)
+				if t.State == sdl.PRESSED && t.Repeat == 0 && t.Keysym.Sym == sdl.K_u && userEvent != 0xffffffff {
+					input.Push(&sdl.UserEvent{Type: userEvent, Timestamp: sdl.GetTicks(), Code: int32(logger.Count)})
+				}
 			case *sdl.JoyDeviceAddedEvent:
-				// Open joystick for use
-				joysticks[int(t.Which)] = sdl.JoystickOpen(int(t.Which))
-				if joysticks[int(t.Which)] != nil {
-					fmt.Printf("Joystick %v connected (%v)\n", t.Which, joysticks[int(t.Which)].Name())
+				// Which is a device index here, the registry opens it by instance ID.
+				// Device names go to stderr so that stdout stays JSON Lines.
+				if device != nil {
+					fmt.Fprintf(os.Stderr, "Joystick %v connected (%v)\n", device.ID, device.Name)
+				}
+				// Controller and sensor events only come from opened game controllers
+				if device != nil && controllers[device.ID] == nil && sdl.IsGameController(int(t.Which)) {
+					if ctrl := sdl.GameControllerOpen(int(t.Which)); ctrl != nil {
+						for _, typ := range []sdl.SensorType{sdl.SENSOR_GYRO, sdl.SENSOR_ACCEL} {
+							if motion.HasSensor(ctrl, typ) {
+								motion.SetSensorEnabled(ctrl, typ, true)
+							}
+						}
+						controllers[device.ID] = ctrl
+					}
 				}
 			case *sdl.JoyDeviceRemovedEvent:
-				if joystick := joysticks[int(t.Which)]; joystick != nil {
-					joystick.Close()
+				if ctrl := controllers[t.Which]; ctrl != nil {
+					ctrl.Close()
+					delete(controllers, t.Which)
 				}
-				fmt.Println("Joystick", t.Which, "disconnected")
-			default:
-				fmt.Printf("Some event\n")
 			}
 		}
 
@@ -0,0 +1,342 @@
+// Gamepad tester
+// Shows every control of a joystick: both sticks with their deadzones and
+// a trail, the triggers, all axes, buttons, hats and balls. With several
+// joysticks connected each has a tab, Tab or 1-9 or a click switches.
+// E exports the description of the device to device-<guid>.json, ESC or
+// SELECT+START exits.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+)
+
+var winTitle string = "Go-SDL2 Gamepad Tester"
+var winWidth, winHeight int32 = 640, 480
+
+const trailLength = 40
+
+// pad is what the tester keeps about one device.
+type pad struct {
+	device   *input.Device
+	sticks   []*input.Stick
+	trails   [][]sdl.Point
+	triggers []*input.Trigger
+	balls    []sdl.Point // summed ball motion
+}
+
+func newPad(d *input.Device) *pad {
+	p := &pad{device: d}
+	axis := func(name string, fallback int) int {
+		if i := d.Profile.Axis(name); i >= 0 {
+			return i
+		}
+		if fallback < d.NumAxes() {
+			return fallback
+		}
+		return -1
+	}
+	for _, names := range [][2]string{{"LX", "LY"}, {"RX", "RY"}} {
+		fallback := 2 * len(p.sticks)
+		x, y := axis(names[0], fallback), axis(names[1], fallback+1)
+		if x >= 0 && y >= 0 {
+			p.sticks = append(p.sticks, input.NewStick(x, y))
+			p.trails = append(p.trails, nil)
+		}
+	}
+	for _, name := range []string{"L2", "R2"} {
+		if i := d.Profile.Axis(name); i >= 0 {
+			p.triggers = append(p.triggers, input.NewTrigger(i))
+		}
+	}
+	if d.Joystick != nil {
+		p.balls = make([]sdl.Point, d.Joystick.NumBalls())
+	}
+	return p
+}
+
+// drawStick draws the plot of stick i at x, y: the outer and inner
+// deadzone, the trail and the raw (gray) and shaped (green) positions.
+func (p *pad) drawStick(renderer *sdl.Renderer, cal *input.Calibration, i int, x, y int32) {
+	const size = 100
+	s := p.sticks[i]
+	cx, cy := x+size/2, y+size/2
+	renderer.SetDrawColor(200, 200, 200, 255)
+	renderer.DrawRect(&sdl.Rect{x, y, size, size})
+	gfx.CircleRGBA(renderer, cx, cy, int32(s.Deadzone.Outer*size/2), 100, 100, 100, 255)
+	gfx.CircleRGBA(renderer, cx, cy, int32(s.Deadzone.Inner*size/2), 255, 0, 0, 255)
+
+	rawX, rawY := p.device.Axis(s.X), p.device.Axis(s.Y)
+	nx, ny := cal.Normalize(s.X, rawX), cal.Normalize(s.Y, rawY)
+	sx, sy, _ := s.Update(cal, rawX, rawY)
+	rx, ry := cx+int32(nx*size/2), cy+int32(ny*size/2)
+	p.trails[i] = append(p.trails[i], sdl.Point{rx, ry})
+	if len(p.trails[i]) > trailLength {
+		p.trails[i] = p.trails[i][1:]
+	}
+	renderer.SetDrawColor(0, 120, 255, 255)
+	renderer.DrawLines(p.trails[i])
+	gfx.FilledCircleRGBA(renderer, rx, ry, 3, 160, 160, 160, 255)
+	gfx.FilledCircleRGBA(renderer, cx+int32(sx*size/2), cy+int32(sy*size/2), 4, 0, 255, 0, 255)
+
+	name := fmt.Sprintf("%s/%s", p.device.Profile.AxisName(s.X), p.device.Profile.AxisName(s.Y))
+	gfx.StringRGBA(renderer, x, y+size+4, name, 255, 255, 255, 255)
+	gfx.StringRGBA(renderer, x, y+size+20, fmt.Sprintf("%+.2f %+.2f", sx, sy), 255, 255, 255, 255)
+}
+
+// drawTrigger draws trigger i as a bar filling upwards, red when it counts
+// as pressed.
+func (p *pad) drawTrigger(renderer *sdl.Renderer, cal *input.Calibration, i int, x, y int32) {
+	const height = 100
+	t := p.triggers[i]
+	value, pressed := t.Update(cal, p.device.Axis(t.Axis))
+	renderer.SetDrawColor(200, 200, 200, 255)
+	renderer.DrawRect(&sdl.Rect{x, y, 20, height})
+	if pressed {
+		renderer.SetDrawColor(255, 0, 0, 255)
+	} else {
+		renderer.SetDrawColor(0, 255, 0, 255)
+	}
+	h := int32(value * (height - 2))
+	renderer.FillRect(&sdl.Rect{x + 1, y + height - 1 - h, 18, h})
+	gfx.StringRGBA(renderer, x+2, y+height+4, p.device.Profile.AxisName(t.Axis), 255, 255, 255, 255)
+}
+
+// drawAxes draws a bar from the middle for every axis, in two columns.
+func (p *pad) drawAxes(renderer *sdl.Renderer, x, y int32) {
+	d := p.device
+	for i := 0; i < d.NumAxes() && i < 16; i++ {
+		ax, ay := x+int32(i/8)*170, y+int32(i%8)*16
+		renderer.SetDrawColor(200, 200, 200, 255)
+		renderer.DrawRect(&sdl.Rect{ax + 32, ay, 100, 12})
+		w := int32(d.Axis(i)) * 49 / 32768
+		renderer.SetDrawColor(0, 255, 0, 255)
+		if w >= 0 {
+			renderer.FillRect(&sdl.Rect{ax + 82, ay + 1, w + 1, 10})
+		} else {
+			renderer.FillRect(&sdl.Rect{ax + 82 + w, ay + 1, -w, 10})
+		}
+		gfx.StringRGBA(renderer, ax, ay+2, d.Profile.AxisName(i), 255, 255, 255, 255)
+	}
+	if d.NumAxes() > 16 {
+		gfx.StringRGBA(renderer, x, y+8*16, fmt.Sprintf("+%d axes", d.NumAxes()-16), 255, 255, 255, 255)
+	}
+}
+
+// drawButtons draws a cell for every button, with its index and name.
+func (p *pad) drawButtons(renderer *sdl.Renderer, x, y int32) {
+	const perRow, rows = 12, 4
+	d := p.device
+	if d.NumButtons() > perRow*rows {
+		gfx.StringRGBA(renderer, x+530, y-12, fmt.Sprintf("+%d buttons", d.NumButtons()-perRow*rows), 255, 255, 255, 255)
+	}
+	for i := 0; i < d.NumButtons() && i < perRow*rows; i++ {
+		r := sdl.Rect{x + int32(i%perRow)*52, y + int32(i/perRow)*36, 48, 32}
+		if d.Button(i) {
+			renderer.SetDrawColor(0, 255, 0, 255)
+			renderer.FillRect(&r)
+		} else {
+			renderer.SetDrawColor(200, 200, 200, 255)
+			renderer.DrawRect(&r)
+		}
+		gfx.StringRGBA(renderer, r.X+4, r.Y+4, fmt.Sprint(i), 255, 255, 255, 255)
+		if name, ok := d.Profile.Buttons[i]; ok {
+			gfx.StringRGBA(renderer, r.X+4, r.Y+18, name, 255, 255, 255, 255)
+		}
+	}
+}
+
+// drawHats draws a cross for every hat with its pressed directions lit.
+func (p *pad) drawHats(renderer *sdl.Renderer, x, y int32) {
+	d := p.device
+	for i := 0; i < d.NumHats() && i < 8; i++ {
+		hx := x + int32(i)*60
+		hat := d.Hat(i)
+		for _, dir := range []struct {
+			mask uint8
+			rect sdl.Rect
+		}{
+			{sdl.HAT_UP, sdl.Rect{hx + 14, y, 12, 14}},
+			{sdl.HAT_DOWN, sdl.Rect{hx + 14, y + 26, 12, 14}},
+			{sdl.HAT_LEFT, sdl.Rect{hx, y + 14, 14, 12}},
+			{sdl.HAT_RIGHT, sdl.Rect{hx + 26, y + 14, 14, 12}},
+		} {
+			if hat&dir.mask != 0 {
+				renderer.SetDrawColor(0, 255, 0, 255)
+				renderer.FillRect(&dir.rect)
+			} else {
+				renderer.SetDrawColor(200, 200, 200, 255)
+				renderer.DrawRect(&dir.rect)
+			}
+		}
+		gfx.StringRGBA(renderer, hx, y+44, d.Profile.HatName(i), 255, 255, 255, 255)
+	}
+}
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var err error
+	var msg string
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	profiles := input.NewProfiles()
+	if err := profiles.LoadFile("profiles.json"); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Failed to load profiles: %s\n", err)
+	}
+	calibrations, err := input.LoadCalibrations("calibration.json")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load calibration: %s\n", err)
+	}
+	registry := input.NewRegistry(profiles)
+	defer registry.Close()
+	hotkeys := input.NewHotkeys(registry)
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	sdl.JoystickEventState(sdl.ENABLE)
+
+	pads := make(map[*input.Device]*pad)
+	tab := 0
+
+	running := true
+	for running {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			if d, change := registry.HandleEvent(event); change != input.NoChange {
+				switch change {
+				case input.Added, input.Reconnected:
+					msg = fmt.Sprintf("%s connected", d.Name)
+					pads[d] = newPad(d)
+				case input.Removed:
+					msg = fmt.Sprintf("%s disconnected", d.Name)
+					delete(pads, d)
+				}
+			}
+			for _, e := range hotkeys.HandleEvent(event) {
+				devices := registry.Devices()
+				switch t := e.(type) {
+				case *sdl.QuitEvent:
+					running = false
+				case *sdl.JoyBallEvent:
+					if p := pads[registry.Device(t.Which)]; p != nil && int(t.Ball) < len(p.balls) {
+						p.balls[t.Ball].X += int32(t.XRel)
+						p.balls[t.Ball].Y += int32(t.YRel)
+					}
+				case *sdl.MouseButtonEvent:
+					if t.State == sdl.PRESSED && t.Y < 22 && int(t.X/100) < len(devices) {
+						tab = int(t.X / 100)
+					}
+				case *sdl.KeyboardEvent:
+					if t.State != sdl.PRESSED {
+						break
+					}
+					switch sym := t.Keysym.Sym; {
+					case sym == sdl.K_TAB && len(devices) > 0:
+						tab = (tab + 1) % len(devices)
+					case sym >= sdl.K_1 && sym <= sdl.K_9 && int(sym-sdl.K_1) < len(devices):
+						tab = int(sym - sdl.K_1)
+					case sym == sdl.K_e && tab < len(devices):
+						d := devices[tab]
+						filename := fmt.Sprintf("device-%s.json", d.GUID)
+						if err := d.Describe().Save(filename); err != nil {
+							msg = fmt.Sprintf("Failed to export: %s", err)
+						} else {
+							msg = "Exported " + filename
+						}
+					}
+				}
+			}
+		}
+		for _, action := range hotkeys.Update(sdl.GetTicks()) {
+			if action == input.ActionQuit {
+				running = false
+			}
+		}
+
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+		renderer.SetDrawColor(255, 255, 255, 255)
+		renderer.DrawRect(&sdl.Rect{0, 0, 640, 480})
+
+		devices := registry.Devices()
+		if tab >= len(devices) {
+			tab = 0
+		}
+		for i, d := range devices {
+			r := sdl.Rect{int32(i) * 100, 0, 100, 22}
+			if i == tab {
+				renderer.SetDrawColor(0, 80, 160, 255)
+				renderer.FillRect(&r)
+			}
+			renderer.SetDrawColor(200, 200, 200, 255)
+			renderer.DrawRect(&r)
+			name := d.Name
+			if len(name) > 9 {
+				name = name[:9]
+			}
+			gfx.StringRGBA(renderer, r.X+4, r.Y+8, fmt.Sprintf("%d %s", i+1, name), 255, 255, 255, 255)
+		}
+
+		if len(devices) == 0 {
+			gfx.StringRGBA(renderer, 10, 40, "Connect a joystick", 255, 255, 255, 255)
+		} else {
+			d := devices[tab]
+			p := pads[d]
+			if p == nil {
+				p = newPad(d)
+				pads[d] = p
+			}
+			cal := calibrations[d.GUID]
+			gfx.StringRGBA(renderer, 10, 30, fmt.Sprintf("%s  profile:%s  power:%s", d.Name, d.Profile.Name, input.PowerName(d.PowerLevel())), 255, 255, 255, 255)
+			gfx.StringRGBA(renderer, 10, 46, fmt.Sprintf("GUID %s  axes:%d buttons:%d hats:%d balls:%d",
+				d.GUID, d.NumAxes(), d.NumButtons(), d.NumHats(), len(p.balls)), 255, 255, 255, 255)
+
+			for i := range p.sticks {
+				p.drawStick(renderer, cal, i, 10+int32(i)*110, 66)
+			}
+			for i := range p.triggers {
+				p.drawTrigger(renderer, cal, i, 240+int32(i)*30, 66)
+			}
+			p.drawAxes(renderer, 300, 66)
+			p.drawButtons(renderer, 10, 210)
+			p.drawHats(renderer, 10, 370)
+			for i, b := range p.balls {
+				gfx.StringRGBA(renderer, 500, 370+int32(i)*16, fmt.Sprintf("Ball %d: %d,%d", i, b.X, b.Y), 255, 255, 255, 255)
+			}
+		}
+		gfx.StringRGBA(renderer, 10, 440, "Tab/1-9: device  E: export JSON  ESC: exit", 255, 255, 255, 255)
+		gfx.StringRGBA(renderer, 10, 456, msg, 255, 255, 255, 255)
+		renderer.Present()
+
+		sdl.Delay(16)
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
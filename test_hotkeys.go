@@ -0,0 +1,183 @@
+// Global hotkeys
+// Keyboard: ESC quits, Pause pauses, F12 takes a screenshot, F3 toggles the
+// FPS counter, Ctrl+Up/Down changes the volume. Joystick: SELECT+START
+// quits, holding START pauses, SELECT+L1 takes a screenshot, SELECT+R1
+// toggles the FPS counter, SELECT+UP/DOWN changes the volume. A profile
+// can bring its own "hotkeys". The square is moved with what the hotkeys
+// let through to the game, so SELECT+UP doesn't move it.
+// Usage: ./test_hotkeys [profiles.json]
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+)
+
+var winTitle string = "Go-SDL2 Hotkeys"
+var winWidth, winHeight int32 = 640, 480
+
+// screenshot saves what has been drawn so far as a BMP file.
+func screenshot(renderer *sdl.Renderer, file string) error {
+	w, h, err := renderer.GetOutputSize()
+	if err != nil {
+		return err
+	}
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, w, h, 32, sdl.PIXELFORMAT_ARGB8888)
+	if err != nil {
+		return err
+	}
+	defer surface.Free()
+	if err := renderer.ReadPixels(nil, surface.Format.Format, surface.Data(), int(surface.Pitch)); err != nil {
+		return err
+	}
+	return surface.SaveBMP(file)
+}
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var err error
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	profiles := input.NewProfiles()
+	if len(os.Args) > 1 {
+		if err := profiles.LoadFile(os.Args[1]); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load profiles: %s\n", err)
+			return 3
+		}
+	}
+	registry := input.NewRegistry(profiles)
+	defer registry.Close()
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	sdl.JoystickEventState(sdl.ENABLE)
+	hotkeys := input.NewHotkeys(registry)
+	state := input.NewState(60)
+
+	var log []string
+	var paused, showFPS, shoot bool
+	volume := 5
+	shots := 0
+	x, y := winWidth/2-20, winHeight/2-20
+	frames, fps := 0, 0
+	second := sdl.GetTicks()
+
+	running := true
+	for running {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			registry.HandleEvent(event)
+			for _, e := range hotkeys.HandleEvent(event) {
+				state.HandleEvent(e)
+			}
+			if _, ok := event.(*sdl.QuitEvent); ok {
+				running = false
+			}
+		}
+		for _, action := range hotkeys.Update(sdl.GetTicks()) {
+			switch action {
+			case input.ActionQuit:
+				running = false
+			case input.ActionPause:
+				paused = !paused
+			case input.ActionScreenshot:
+				shoot = true
+			case input.ActionFPS:
+				showFPS = !showFPS
+			case input.ActionVolumeUp:
+				if volume < 10 {
+					volume++
+				}
+			case input.ActionVolumeDown:
+				if volume > 0 {
+					volume--
+				}
+			}
+			log = append(log, fmt.Sprintf("%6d ms  %s", sdl.GetTicks(), action))
+			if len(log) > 8 {
+				log = log[1:]
+			}
+		}
+
+		if !paused && !hotkeys.Active() {
+			if (state.Held(input.JoyHat(0, sdl.HAT_LEFT)) || state.Held(input.Key(sdl.SCANCODE_LEFT))) && x > 0 {
+				x -= 4
+			}
+			if (state.Held(input.JoyHat(0, sdl.HAT_RIGHT)) || state.Held(input.Key(sdl.SCANCODE_RIGHT))) && x < winWidth-40 {
+				x += 4
+			}
+			if (state.Held(input.JoyHat(0, sdl.HAT_UP)) || state.Held(input.Key(sdl.SCANCODE_UP))) && y > 140 {
+				y -= 4
+			}
+			if (state.Held(input.JoyHat(0, sdl.HAT_DOWN)) || state.Held(input.Key(sdl.SCANCODE_DOWN))) && y < winHeight-40 {
+				y += 4
+			}
+		}
+
+		frames++
+		if now := sdl.GetTicks(); now-second >= 1000 {
+			fps, frames, second = frames, 0, now
+		}
+
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+		gfx.StringRGBA(renderer, 10, 10, "ESC/SELECT+START quit, Pause/hold START pause, F12/SELECT+L1 screenshot", 255, 255, 255, 255)
+		gfx.StringRGBA(renderer, 10, 26, "F3/SELECT+R1 FPS, Ctrl+Up/Down or SELECT+UP/DOWN volume", 255, 255, 255, 255)
+		gfx.StringRGBA(renderer, 10, 50, "Volume: "+strings.Repeat("#", volume)+strings.Repeat(".", 10-volume), 0, 255, 0, 255)
+		if showFPS {
+			gfx.StringRGBA(renderer, winWidth-80, 50, fmt.Sprintf("%d FPS", fps), 255, 255, 0, 255)
+		}
+		for i, line := range log {
+			gfx.StringRGBA(renderer, 10, int32(74+i*16), line, 0, 255, 255, 255)
+		}
+		renderer.SetDrawColor(255, 64, 64, 255)
+		renderer.FillRect(&sdl.Rect{x, y, 40, 40})
+		if paused {
+			gfx.StringRGBA(renderer, winWidth/2-24, winHeight/2, "PAUSED", 255, 255, 0, 255)
+		}
+		if shoot {
+			shoot = false
+			shots++
+			file := fmt.Sprintf("screenshot-%d.bmp", shots)
+			if err := screenshot(renderer, file); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to take screenshot: %s\n", err)
+			} else {
+				fmt.Printf("Saved %s\n", file)
+			}
+		}
+		renderer.Present()
+
+		state.NextFrame()
+		sdl.Delay(16)
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
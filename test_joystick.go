@@ -10,16 +10,49 @@ import (
 
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/gfx"
+
+	"go-sdl2/input"
 )
 
 var winTitle string = "Go-SDL2"
 var winWidth, winHeight int32 = 640, 480
 var msgKeyboardEvent string = ""
 var msgJoystickEvent [4]string = [4]string{"", "", "", ""}
-var joysticks [8]*sdl.Joystick
-var msgJoystickInfo [5]string = [5]string{"", "", "", "", ""}
-var buttonState [16]uint8
-var hatValue uint8
+var msgJoystickInfo [6]string = [6]string{"", "", "", "", "", ""}
+var profiles = input.NewProfiles()
+var profile = profiles.Fallback
+var registry = input.NewRegistry(profiles)
+var state = input.NewState(60) // first connected joystick, taps shorter than a frame included
+var lastButton = -1             // most recently pressed button
+var calibrations input.Calibrations
+var stick = input.NewStick(0, 1)
+var dpad uint8 // hat 0 combined with the left stick directions
+var keypad = input.NewKeyPad(registry) // gamepad from Steam's keyboard events on the Steam Deck
+var batteries = input.NewBatteries(registry)
+
+// profiles.json next to the executable overrides or adds device profiles
+const profileFile = "profiles.json"
+
+func buttonPressed(button int) bool {
+	return button >= 0 && state.Down(input.JoyButton(button))
+}
+
+func showJoystickInfo(device *input.Device) {
+	if device == nil {
+		msgJoystickInfo = [6]string{}
+		return
+	}
+	msgJoystickInfo[0] = fmt.Sprintf("Joystick Name: %s", device.Name)
+	msgJoystickInfo[1] = fmt.Sprintf("  - Number of Axes: %d", device.NumAxes())
+	msgJoystickInfo[2] = fmt.Sprintf("  - Number of Buttons: %d", device.NumButtons())
+	if device.Joystick != nil {
+		msgJoystickInfo[3] = fmt.Sprintf("  - Number of Balls: %d", device.Joystick.NumBalls())
+	} else {
+		msgJoystickInfo[3] = "  - Number of Balls: 0"
+	}
+	msgJoystickInfo[4] = fmt.Sprintf("  - Number of Hats: %d", device.NumHats())
+	msgJoystickInfo[5] = fmt.Sprintf("  - Profile: %s", device.Profile.Name)
+}
 
 func run() int {
 	var window *sdl.Window
@@ -28,12 +61,36 @@ func run() int {
 	// var rect sdl.Rect
 	// var rects []sdl.Rect
 
+	if err := profiles.LoadFile(profileFile); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Failed to load profiles: %s\n", err)
+	}
+	var err error
+	// ./test_joystick keypad emulates the Steam Deck gamepad on any machine
+	if len(os.Args) > 1 && os.Args[1] == "keypad" {
+		keypad.Enabled = true
+	}
+	if calibrations, err = input.LoadCalibrations("calibration.json"); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load calibration: %s\n", err)
+	}
+
+	// Pick the drivers of the attached device before video and audio start
+	if err := sdl.Init(sdl.INIT_JOYSTICK); err != nil {
+		return -1
+	}
+	profile = profiles.Detect()
+	profile.ApplyHints()
+	// Fill the screen of a handheld, the diagram is scaled to it
+	if profile.Width > 0 && profile.Height > 0 {
+		winWidth, winHeight = profile.Width, profile.Height
+	}
+
 	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
 		return -1
 	}
 	defer sdl.Quit()
+	defer registry.Close()
 
-	window, err := sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
 		winWidth, winHeight, sdl.WINDOW_SHOWN)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
@@ -47,12 +104,23 @@ func run() int {
 		return 2
 	}
 	defer renderer.Destroy()
+	renderer.SetLogicalSize(640, 480)
 
 	running := true
 	sdl.JoystickEventState(sdl.ENABLE)
 
 	for running {
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			if keypad.HandleEvent(event, input.Push) {
+				continue
+			}
+			device, change := registry.HandleEvent(event)
+			// Follow the first joystick; nothing the previous one held carries over
+			if first := registry.First(); first != nil && first.ID != state.Joystick {
+				state.HandleEvent(&sdl.JoyDeviceRemovedEvent{Type: sdl.JOYDEVICEREMOVED, Which: state.Joystick})
+				state.Joystick = first.ID
+			}
+			state.HandleEvent(event)
 			switch t := event.(type) {
 			case *sdl.QuitEvent:
 				running = false
@@ -68,28 +136,51 @@ func run() int {
 			case *sdl.JoyButtonEvent:
 				msgJoystickEvent[0] = fmt.Sprintf("JoyButton type:%d which:%d button:%d state:%d",
 					 t.Type, t.Which, t.Button, t.State)
-				buttonState[t.Button] = t.State
 			case *sdl.JoyHatEvent:
 				msgJoystickEvent[1] = fmt.Sprintf("JoyHat type:%d which:%d hat:%d value:%d",
 					 t.Type, t.Which, t.Hat, t.Value)
-				hatValue = t.Value
 			case *sdl.JoyDeviceAddedEvent:
-					// Open joystick for use
-					joysticks[int(t.Which)] = sdl.JoystickOpen(int(t.Which))
-					if joysticks[int(t.Which)] != nil {
-						msgJoystickEvent[0] = fmt.Sprintf("Joystick id=%v connected (%v)", t.Which, joysticks[int(t.Which)].Name())
-						msgJoystickInfo[0] = fmt.Sprintf("Joystick Name: %s", joysticks[int(t.Which)].Name())
-						msgJoystickInfo[1] = fmt.Sprintf("  - Number of Axes: %d", joysticks[int(t.Which)].NumAxes())
-						msgJoystickInfo[2] = fmt.Sprintf("  - Number of Buttons: %d", joysticks[int(t.Which)].NumButtons())
-						msgJoystickInfo[3] = fmt.Sprintf("  - Number of Balls: %d", joysticks[int(t.Which)].NumBalls())
-						msgJoystickInfo[4] = fmt.Sprintf("  - Number of Hats: %d", joysticks[int(t.Which)].NumHats())
+				if device != nil && change != input.NoChange {
+					if change == input.Reconnected {
+						msgJoystickEvent[0] = fmt.Sprintf("Joystick id=%v reconnected (%v)", device.ID, device.Name)
+					} else {
+						msgJoystickEvent[0] = fmt.Sprintf("Joystick id=%v connected (%v)", device.ID, device.Name)
 					}
+					showJoystickInfo(registry.First())
+				}
+			case *sdl.JoyDeviceRemovedEvent:
+				if device != nil {
+					msgJoystickEvent[0] = fmt.Sprintf("Joystick id=%v disconnected (%v)", t.Which, device.Name)
+					showJoystickInfo(registry.First())
+				}
+			}
+		}
+
+		keypad.Update(input.Push)
+
+		for _, c := range batteries.Update(sdl.GetTicks()) {
+			if c.Low() {
+				msgJoystickEvent[0] = fmt.Sprintf("Joystick id=%v battery %s (%v)", c.Device.ID, input.PowerName(c.Level), c.Device.Name)
+			}
+		}
+
+		// Only the first connected joystick drives the diagram
+		if first := registry.First(); first != nil {
+			profile = first.Profile
+			_, _, stickHat := stick.UpdateDevice(calibrations[first.GUID], first)
+			dpad = state.Hat(0) | stickHat
+			for i := 0; i < first.NumButtons(); i++ {
+				if state.JustPressed(input.JoyButton(i)) {
+					lastButton = i
+				}
 			}
+		} else {
+			dpad = sdl.HAT_CENTERED
 		}
 
-		if (buttonState[6] & buttonState[7]) == 1 {
+		if profile.ExitPressed(buttonPressed) {
 			running = false
-		} 
+		}
 
 		renderer.SetDrawColor(0, 0, 0, 255)
 		renderer.Clear()
@@ -116,23 +207,23 @@ func run() int {
 		renderer.SetDrawColor(200, 200, 200, 255)
 		renderer.DrawRects([]sdl.Rect{{120, 230, 40, 120}, {80, 270, 120, 40}})
 		renderer.SetDrawColor(0, 255, 0, 255)
-		if hatValue == 1 {
+		if dpad&sdl.HAT_UP != 0 || buttonPressed(profile.Button("UP")) {
 			renderer.FillRect(&sdl.Rect{121, 231, 39, 39})
 		}
-		if hatValue == 2 {
+		if dpad&sdl.HAT_RIGHT != 0 || buttonPressed(profile.Button("RIGHT")) {
 			renderer.FillRect(&sdl.Rect{160, 271, 39, 39})
 		}
-		if hatValue == 4 {
+		if dpad&sdl.HAT_DOWN != 0 || buttonPressed(profile.Button("DOWN")) {
 			renderer.FillRect(&sdl.Rect{121, 310, 39, 39})
 		}
-		if hatValue == 8 {
+		if dpad&sdl.HAT_LEFT != 0 || buttonPressed(profile.Button("LEFT")) {
 			renderer.FillRect(&sdl.Rect{81, 271, 39, 39})
 		}
 
 		// Draw Select + Start Button
 		renderer.SetDrawColor(200, 200, 200, 255)
 		renderer.DrawRects([]sdl.Rect{{245, 330, 60, 20}, {320, 330, 60, 20}})
-		if buttonState[6] == 1 {
+		if buttonPressed(profile.Button("SELECT")) {
 			renderer.SetDrawColor(0, 255, 0, 255)
 			renderer.FillRect(&sdl.Rect{246, 331, 58, 18})
 			gfx.StringRGBA(renderer, 251, 336, "SELECT", 0, 0, 0, 255)
@@ -140,7 +231,7 @@ func run() int {
 			gfx.StringRGBA(renderer, 251, 336, "SELECT", 255, 255, 255, 255)
 		}
 
-		if buttonState[7] == 1 {
+		if buttonPressed(profile.Button("START")) {
 			renderer.SetDrawColor(0, 255, 0, 255)
 			renderer.FillRect(&sdl.Rect{321, 331, 58, 18})
 			gfx.StringRGBA(renderer, 329, 336, "START", 0, 0, 0, 255)
@@ -150,7 +241,7 @@ func run() int {
 
 		// Draw X Buttons
 		gfx.CircleRGBA(renderer, 450, 290, 20, 200, 200, 200, 255)
-		if buttonState[2] == 1 {
+		if buttonPressed(profile.Button("X")) {
 			gfx.FilledCircleRGBA(renderer, 450, 290, 18, 0, 255, 0, 255)
 			gfx.StringRGBA(renderer, 447, 288, "X", 0, 0, 0, 255)
 		}else{
@@ -159,7 +250,7 @@ func run() int {
 		
 		// Draw B Buttons
 		gfx.CircleRGBA(renderer, 540, 290, 20, 200, 200, 200, 255)
-		if buttonState[1] == 1 {
+		if buttonPressed(profile.Button("B")) {
 			gfx.FilledCircleRGBA(renderer, 540, 290, 18, 0, 255, 0, 255)
 			gfx.StringRGBA(renderer, 537, 288, "B", 0, 0, 0, 255)
 		}else{
@@ -168,7 +259,7 @@ func run() int {
 		
 		// Draw Y Buttons
 		gfx.CircleRGBA(renderer, 495, 250, 20, 200, 200, 200, 255)
-		if buttonState[3] == 1 {
+		if buttonPressed(profile.Button("Y")) {
 			gfx.FilledCircleRGBA(renderer, 495, 250, 18, 0, 255, 0, 255)
 			gfx.StringRGBA(renderer, 492, 248, "Y", 0, 0, 0, 255)
 		}else{
@@ -177,21 +268,35 @@ func run() int {
 		
 		// Draw A Buttons
 		gfx.CircleRGBA(renderer, 495, 330, 20, 200, 200, 200, 255)
-		if joysticks[0].Button(0) == 1 {
-		// if buttonState[0] == 1 {
+		if buttonPressed(profile.Button("A")) {
 			gfx.FilledCircleRGBA(renderer, 495, 330, 18, 0, 255, 0, 255)
 			gfx.StringRGBA(renderer, 492, 328, "A", 255, 0, 255, 255)
 		}else{
 			gfx.StringRGBA(renderer, 492, 328, "A", 255, 255, 255, 255)
 		}
 		
-		gfx.StringRGBA(renderer, 100, 10, "Test Input Joystick in SDL2 (EXIT: SELECT+START)", 255, 255, 255, 255)
+		gfx.StringRGBA(renderer, 100, 10, "Test Input Joystick in SDL2 (EXIT: "+exitChordName()+")", 255, 255, 255, 255)
 		if msgJoystickInfo[0] != "" {
 			gfx.StringRGBA(renderer, 50, 30, msgJoystickInfo[0], 0, 255, 0, 255)
 			gfx.StringRGBA(renderer, 50, 30 + 16*1, msgJoystickInfo[1], 0, 255, 0, 255)
 			gfx.StringRGBA(renderer, 50, 30 + 16*2, msgJoystickInfo[2], 0, 255, 0, 255)
 			gfx.StringRGBA(renderer, 50, 30 + 16*3, msgJoystickInfo[3], 0, 255, 0, 255)
 			gfx.StringRGBA(renderer, 50, 30 + 16*4, msgJoystickInfo[4], 0, 255, 0, 255)
+			gfx.StringRGBA(renderer, 50, 30 + 16*5, msgJoystickInfo[5], 0, 255, 0, 255)
+		}
+		if first := registry.First(); first != nil {
+			switch level := batteries.Level(first); level {
+			case sdl.JOYSTICK_POWER_LOW, sdl.JOYSTICK_POWER_EMPTY:
+				gfx.StringRGBA(renderer, 50, 30 + 16*6, "  - Battery: "+input.PowerName(level), 255, 0, 0, 255)
+			default:
+				gfx.StringRGBA(renderer, 50, 30 + 16*6, "  - Battery: "+input.PowerName(level), 0, 255, 0, 255)
+			}
+		}
+		gfx.StringRGBA(renderer, 50, 368, "Gamepad: "+keypad.Mode().String(), 255, 255, 0, 255)
+		if lastButton >= 0 {
+			b := input.JoyButton(lastButton)
+			gfx.StringRGBA(renderer, 50, 384, fmt.Sprintf("%s pressed %d times, held %d frames",
+				profile.ButtonName(lastButton), state.TotalPresses(b), state.HeldFrames(b)), 255, 255, 0, 255)
 		}
 		gfx.StringRGBA(renderer, 50, 400, msgKeyboardEvent, 0, 255, 0, 255)
 
@@ -208,12 +313,24 @@ func run() int {
 			gfx.StringRGBA(renderer, 50, 416 + 16*3, msgJoystickEvent[3], 0, 255, 0, 255)
 		}
 		renderer.Present()
+		state.NextFrame()
 		sdl.Delay(16)
 	}
 
 	return 0
 }
 
+func exitChordName() string {
+	name := ""
+	for i, b := range profile.ExitChord {
+		if i > 0 {
+			name += "+"
+		}
+		name += profile.ButtonName(b)
+	}
+	return name
+}
+
 func main() {
 	os.Exit(run())
 }
@@ -9,28 +9,30 @@ import (
 	"os"
 
 	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
 )
 
 var winTitle string = "Go-SDL2"
 var winWidth, winHeight int32 = 640, 480
 var msgKeyboardEvent string = ""
 var msgJoystickEvent [4]string = [4]string{"", "", "", ""}
-var joysticks [8]*sdl.Joystick
 var msgJoystickInfo [5]string = [5]string{"", "", "", "", ""}
-var buttonState [16]uint8
-var hatValue uint8
+var registry = input.NewRegistry(input.NewProfiles())
 
 func run() int {
 	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
 		return -1
 	}
 	defer sdl.Quit()
+	defer registry.Close()
 
 	running := true
 	sdl.JoystickEventState(sdl.ENABLE)
 
 	for running {
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			device, change := registry.HandleEvent(event)
 			switch t := event.(type) {
 			case *sdl.QuitEvent:
 				running = false
@@ -46,27 +48,30 @@ func run() int {
 			case *sdl.JoyButtonEvent:
 				fmt.Printf("JoyButton type:%d which:%d button:%d state:%d\n",
 					t.Type, t.Which, t.Button, t.State)
-				buttonState[t.Button] = t.State
 			case *sdl.JoyHatEvent:
 				fmt.Printf("JoyHat type:%d which:%d hat:%d value:%d\n",
 					t.Type, t.Which, t.Hat, t.Value)
-				hatValue = t.Value
 			case *sdl.JoyDeviceAddedEvent:
-				// Open joystick for use
-				joysticks[int(t.Which)] = sdl.JoystickOpen(int(t.Which))
-				if joysticks[int(t.Which)] != nil {
-					fmt.Printf("Joystick id=%v connected (%v)\n", t.Which, joysticks[int(t.Which)].Name())
-					fmt.Printf("Joystick Name: %s\n", joysticks[int(t.Which)].Name())
-					fmt.Printf("  - Number of Axes: %d\n", joysticks[int(t.Which)].NumAxes())
-					fmt.Printf("  - Number of Buttons: %d\n", joysticks[int(t.Which)].NumButtons())
-					fmt.Printf("  - Number of Balls: %d\n", joysticks[int(t.Which)].NumBalls())
-					fmt.Printf("  - Number of Hats: %d\n", joysticks[int(t.Which)].NumHats())
+				if device != nil && change != input.NoChange {
+					fmt.Printf("Joystick id=%v connected (%v)\n", device.ID, device.Name)
+					fmt.Printf("Joystick Name: %s\n", device.Name)
+					fmt.Printf("  - Number of Axes: %d\n", device.NumAxes())
+					fmt.Printf("  - Number of Buttons: %d\n", device.NumButtons())
+					fmt.Printf("  - Number of Balls: %d\n", device.Joystick.NumBalls())
+					fmt.Printf("  - Number of Hats: %d\n", device.NumHats())
+					fmt.Printf("  - Profile: %s\n", device.Profile.Name)
+				}
+			case *sdl.JoyDeviceRemovedEvent:
+				if device != nil {
+					fmt.Printf("Joystick id=%v disconnected (%v)\n", t.Which, device.Name)
 				}
 			}
 		}
 
-		if (buttonState[6] & buttonState[7]) == 1 {
-			running = false
+		for _, device := range registry.Devices() {
+			if device.Profile.ExitPressed(device.Button) {
+				running = false
+			}
 		}
 
 		sdl.Delay(16)
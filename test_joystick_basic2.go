@@ -5,9 +5,11 @@ import (
 	"os"
 
 	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
 )
 
-var joysticks [16]*sdl.Joystick
+var registry = input.NewRegistry(nil)
 
 func run() (err error) {
 	var window *sdl.Window
@@ -16,6 +18,7 @@ func run() (err error) {
 		return
 	}
 	defer sdl.Quit()
+	defer registry.Close()
 
 	window, err = sdl.CreateWindow("Input", sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED, 800, 600, sdl.WINDOW_SHOWN)
 	if err != nil {
@@ -28,6 +31,7 @@ func run() (err error) {
 	running := true
 	for running {
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			device, change := registry.HandleEvent(event)
 			switch t := event.(type) {
 			case *sdl.QuitEvent:
 				running = false
@@ -68,16 +72,16 @@ func run() (err error) {
 
 				fmt.Println("Joystick", t.Which, "hat", t.Hat, "moved to", position, "position")
 			case *sdl.JoyDeviceAddedEvent:
-				// Open joystick for use
-				joysticks[int(t.Which)] = sdl.JoystickOpen(int(t.Which))
-				if joysticks[int(t.Which)] != nil {
-					fmt.Println("Joystick", t.Which, "connected")
+				switch change {
+				case input.Added:
+					fmt.Println("Joystick", device.ID, "connected")
+				case input.Reconnected:
+					fmt.Println("Joystick", device.ID, "reconnected")
 				}
 			case *sdl.JoyDeviceRemovedEvent:
-				if joystick := joysticks[int(t.Which)]; joystick != nil {
-					joystick.Close()
+				if device != nil {
+					fmt.Println("Joystick", t.Which, "disconnected")
 				}
-				fmt.Println("Joystick", t.Which, "disconnected")
 			}
 		}
 
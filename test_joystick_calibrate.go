@@ -0,0 +1,197 @@
+// Analog stick calibration
+// Shows raw and processed stick positions with their dead zones and the
+// digital directions derived from them. Calibrations are saved per device.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+)
+
+var winTitle string = "Go-SDL2 Calibrate"
+var winWidth, winHeight int32 = 640, 480
+
+const calibrationFile = "calibration.json"
+
+var registry = input.NewRegistry(input.NewProfiles())
+var sticks []*input.Stick
+
+// newSticks builds the left and right stick from the axis names of a profile
+func newSticks(profile *input.Profile) []*input.Stick {
+	var list []*input.Stick
+	for _, names := range [][2]string{{"LX", "LY"}, {"RX", "RY"}} {
+		if x, y := profile.Axis(names[0]), profile.Axis(names[1]); x >= 0 && y >= 0 {
+			list = append(list, input.NewStick(x, y))
+		}
+	}
+	return list
+}
+
+// drawStick plots a stick inside a square of size 2*r centered at cx, cy
+func drawStick(renderer *sdl.Renderer, cx, cy, r int32, dz input.Deadzone, rawX, rawY, x, y float64, hat uint8) {
+	renderer.SetDrawColor(200, 200, 200, 255)
+	renderer.DrawRect(&sdl.Rect{cx - r, cy - r, 2 * r, 2 * r})
+	gfx.CircleRGBA(renderer, cx, cy, int32(float64(r)*dz.Inner), 255, 0, 0, 255)
+	gfx.CircleRGBA(renderer, cx, cy, int32(float64(r)*dz.Outer), 255, 255, 0, 255)
+	gfx.FilledCircleRGBA(renderer, cx+int32(rawX*float64(r)), cy+int32(rawY*float64(r)), 4, 120, 120, 120, 255)
+	gfx.FilledCircleRGBA(renderer, cx+int32(x*float64(r)), cy+int32(y*float64(r)), 5, 0, 255, 0, 255)
+
+	// Digital directions around the square
+	renderer.SetDrawColor(0, 255, 0, 255)
+	if hat&sdl.HAT_UP != 0 {
+		renderer.FillRect(&sdl.Rect{cx - 10, cy - r - 14, 20, 10})
+	}
+	if hat&sdl.HAT_DOWN != 0 {
+		renderer.FillRect(&sdl.Rect{cx - 10, cy + r + 4, 20, 10})
+	}
+	if hat&sdl.HAT_LEFT != 0 {
+		renderer.FillRect(&sdl.Rect{cx - r - 14, cy - 10, 10, 20})
+	}
+	if hat&sdl.HAT_RIGHT != 0 {
+		renderer.FillRect(&sdl.Rect{cx + r + 4, cy - 10, 10, 20})
+	}
+}
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var msgStatus string = "ENTER/START: calibrate   ESC/SELECT+START: exit"
+	calibrating := false
+
+	calibrations, err := input.LoadCalibrations(calibrationFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load %s: %s\n", calibrationFile, err)
+		return 1
+	}
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		return -1
+	}
+	defer sdl.Quit()
+	defer registry.Close()
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	running := true
+	sdl.JoystickEventState(sdl.ENABLE)
+
+	for running {
+		toggle := false
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			device, _ := registry.HandleEvent(event)
+			switch t := event.(type) {
+			case *sdl.QuitEvent:
+				running = false
+			case *sdl.KeyboardEvent:
+				if t.State == sdl.PRESSED && t.Keysym.Sym == sdl.K_ESCAPE {
+					running = false
+				}
+				if t.State == sdl.PRESSED && t.Keysym.Sym == sdl.K_RETURN {
+					toggle = true
+				}
+			case *sdl.JoyButtonEvent:
+				if device != nil && t.State == sdl.PRESSED && int(t.Button) == device.Profile.Button("START") {
+					toggle = true
+				}
+			case *sdl.JoyAxisEvent:
+				if device == nil {
+					break
+				}
+				if cal := calibrations[device.GUID]; calibrating && cal != nil {
+					cal.Observe(int(t.Axis), t.Value)
+				}
+			case *sdl.JoyDeviceAddedEvent:
+				if device != nil && device == registry.First() {
+					sticks = newSticks(device.Profile)
+				}
+			}
+		}
+
+		device := registry.First()
+		if device != nil && device.Profile.ExitPressed(device.Button) {
+			running = false
+		}
+
+		if toggle && device != nil {
+			if !calibrating {
+				// The sticks must be left alone while the rest position is taken
+				cal := &input.Calibration{}
+				for i := 0; i < device.NumAxes(); i++ {
+					cal.Begin(i, device.Axis(i))
+				}
+				calibrations[device.GUID] = cal
+				calibrating = true
+				msgStatus = "Rotate the sticks and press the triggers fully, then ENTER/START"
+			} else {
+				calibrating = false
+				if err := calibrations.Save(calibrationFile); err != nil {
+					msgStatus = fmt.Sprintf("Failed to save: %s", err)
+				} else {
+					msgStatus = "Calibration saved to " + calibrationFile
+				}
+			}
+		}
+
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+		renderer.SetDrawColor(255, 255, 255, 255)
+		renderer.DrawRect(&sdl.Rect{0, 0, 640, 480})
+		gfx.StringRGBA(renderer, 100, 10, "Analog Stick Calibration", 255, 255, 255, 255)
+
+		if device == nil {
+			gfx.StringRGBA(renderer, 50, 40, "Connect a joystick...", 255, 255, 0, 255)
+		} else {
+			cal := calibrations[device.GUID]
+			gfx.StringRGBA(renderer, 50, 40, fmt.Sprintf("Joystick: %s (%s)", device.Name, device.GUID), 0, 255, 0, 255)
+			for i, stick := range sticks {
+				if stick.X >= device.NumAxes() || stick.Y >= device.NumAxes() {
+					continue
+				}
+				rawX := cal.Normalize(stick.X, device.Axis(stick.X))
+				rawY := cal.Normalize(stick.Y, device.Axis(stick.Y))
+				x, y, hat := stick.UpdateDevice(cal, device)
+				cx := int32(170 + i*300)
+				drawStick(renderer, cx, 220, 100, stick.Deadzone, rawX, rawY, x, y, hat)
+				gfx.StringRGBA(renderer, cx-100, 345, fmt.Sprintf("A%d/A%d %+.2f %+.2f", stick.X, stick.Y, x, y), 255, 255, 255, 255)
+			}
+			for i := 0; i < device.NumAxes() && i < 6; i++ {
+				ac := cal.Axis(i)
+				gfx.StringRGBA(renderer, 50, int32(370+16*i), fmt.Sprintf("A%d raw:%6d min:%6d center:%6d max:%6d",
+					i, device.Axis(i), ac.Min, ac.Center, ac.Max), 200, 200, 200, 255)
+			}
+		}
+		if calibrating {
+			gfx.StringRGBA(renderer, 50, 56, msgStatus, 255, 255, 0, 255)
+		} else {
+			gfx.StringRGBA(renderer, 50, 56, msgStatus, 200, 200, 200, 255)
+		}
+
+		renderer.Present()
+		sdl.Delay(16)
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
@@ -0,0 +1,234 @@
+// Controller remapping wizard
+// Prompts for every gamepad element, then writes an SDL GameController
+// mapping line so unknown handhelds work without recompiling.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+)
+
+var winTitle string = "Go-SDL2 Remap"
+var winWidth, winHeight int32 = 640, 480
+
+// SDL reads this file too when SDL_GAMECONTROLLERCONFIG_FILE points to it
+const mappingFile = "gamecontrollerdb_user.txt"
+
+type element struct {
+	target string
+	label  string
+	rect   sdl.Rect // buttons are drawn as rectangles
+	circle bool     // ... or as circles centered in rect
+}
+
+// Same diagram as test_joystick.go, with shoulders and sticks added
+var diagram = []element{
+	{"dpup", "", sdl.Rect{121, 231, 39, 39}, false},
+	{"dpright", "", sdl.Rect{160, 271, 39, 39}, false},
+	{"dpdown", "", sdl.Rect{121, 310, 39, 39}, false},
+	{"dpleft", "", sdl.Rect{81, 271, 39, 39}, false},
+	{"back", "SELECT", sdl.Rect{246, 331, 58, 18}, false},
+	{"start", "START", sdl.Rect{321, 331, 58, 18}, false},
+	{"guide", "MENU", sdl.Rect{283, 290, 58, 18}, false},
+	{"x", "X", sdl.Rect{430, 270, 40, 40}, true},
+	{"b", "B", sdl.Rect{520, 270, 40, 40}, true},
+	{"y", "Y", sdl.Rect{475, 230, 40, 40}, true},
+	{"a", "A", sdl.Rect{475, 310, 40, 40}, true},
+	{"leftshoulder", "L1", sdl.Rect{80, 190, 120, 20}, false},
+	{"lefttrigger", "L2", sdl.Rect{80, 165, 120, 20}, false},
+	{"rightshoulder", "R1", sdl.Rect{430, 190, 130, 20}, false},
+	{"righttrigger", "R2", sdl.Rect{430, 165, 130, 20}, false},
+	{"leftx", "LX", sdl.Rect{230, 370, 40, 40}, true},
+	{"lefty", "LY", sdl.Rect{230, 370, 40, 40}, true},
+	{"leftstick", "L3", sdl.Rect{230, 370, 40, 40}, true},
+	{"rightx", "RX", sdl.Rect{360, 370, 40, 40}, true},
+	{"righty", "RY", sdl.Rect{360, 370, 40, 40}, true},
+	{"rightstick", "R3", sdl.Rect{360, 370, 40, 40}, true},
+}
+
+func drawElement(renderer *sdl.Renderer, e element, r, g, b uint8, filled bool) {
+	if e.circle {
+		x, y, rad := e.rect.X+e.rect.W/2, e.rect.Y+e.rect.H/2, e.rect.W/2
+		gfx.CircleRGBA(renderer, x, y, rad, 200, 200, 200, 255)
+		if filled {
+			gfx.FilledCircleRGBA(renderer, x, y, rad-2, r, g, b, 255)
+		}
+		gfx.StringRGBA(renderer, x-int32(len(e.label))*4, y-4, e.label, 255, 255, 255, 255)
+		return
+	}
+	renderer.SetDrawColor(200, 200, 200, 255)
+	renderer.DrawRect(&e.rect)
+	if filled {
+		renderer.SetDrawColor(r, g, b, 255)
+		renderer.FillRect(&sdl.Rect{e.rect.X + 1, e.rect.Y + 1, e.rect.W - 2, e.rect.H - 2})
+	}
+	gfx.StringRGBA(renderer, e.rect.X+e.rect.W/2-int32(len(e.label))*4, e.rect.Y+e.rect.H/2-4, e.label, 255, 255, 255, 255)
+}
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var joystick *sdl.Joystick
+	var wizard *input.Wizard
+	var msgResult string
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		return -1
+	}
+	defer sdl.Quit()
+
+	window, err := sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	// finish writes the mapping once every prompt is answered or skipped,
+	// and forgets it when BACKSPACE goes back into the wizard
+	finish := func() {
+		if !wizard.Done() {
+			msgResult = ""
+			return
+		}
+		if msgResult != "" {
+			return
+		}
+		mapping := wizard.Mapping(sdl.JoystickGetGUIDString(joystick.GUID()), joystick.Name())
+		fmt.Println(mapping)
+		if err := input.SaveMapping(mappingFile, mapping); err != nil {
+			msgResult = fmt.Sprintf("Failed to save mapping: %s", err)
+		} else {
+			msgResult = "Mapping saved to " + mappingFile
+		}
+	}
+
+	running := true
+	sdl.JoystickEventState(sdl.ENABLE)
+
+	for running {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch t := event.(type) {
+			case *sdl.QuitEvent:
+				running = false
+			case *sdl.KeyboardEvent:
+				if t.State != sdl.PRESSED || wizard == nil {
+					if t.Keysym.Sym == sdl.K_ESCAPE {
+						running = false
+					}
+					break
+				}
+				switch t.Keysym.Sym {
+				case sdl.K_ESCAPE:
+					running = false
+				case sdl.K_BACKSPACE:
+					wizard.Back()
+					finish()
+				case sdl.K_TAB, sdl.K_SPACE:
+					wizard.Skip()
+					finish()
+				}
+			case *sdl.JoyDeviceAddedEvent:
+				// Remap the first joystick only, the others are ignored
+				if joystick != nil {
+					break
+				}
+				joystick = sdl.JoystickOpen(int(t.Which))
+				if joystick == nil {
+					break
+				}
+				wizard = input.NewWizard(input.DefaultPrompts)
+				for i := 0; i < joystick.NumAxes(); i++ {
+					if value, ok := joystick.AxisInitialState(i); ok {
+						wizard.SetRest(i, value)
+					} else {
+						wizard.SetRest(i, joystick.Axis(i))
+					}
+				}
+			case *sdl.JoyDeviceRemovedEvent:
+				if joystick != nil && joystick.InstanceID() == t.Which {
+					joystick.Close()
+					joystick = nil
+					wizard = nil
+				}
+			case *sdl.JoyButtonEvent, *sdl.JoyHatEvent, *sdl.JoyAxisEvent:
+				if wizard == nil || wizard.Done() {
+					// Any button leaves once the mapping is written
+					if e, ok := t.(*sdl.JoyButtonEvent); ok && msgResult != "" && e.State == sdl.PRESSED {
+						running = false
+					}
+					break
+				}
+				wizard.Feed(event)
+				finish()
+			}
+		}
+
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+
+		renderer.SetDrawColor(255, 255, 255, 255)
+		renderer.DrawRect(&sdl.Rect{0, 0, 640, 480})
+		gfx.StringRGBA(renderer, 100, 10, "Controller Remapping Wizard (EXIT: ESC)", 255, 255, 255, 255)
+
+		if wizard == nil {
+			gfx.StringRGBA(renderer, 50, 60, "Connect a joystick...", 255, 255, 0, 255)
+			renderer.Present()
+			sdl.Delay(16)
+			continue
+		}
+
+		prompt := wizard.Current()
+		for _, e := range diagram {
+			if _, ok := wizard.Bound(e.target); ok {
+				drawElement(renderer, e, 0, 120, 0, true)
+			} else {
+				drawElement(renderer, e, 0, 0, 0, false)
+			}
+		}
+		// The prompted element is drawn last so it stays visible on shared sticks
+		for _, e := range diagram {
+			if prompt != nil && e.target == prompt.Target {
+				drawElement(renderer, e, 200, 200, 0, true)
+			}
+		}
+
+		gfx.StringRGBA(renderer, 50, 30, fmt.Sprintf("Joystick: %s", joystick.Name()), 0, 255, 0, 255)
+		if prompt != nil {
+			gfx.StringRGBA(renderer, 50, 60, fmt.Sprintf("[%d/%d] Please %s", wizard.Step+1, len(wizard.Prompts), prompt.Text), 255, 255, 0, 255)
+			if prompt.Optional {
+				gfx.StringRGBA(renderer, 50, 76, "Optional: press START or TAB to skip", 200, 200, 200, 255)
+			}
+			gfx.StringRGBA(renderer, 50, 92, "BACKSPACE: previous step", 200, 200, 200, 255)
+			if wizard.Message != "" {
+				gfx.StringRGBA(renderer, 50, 430, wizard.Message, 255, 0, 0, 255)
+			}
+		} else {
+			gfx.StringRGBA(renderer, 50, 60, msgResult, 0, 255, 0, 255)
+			gfx.StringRGBA(renderer, 50, 76, "Press any button to exit", 200, 200, 200, 255)
+		}
+
+		renderer.Present()
+		sdl.Delay(16)
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
@@ -0,0 +1,186 @@
+// Input latency and polling rate measurement
+// Press keys, mouse or joystick buttons: the screen flashes white on the
+// frame that shows the press. Move a stick to measure the report rate of
+// its joystick. ESC or closing the window prints the report to stdout.
+// Usage: ./test_latency [-vsync] [-delay ms] [-seconds n]
+// -delay is the sleep per frame, 16 by default and 0 with -vsync; -seconds
+// stops after n seconds. Set SDL_VIDEODRIVER and SDL_RENDER_DRIVER to
+// compare drivers.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+	"go-sdl2/latency"
+)
+
+var winTitle string = "Go-SDL2 Latency"
+var winWidth, winHeight int32 = 640, 480
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var err error
+
+	vsync := false
+	delay := -1
+	seconds := 0
+	for i := 1; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "-vsync":
+			vsync = true
+		case "-delay", "-seconds":
+			if i+1 >= len(os.Args) {
+				fmt.Fprintf(os.Stderr, "Missing value for %s\n", os.Args[i])
+				return 3
+			}
+			n, err := strconv.Atoi(os.Args[i+1])
+			if err != nil || n < 0 {
+				fmt.Fprintf(os.Stderr, "Invalid value for %s: %s\n", os.Args[i], os.Args[i+1])
+				return 3
+			}
+			if os.Args[i] == "-delay" {
+				delay = n
+			} else {
+				seconds = n
+			}
+			i++
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown argument: %s\n", os.Args[i])
+			return 3
+		}
+	}
+	if delay < 0 {
+		delay = 16
+		if vsync {
+			delay = 0
+		}
+	}
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	registry := input.NewRegistry(input.NewProfiles())
+	defer registry.Close()
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	flags := uint32(sdl.RENDERER_ACCELERATED)
+	if vsync {
+		flags |= sdl.RENDERER_PRESENTVSYNC
+	}
+	renderer, err = sdl.CreateRenderer(window, -1, flags)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	var header []string
+	if driver, err := sdl.GetCurrentVideoDriver(); err == nil {
+		header = append(header, "Video driver: "+driver)
+	}
+	if info, err := renderer.GetInfo(); err == nil {
+		header = append(header, fmt.Sprintf("Renderer: %s, vsync %v, delay %d ms",
+			info.Name, info.Flags&sdl.RENDERER_PRESENTVSYNC != 0, delay))
+	}
+	if mode, err := window.GetDisplayMode(); err == nil {
+		header = append(header, fmt.Sprintf("Display: %dx%d at %d Hz", mode.W, mode.H, mode.RefreshRate))
+	}
+
+	sdl.JoystickEventState(sdl.ENABLE)
+	meter := latency.NewMeter()
+	start := sdl.GetTicks()
+	// The statistics are redrawn twice a second, computing them every
+	// frame would add to the frame time being measured
+	var stats, devices []string
+	var updated uint32
+
+	running := true
+	for running {
+		flash := false
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			if meter.HandleEvent(event) {
+				flash = true
+			}
+			device, _ := registry.HandleEvent(event)
+			switch t := event.(type) {
+			case *sdl.QuitEvent:
+				running = false
+			case *sdl.KeyboardEvent:
+				if t.State == sdl.PRESSED && t.Keysym.Sym == sdl.K_ESCAPE {
+					running = false
+				}
+			case *sdl.JoyDeviceAddedEvent:
+				if device != nil {
+					meter.Device(device.ID).Name = fmt.Sprintf("%s (id %d)", device.Name, device.ID)
+				}
+			}
+		}
+		if seconds > 0 && sdl.GetTicks()-start >= uint32(seconds)*1000 {
+			running = false
+		}
+
+		if flash {
+			renderer.SetDrawColor(255, 255, 255, 255)
+			renderer.Clear()
+		} else {
+			renderer.SetDrawColor(0, 0, 0, 255)
+			renderer.Clear()
+			for i, line := range header {
+				gfx.StringRGBA(renderer, 10, int32(10+i*16), line, 255, 255, 255, 255)
+			}
+			if stats == nil || sdl.GetTicks()-updated >= 500 {
+				stats, devices = stats[:0], devices[:0]
+				for _, s := range []*latency.Samples{&meter.EventDelay, &meter.Visible, &meter.Frames, &meter.FrameTime} {
+					st := s.Stats()
+					stats = append(stats, fmt.Sprintf("%-18s %-6s n=%-5d min %6.2f med %6.2f p99 %6.2f",
+						s.Name, s.Unit, st.N, st.Min, st.Median, st.P99))
+				}
+				for _, d := range meter.Devices() {
+					st := d.Interval.Stats()
+					devices = append(devices, fmt.Sprintf("%s: %.0f Hz, jitter %.2f ms", d.Name, d.Rate(), st.StdDev))
+				}
+				updated = sdl.GetTicks()
+			}
+			y := int32(10 + len(header)*16 + 16)
+			for _, line := range stats {
+				gfx.StringRGBA(renderer, 10, y, line, 0, 255, 0, 255)
+				y += 16
+			}
+			y += 16
+			for _, line := range devices {
+				gfx.StringRGBA(renderer, 10, y, line, 255, 255, 0, 255)
+				y += 16
+			}
+		}
+		renderer.Present()
+		meter.Presented()
+
+		if delay > 0 {
+			sdl.Delay(uint32(delay))
+		}
+	}
+
+	meter.Report(os.Stdout, header...)
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
@@ -0,0 +1,171 @@
+// Loading screen
+// Loads an image, a font at several sizes, an SND bank and music in the
+// background and draws a progress bar meanwhile. Missing files show as
+// errors. ESC during loading cancels and exits.
+// Usage: ./test_loader [image] [font.ttf] [file.snd] [music]
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/mix"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+
+	"go-sdl2/leak"
+	"go-sdl2/loader"
+	"go-sdl2/mainthread"
+)
+
+var winTitle string = "Go-SDL2 Loader"
+var winWidth, winHeight int32 = 640, 480
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var err error
+
+	// Built with -tags leakcheck, lists what the defers below didn't free
+	defer leak.Check()
+
+	files := []string{"image.png", "DejaVuSans.ttf", "test.snd", "background.mp3"}
+	copy(files, os.Args[1:])
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	if err := ttf.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init TTF: %s\n", err)
+		return -1
+	}
+	defer ttf.Quit()
+	audio := mix.OpenAudio(44100, mix.DEFAULT_FORMAT, 2, 2048) == nil
+	if audio {
+		defer mix.CloseAudio()
+	}
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	assets := loader.New(renderer)
+	image := assets.Image(files[0])
+	var fonts []*loader.Asset
+	for size := 8; size <= 64; size += 8 {
+		fonts = append(fonts, assets.Font(files[1], size))
+	}
+	var bank, music *loader.Asset
+	if audio {
+		bank = assets.Snd(files[2])
+		music = assets.Music(files[3])
+	}
+	assets.Start(context.Background())
+	defer assets.Free()
+
+	var lines []string
+	start := sdl.GetTicks()
+	var loadedIn uint32
+	running := true
+	for running {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch t := event.(type) {
+			case *sdl.QuitEvent:
+				running = false
+			case *sdl.KeyboardEvent:
+				if t.State == sdl.PRESSED && t.Keysym.Sym == sdl.K_ESCAPE {
+					running = false
+				}
+			}
+		}
+		if !running && !assets.Done() {
+			assets.Cancel()
+			fmt.Println("Loading cancelled")
+			break
+		}
+
+		// Textures, fonts and chunks are created here, at most 4 ms a frame
+		mainthread.ProcessFor(4 * time.Millisecond)
+		for pending := true; pending; {
+			select {
+			case p := <-assets.Progress():
+				line := fmt.Sprintf("%d/%d %s", p.Done, p.Total, p.Asset)
+				if p.Asset.Err != nil {
+					line = fmt.Sprintf("%d/%d %s", p.Done, p.Total, p.Asset.Err)
+				}
+				lines = append(lines, line)
+				if p.Done == p.Total {
+					loadedIn = sdl.GetTicks() - start
+					if music != nil && music.Loaded {
+						music.Music.Play(-1)
+					}
+				}
+			default:
+				pending = false
+			}
+		}
+
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+		if !assets.Done() {
+			renderer.SetDrawColor(255, 255, 255, 255)
+			renderer.DrawRect(&sdl.Rect{120, 220, 400, 24})
+			renderer.FillRect(&sdl.Rect{122, 222, int32(assets.Fraction() * 396), 20})
+			gfx.StringRGBA(renderer, 120, 200, fmt.Sprintf("Loading... %.0f%%", assets.Fraction()*100), 255, 255, 255, 255)
+		} else {
+			if image.Loaded {
+				renderer.Copy(image.Texture, nil, &sdl.Rect{320, 10, 310, 230})
+			}
+			y := int32(10)
+			for _, f := range fonts {
+				if !f.Loaded {
+					continue
+				}
+				surface, err := leak.Surface(f.Font.RenderUTF8Blended(fmt.Sprintf("%dpt", f.Size), sdl.Color{R: 255, G: 255, B: 255, A: 255}))
+				if err != nil {
+					continue
+				}
+				if texture, err := leak.Texture(renderer.CreateTextureFromSurface(surface)); err == nil {
+					renderer.Copy(texture, nil, &sdl.Rect{10, y, surface.W, surface.H})
+					leak.DestroyTexture(texture)
+				}
+				y += surface.H
+				leak.FreeSurface(surface)
+			}
+			if bank != nil && bank.Loaded {
+				gfx.StringRGBA(renderer, 320, 250, fmt.Sprintf("%d sounds in %s", len(bank.Bank.Chunks), bank.Path), 255, 255, 255, 255)
+			}
+			gfx.StringRGBA(renderer, 320, 266, fmt.Sprintf("Loaded in %d ms", loadedIn), 255, 255, 255, 255)
+		}
+		for i, line := range lines {
+			gfx.StringRGBA(renderer, 10, 300+int32(i)*12, line, 200, 200, 200, 255)
+		}
+		leak.Draw(renderer, 10, 200)
+		renderer.Present()
+
+		sdl.Delay(16)
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
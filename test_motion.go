@@ -0,0 +1,236 @@
+// Gyro and accelerometer of a game controller
+// Shows the gyro rates, the tilt from the accelerometer and a crosshair
+// aimed by turning the controller. Hold L1 to ratchet (re-center without
+// moving), C or BACK calibrates (keep the controller still for 2 seconds),
+// M moves the mouse too, +/- sensitivity, I inverts Y, G turns the sensors
+// on and off, R re-centers, ESC or SELECT+START exits.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+	"go-sdl2/motion"
+)
+
+var winTitle string = "Go-SDL2 Motion"
+var winWidth, winHeight int32 = 640, 480
+
+const calibrationMS = 2000
+
+// drawRate draws a bar from the middle of a 200 pixel track, full at 2π rad/s.
+func drawRate(renderer *sdl.Renderer, x, y int32, name string, rate float64) {
+	renderer.SetDrawColor(200, 200, 200, 255)
+	renderer.DrawRect(&sdl.Rect{x, y, 200, 16})
+	w := int32(rate / (2 * 3.14159) * 100)
+	if w > 100 {
+		w = 100
+	} else if w < -100 {
+		w = -100
+	}
+	renderer.SetDrawColor(0, 255, 0, 255)
+	if w >= 0 {
+		renderer.FillRect(&sdl.Rect{x + 100, y + 1, w, 14})
+	} else {
+		renderer.FillRect(&sdl.Rect{x + 100 + w, y + 1, -w, 14})
+	}
+	gfx.StringRGBA(renderer, x-48, y+4, name, 255, 255, 255, 255)
+	gfx.StringRGBA(renderer, x+210, y+4, fmt.Sprintf("%+6.2f rad/s", rate), 255, 255, 255, 255)
+}
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var err error
+	var ctrl *motion.Controller
+	var msg string
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	registry := input.NewRegistry(input.NewProfiles())
+	defer registry.Close()
+	hotkeys := input.NewHotkeys(registry)
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	sdl.JoystickEventState(sdl.ENABLE)
+	sdl.GameControllerEventState(sdl.ENABLE)
+	defer func() {
+		if ctrl != nil {
+			ctrl.Close()
+		}
+	}()
+
+	crossX, crossY := winWidth/2, winHeight/2+80
+	moveMouse := false
+	var calibrateUntil uint32
+
+	startCalibration := func() {
+		if ctrl != nil && !ctrl.Gyro.Calibrating() {
+			ctrl.Gyro.StartCalibration()
+			calibrateUntil = sdl.GetTicks() + calibrationMS
+			msg = "Calibrating, keep the controller still"
+		}
+	}
+
+	running := true
+	for running {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			registry.HandleEvent(event)
+			for _, e := range hotkeys.HandleEvent(event) {
+				if ctrl != nil && ctrl.HandleEvent(e) {
+					continue
+				}
+				switch t := e.(type) {
+				case *sdl.QuitEvent:
+					running = false
+				case *sdl.ControllerDeviceEvent:
+					if t.Type == sdl.CONTROLLERDEVICEADDED && ctrl == nil {
+						if ctrl, err = motion.Open(int(t.Which)); err != nil {
+							msg = fmt.Sprintf("Failed to open controller: %s", err)
+							ctrl = nil
+						} else {
+							msg = fmt.Sprintf("Controller id=%v connected (%s)", ctrl.ID, ctrl.GameController.Name())
+							startCalibration()
+						}
+					} else if t.Type == sdl.CONTROLLERDEVICEREMOVED && ctrl != nil && t.Which == ctrl.ID {
+						msg = fmt.Sprintf("Controller id=%v disconnected", t.Which)
+						ctrl.Close()
+						ctrl = nil
+					}
+				case *sdl.ControllerButtonEvent:
+					if t.State == sdl.PRESSED && t.Button == sdl.CONTROLLER_BUTTON_BACK {
+						startCalibration()
+					}
+				case *sdl.KeyboardEvent:
+					if t.State != sdl.PRESSED || ctrl == nil {
+						break
+					}
+					switch t.Keysym.Sym {
+					case sdl.K_c:
+						startCalibration()
+					case sdl.K_m:
+						moveMouse = !moveMouse
+					case sdl.K_PLUS, sdl.K_EQUALS, sdl.K_KP_PLUS:
+						ctrl.Aim.Sensitivity += 2
+					case sdl.K_MINUS, sdl.K_KP_MINUS:
+						if ctrl.Aim.Sensitivity > 2 {
+							ctrl.Aim.Sensitivity -= 2
+						}
+					case sdl.K_i:
+						ctrl.Aim.InvertY = !ctrl.Aim.InvertY
+					case sdl.K_g:
+						if err := ctrl.Enable(!ctrl.Enabled()); err != nil {
+							msg = fmt.Sprintf("Failed to switch sensors: %s", err)
+						}
+					case sdl.K_r:
+						crossX, crossY = winWidth/2, winHeight/2+80
+					}
+				}
+			}
+		}
+		for _, action := range hotkeys.Update(sdl.GetTicks()) {
+			if action == input.ActionQuit {
+				running = false
+			}
+		}
+
+		if ctrl != nil && ctrl.Gyro.Calibrating() && sdl.GetTicks() >= calibrateUntil {
+			if ctrl.Gyro.FinishCalibration() {
+				b := ctrl.Gyro.Bias
+				msg = fmt.Sprintf("Calibrated, bias %+.4f %+.4f %+.4f rad/s", b[0], b[1], b[2])
+			} else {
+				msg = "No gyro data to calibrate with"
+			}
+		}
+		if ctrl != nil {
+			dx, dy := ctrl.Motion()
+			crossX, crossY = crossX+dx, crossY+dy
+			if crossX < 0 {
+				crossX = 0
+			} else if crossX >= winWidth {
+				crossX = winWidth - 1
+			}
+			if crossY < 200 {
+				crossY = 200
+			} else if crossY >= winHeight {
+				crossY = winHeight - 1
+			}
+			if moveMouse && (dx != 0 || dy != 0) {
+				window.WarpMouseInWindow(crossX, crossY)
+			}
+		}
+
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+		renderer.SetDrawColor(255, 255, 255, 255)
+		renderer.DrawRect(&sdl.Rect{0, 0, 640, 480})
+
+		if ctrl == nil {
+			gfx.StringRGBA(renderer, 10, 10, "Connect a game controller with a gyro", 255, 255, 255, 255)
+		} else {
+			gfx.StringRGBA(renderer, 10, 10, fmt.Sprintf("%s  gyro:%v accel:%v on:%v rate:%.0f Hz",
+				ctrl.GameController.Name(), ctrl.HasGyro(), ctrl.HasAccel(), ctrl.Enabled(), ctrl.DataRate()), 255, 255, 255, 255)
+			gfx.StringRGBA(renderer, 10, 26, fmt.Sprintf("Sensitivity %.0f px/deg  invert Y:%v  mouse:%v  still:%v",
+				ctrl.Aim.Sensitivity, ctrl.Aim.InvertY, moveMouse, ctrl.Gyro.Still()), 255, 255, 255, 255)
+
+			// Gyro rates
+			rate := ctrl.Gyro.Rate
+			drawRate(renderer, 70, 50, "pitch", rate[0])
+			drawRate(renderer, 70, 70, "yaw", rate[1])
+			drawRate(renderer, 70, 90, "roll", rate[2])
+
+			// Bubble level from the accelerometer
+			pitch, roll := ctrl.Accel.Tilt()
+			gfx.CircleRGBA(renderer, 560, 100, 50, 200, 200, 200, 255)
+			gfx.CircleRGBA(renderer, 560, 100, 8, 100, 100, 100, 255)
+			bx, by := int32(560+roll/90*50), int32(100+pitch/90*50)
+			gfx.FilledCircleRGBA(renderer, bx, by, 6, 0, 255, 0, 255)
+			gfx.StringRGBA(renderer, 500, 160, fmt.Sprintf("P%+4.0f R%+4.0f", pitch, roll), 255, 255, 255, 255)
+
+			// Crosshair
+			r, g, b := uint8(0), uint8(255), uint8(0)
+			if ctrl.Aim.Ratchet {
+				r, g, b = 255, 255, 0
+				gfx.StringRGBA(renderer, 10, 184, "Ratchet", 255, 255, 0, 255)
+			}
+			if ctrl.Gyro.Calibrating() {
+				r, g, b = 255, 0, 0
+			}
+			gfx.CircleRGBA(renderer, crossX, crossY, 12, r, g, b, 255)
+			gfx.LineRGBA(renderer, crossX-20, crossY, crossX+20, crossY, r, g, b, 255)
+			gfx.LineRGBA(renderer, crossX, crossY-20, crossX, crossY+20, r, g, b, 255)
+		}
+		gfx.StringRGBA(renderer, 10, 168, msg, 255, 255, 255, 255)
+		renderer.Present()
+
+		sdl.Delay(16)
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
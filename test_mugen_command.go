@@ -0,0 +1,173 @@
+// MUGEN style command input
+// Shows the input history like a training mode and the commands of a .cmd
+// file (sample.cmd by default) recognized from keyboard (arrows, a s d =
+// x y z, z x c = a b c) or the first joystick.
+// Usage: ./test_mugen_command [file.cmd]
+// TAB or SELECT turns the player around, ESC exits.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+	"go-sdl2/mugen"
+)
+
+var winTitle string = "Go-SDL2 Command"
+var winWidth, winHeight int32 = 640, 480
+
+// historyEntry is one line of the input display
+type historyEntry struct {
+	keys  mugen.Keys
+	ticks int
+}
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var history []historyEntry
+	var fired []string
+	var wasActive map[string]bool
+	facing := 1
+
+	cmdFile := "sample.cmd"
+	if len(os.Args) > 1 {
+		cmdFile = os.Args[1]
+	}
+	cmd, err := mugen.LoadCmd(cmdFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load commands: %s\n", err)
+		return 1
+	}
+	recognizer := cmd.Recognizer()
+	in := mugen.NewInput()
+	registry := input.NewRegistry(input.NewProfiles())
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	defer registry.Close()
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	sdl.JoystickEventState(sdl.ENABLE)
+
+	running := true
+	for running {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			device, _ := registry.HandleEvent(event)
+			in.Device = registry.First()
+			in.HandleEvent(event)
+			switch t := event.(type) {
+			case *sdl.QuitEvent:
+				running = false
+			case *sdl.KeyboardEvent:
+				if t.State == sdl.PRESSED && t.Repeat == 0 {
+					switch t.Keysym.Sym {
+					case sdl.K_ESCAPE:
+						running = false
+					case sdl.K_TAB:
+						facing = -facing
+					}
+				}
+			case *sdl.JoyButtonEvent:
+				if device != nil && t.State == sdl.PRESSED && int(t.Button) == device.Profile.Button("SELECT") {
+					facing = -facing
+				}
+			}
+		}
+
+		// One game tick: read the keys, feed the recognizer
+		device := registry.First()
+		if device != nil && device.Profile.ExitPressed(device.Button) {
+			running = false
+		}
+		recognizer.Update(in.Tick(), facing)
+		rel := recognizer.Keys()
+		if len(history) > 0 && history[0].keys == rel {
+			history[0].ticks++
+		} else {
+			history = append([]historyEntry{{rel, 1}}, history...)
+			if len(history) > 24 {
+				history = history[:24]
+			}
+		}
+		// Held commands such as holdfwd are only listed when they start
+		active := make(map[string]bool)
+		for _, name := range recognizer.ActiveNames() {
+			active[name] = true
+			if wasActive[name] {
+				continue
+			}
+			fired = append([]string{name}, fired...)
+			if len(fired) > 24 {
+				fired = fired[:24]
+			}
+		}
+		wasActive = active
+
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+		renderer.SetDrawColor(255, 255, 255, 255)
+		renderer.DrawRect(&sdl.Rect{0, 0, 640, 480})
+		gfx.StringRGBA(renderer, 100, 10, "MUGEN Command Input", 255, 255, 255, 255)
+		if facing > 0 {
+			gfx.StringRGBA(renderer, 400, 10, "Facing: right", 255, 255, 0, 255)
+		} else {
+			gfx.StringRGBA(renderer, 400, 10, "Facing: left", 255, 255, 0, 255)
+		}
+
+		gfx.StringRGBA(renderer, 20, 40, "Input (ticks)", 200, 200, 200, 255)
+		for i, h := range history {
+			gfx.StringRGBA(renderer, 20, int32(60+16*i), fmt.Sprintf("%3d %s", h.ticks, h.keys), 255, 255, 255, 255)
+		}
+
+		gfx.StringRGBA(renderer, 240, 40, "Commands", 200, 200, 200, 255)
+		for i, c := range cmd.Commands {
+			if i >= 10 {
+				break
+			}
+			gfx.StringRGBA(renderer, 240, int32(60+16*i), fmt.Sprintf("%-11s %s", c.Name, c.Source), 150, 150, 150, 255)
+		}
+		gfx.StringRGBA(renderer, 240, 240, "Recognized", 200, 200, 200, 255)
+		for i, name := range fired {
+			if i >= 13 {
+				break
+			}
+			if i == 0 {
+				gfx.StringRGBA(renderer, 240, int32(260+16*i), name, 0, 255, 0, 255)
+			} else {
+				gfx.StringRGBA(renderer, 240, int32(260+16*i), name, 0, 150, 0, 255)
+			}
+		}
+
+		renderer.Present()
+		sdl.Delay(16)
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/veandco/go-sdl2/sdl"
+	"go-sdl2/input"
 )
 
 func main() {
@@ -46,18 +47,21 @@ func main() {
 	gl.DepthFunc(gl.LEQUAL)
 	gl.Viewport(0, 0, int32(winWidth), int32(winHeight))
 
+	hotkeys := input.NewHotkeys(nil)
 	running = true
 	for running {
 		for event = sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-			switch t := event.(type) {
-			case *sdl.QuitEvent:
-				running = false
-			case *sdl.KeyboardEvent:
-				if t.Keysym.Sym == 27 {
+			for _, e := range hotkeys.HandleEvent(event) {
+				if _, ok := e.(*sdl.QuitEvent); ok {
 					running = false
 				}
 			}
 		}
+		for _, action := range hotkeys.Update(sdl.GetTicks()) {
+			if action == input.ActionQuit {
+				running = false
+			}
+		}
 		drawgl()
 		window.GLSwap()
 	}
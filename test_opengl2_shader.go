@@ -3,12 +3,14 @@ package main
 import (
 	"fmt"
 	"log"
-	"runtime"
 	"strings"
 
 	"github.com/go-gl/gl/v2.1/gl"
 	"github.com/veandco/go-sdl2/img"
 	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/leak"
+	"go-sdl2/mainthread" // also locks the main goroutine to the main thread
 )
 
 const (
@@ -43,11 +45,10 @@ const (
 	` + "\x00"
 )
 
-func init() {
-	runtime.LockOSThread()
-}
-
 func main() {
+	// Built with -tags leakcheck, lists what the defers below didn't free
+	defer leak.Check()
+
 	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
 		log.Fatal(err)
 	}
@@ -71,17 +72,26 @@ func main() {
 	fmt.Printf("Version: %v\nRenderer: %v\n", gl.GoStr(gl.GetString(gl.VERSION)), gl.GoStr((gl.GetString(gl.RENDERER))))
 	fmt.Println("Press ESC to quit")
 
-	// Load image
-	textureID, err := loadImage(imageFile)
-	if err != nil {
-		log.Fatal(err)
-	}
+	// Load the image on a goroutine, the window shows meanwhile
+	var textureID uint32
+	textures := make(chan uint32, 1)
+	go func() {
+		textureID, err := loadImage(imageFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		textures <- textureID
+	}()
 
 	// Compile and link shaders
 	program, err := newProgram(vertexShader, fragmentShader)
 	if err != nil {
 		log.Fatal(err)
 	}
+	defer func() {
+		leak.Untrack(leak.GLProgram, program)
+		gl.DeleteProgram(program)
+	}()
 	gl.UseProgram(program)
 
 	// Set up vertex data and attribute pointers
@@ -95,6 +105,7 @@ func main() {
 	var vao, vbo uint32
 	gl.GenVertexArrays(1, &vao)
 	gl.GenBuffers(1, &vbo)
+	leak.Track(leak.GLBuffer, vbo)
 
 	gl.BindVertexArray(vao)
 
@@ -109,6 +120,11 @@ func main() {
 	gl.BindVertexArray(0)
 
 	for !shouldQuit(window) {
+		mainthread.Process()
+		select {
+		case textureID = <-textures:
+		default:
+		}
 		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
 		gl.UseProgram(program)
@@ -124,18 +140,24 @@ func main() {
 	}
 
 	gl.DeleteVertexArrays(1, &vao)
+	leak.Untrack(leak.GLBuffer, vbo)
 	gl.DeleteBuffers(1, &vbo)
+	leak.Untrack(leak.GLTexture, textureID)
 	gl.DeleteTextures(1, &textureID)
 }
 
 func loadImage(filename string) (uint32, error) {
-	surface, err := img.Load(filename)
+	surface, err := leak.Surface(img.Load(filename))
 	if err != nil {
 		return 0, fmt.Errorf("failed to load image: %v", err)
 	}
-	defer surface.Free()
+	defer leak.FreeSurface(surface)
 
-	textureID := createTexture(surface)
+	// Decoding can happen anywhere, GL only on the thread of the context
+	var textureID uint32
+	mainthread.Call(func() {
+		textureID = createTexture(surface)
+	})
 
 	return textureID, nil
 }
@@ -143,6 +165,7 @@ func loadImage(filename string) (uint32, error) {
 func createTexture(surface *sdl.Surface) uint32 {
 	var textureID uint32
 	gl.GenTextures(1, &textureID)
+	leak.Track(leak.GLTexture, textureID)
 	gl.BindTexture(gl.TEXTURE_2D, textureID)
 
 	// Set the texture wrapping parameters
@@ -186,8 +209,10 @@ func newProgram(vertexShaderSource, fragmentShaderSource string) (uint32, error)
 		logInfo := strings.Repeat("\x00", int(logLength+1))
 		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(logInfo))
 
+		gl.DeleteProgram(program)
 		return 0, fmt.Errorf("link program error: %v", logInfo)
 	}
+	leak.Track(leak.GLProgram, program)
 
 	return program, nil
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	gl "github.com/leonkasovan/gl/v3.1/gles2"
 	"github.com/veandco/go-sdl2/sdl"
+	"go-sdl2/input"
 	"runtime"
 )
 
@@ -88,18 +89,21 @@ func main() {
 	vao, vbo := makeVBO(vertices)
 
 	// Main loop
+	hotkeys := input.NewHotkeys(nil)
 	running := true
 	for running {
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-			switch t := event.(type) {
-			case *sdl.QuitEvent:
-				running = false
-			case *sdl.KeyboardEvent:
-				if t.Keysym.Sym == 27 {
+			for _, e := range hotkeys.HandleEvent(event) {
+				if _, ok := e.(*sdl.QuitEvent); ok {
 					running = false
 				}
 			}
 		}
+		for _, action := range hotkeys.Update(sdl.GetTicks()) {
+			if action == input.ActionQuit {
+				running = false
+			}
+		}
 
 		// Clear the screen
 		gl.Clear(gl.COLOR_BUFFER_BIT)
@@ -0,0 +1,184 @@
+// Player slots
+// Press a button on a joystick, or a key of a keyboard half (WASD + F G H,
+// or the arrows + J K L), to join. Unplugging a player's joystick pauses
+// until the same joystick comes back. 1-4 frees a slot, ESC quits. The
+// slots players took are saved to players.json and reused next time. The
+// player LEDs and light bar of a pad show its player, and every player
+// line shows the battery.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+	"go-sdl2/led"
+)
+
+var winTitle string = "Go-SDL2 Players"
+var winWidth, winHeight int32 = 640, 480
+
+const prefsFile = "players.json"
+
+var colors = []sdl.Color{{255, 64, 64, 255}, {64, 128, 255, 255}, {64, 255, 64, 255}, {255, 255, 64, 255}}
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var err error
+	var msg string
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	registry := input.NewRegistry(input.NewProfiles())
+	defer registry.Close()
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	players := input.NewPlayers(registry, 4, 60)
+	if prefs, err := input.LoadSlotPrefs(prefsFile); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load player slots: %s\n", err)
+	} else {
+		players.Prefs = prefs
+	}
+	defer func() {
+		if err := players.Prefs.Save(prefsFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to save player slots: %s\n", err)
+		}
+	}()
+	sdl.JoystickEventState(sdl.ENABLE)
+	batteries := input.NewBatteries(registry)
+
+	// Position of every player's square
+	var x, y [4]int32
+	for i := range x {
+		x[i], y[i] = 80+int32(i)*150, 300
+	}
+
+	running := true
+	for running {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			player, change := players.HandleEvent(event)
+			switch change {
+			case input.Added:
+				msg = fmt.Sprintf("%s joined with %s", player.Name(), player.Source())
+				led.ShowSlot(player)
+			case input.Removed:
+				msg = fmt.Sprintf("%s: %s unplugged", player.Name(), player.Source())
+			case input.Reconnected:
+				msg = fmt.Sprintf("%s: %s is back", player.Name(), player.Source())
+				led.ShowSlot(player)
+			}
+			switch t := event.(type) {
+			case *sdl.QuitEvent:
+				running = false
+			case *sdl.KeyboardEvent:
+				if t.State != sdl.PRESSED || player != nil {
+					break
+				}
+				switch t.Keysym.Sym {
+				case sdl.K_ESCAPE:
+					running = false
+				case sdl.K_1, sdl.K_2, sdl.K_3, sdl.K_4:
+					slot := int(t.Keysym.Sym - sdl.K_1)
+					if p := players.Slot(slot); p.Joined() {
+						msg = fmt.Sprintf("%s (%s) left", p.Name(), p.Source())
+						if p.Device != nil && p.Device.Joystick != nil {
+							led.Clear(p.Device.Joystick)
+						}
+						players.Leave(slot)
+					}
+				}
+			}
+		}
+
+		for _, c := range batteries.Update(sdl.GetTicks()) {
+			if c.Low() {
+				msg = fmt.Sprintf("%s battery %s", c.Device.Name, input.PowerName(c.Level))
+			}
+		}
+
+		paused := players.Paused()
+		for _, p := range players.Joined() {
+			if paused {
+				break
+			}
+			s := p.State
+			if (s.Held(input.JoyHat(0, sdl.HAT_LEFT)) || s.Axis(0) < -16384) && x[p.Index] > 20 {
+				x[p.Index] -= 4
+			}
+			if (s.Held(input.JoyHat(0, sdl.HAT_RIGHT)) || s.Axis(0) > 16384) && x[p.Index] < winWidth-60 {
+				x[p.Index] += 4
+			}
+			if (s.Held(input.JoyHat(0, sdl.HAT_UP)) || s.Axis(1) < -16384) && y[p.Index] > 180 {
+				y[p.Index] -= 4
+			}
+			if (s.Held(input.JoyHat(0, sdl.HAT_DOWN)) || s.Axis(1) > 16384) && y[p.Index] < winHeight-60 {
+				y[p.Index] += 4
+			}
+		}
+
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+		gfx.StringRGBA(renderer, 10, 10, "Press a button or key to join, 1-4 frees a slot, ESC quits", 255, 255, 255, 255)
+		for i, p := range players.Slots {
+			c := colors[i]
+			line := fmt.Sprintf("%s: free", p.Name())
+			if p.Joined() {
+				var held []string
+				profile := p.Profile()
+				for b := 0; b < 16; b++ {
+					if p.State.Held(input.JoyButton(b)) {
+						held = append(held, profile.ButtonName(b))
+					}
+				}
+				line = fmt.Sprintf("%s: %s (%s) %s", p.Name(), p.Source(), profile.Name, strings.Join(held, " "))
+				if p.Device != nil {
+					line = fmt.Sprintf("%s: %s (%s, battery %s) %s", p.Name(), p.Source(), profile.Name,
+						input.PowerName(batteries.Level(p.Device)), strings.Join(held, " "))
+				}
+				renderer.SetDrawColor(c.R, c.G, c.B, c.A)
+				renderer.FillRect(&sdl.Rect{x[i], y[i], 40, 40})
+				gfx.StringRGBA(renderer, x[i]+4, y[i]+16, fmt.Sprintf("P%d", i+1), 0, 0, 0, 255)
+			}
+			gfx.StringRGBA(renderer, 10, int32(40+i*16), line, c.R, c.G, c.B, c.A)
+		}
+		gfx.StringRGBA(renderer, 10, 120, msg, 255, 255, 255, 255)
+		if paused {
+			for i, p := range players.Waiting() {
+				gfx.StringRGBA(renderer, 160, int32(200+i*16), fmt.Sprintf("Paused: %s, reconnect %s", p.Name(), p.Source()), 255, 255, 0, 255)
+			}
+		}
+		renderer.Present()
+
+		players.NextFrame()
+		sdl.Delay(16)
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
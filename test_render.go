@@ -9,16 +9,17 @@ import (
 	"os"
 
 	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
 )
 
 var winTitle string = "Go-SDL2"
 var winWidth, winHeight int32 = 640, 480
 var msgKeyboardEvent string = ""
 var msgJoystickEvent [4]string = [4]string{"", "", "", ""}
-var joysticks [8]*sdl.Joystick
 var msgJoystickInfo [5]string = [5]string{"", "", "", "", ""}
-var buttonState [16]uint8
-var hatValue uint8
+var registry = input.NewRegistry(input.NewProfiles())
+var hotkeys = input.NewHotkeys(registry)
 
 func run() int {
 	var window *sdl.Window
@@ -31,6 +32,7 @@ func run() int {
 		return -1
 	}
 	defer sdl.Quit()
+	defer registry.Close()
 
 	// Get the number of available video drivers
 	numDrivers, err := sdl.GetNumVideoDrivers()
@@ -77,46 +79,48 @@ func run() int {
 
 	for running {
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-			switch t := event.(type) {
-			case *sdl.QuitEvent:
-				running = false
-			case *sdl.KeyboardEvent:
-				msgKeyboardEvent = fmt.Sprintf("Keyboard [%c] type:%d sym:%d scancode:%v modi:%d state:%d",
-				t.Keysym.Sym, t.Type, t.Keysym.Sym, t.Keysym.Scancode, t.Keysym.Mod, t.State)
-				if t.Keysym.Sym == 27 {
+			device, change := registry.HandleEvent(event)
+			for _, e := range hotkeys.HandleEvent(event) {
+				switch t := e.(type) {
+				case *sdl.QuitEvent:
 					running = false
-				}
-			case *sdl.JoyAxisEvent:
-				msgJoystickEvent[2] = fmt.Sprintf("JoyAxis type:%d which:%d axis:%d value:%d",
-					 t.Type, t.Which, t.Axis, t.Value)
-			case *sdl.JoyBallEvent:
-				msgJoystickEvent[3] = fmt.Sprintf("JoyBall type:%d which:%d ball:%d xrel:%d yrel:%d",
-					 t.Type, t.Which, t.Ball, t.XRel, t.YRel)
-			case *sdl.JoyButtonEvent:
-				msgJoystickEvent[0] = fmt.Sprintf("JoyButton type:%d which:%d button:%d state:%d",
-					 t.Type, t.Which, t.Button, t.State)
-				buttonState[t.Button] = t.State
-			case *sdl.JoyHatEvent:
-				msgJoystickEvent[1] = fmt.Sprintf("JoyHat type:%d which:%d hat:%d value:%d",
-					 t.Type, t.Which, t.Hat, t.Value)
-				hatValue = t.Value
-			case *sdl.JoyDeviceAddedEvent:
-					// Open joystick for use
-					joysticks[int(t.Which)] = sdl.JoystickOpen(int(t.Which))
-					if joysticks[int(t.Which)] != nil {
-						msgJoystickEvent[0] = fmt.Sprintf("Joystick id=%v connected (%v)", t.Which, joysticks[int(t.Which)].Name())
-						msgJoystickInfo[0] = fmt.Sprintf("Joystick Name: %s", joysticks[int(t.Which)].Name())
-						msgJoystickInfo[1] = fmt.Sprintf("  - Number of Axes: %d", joysticks[int(t.Which)].NumAxes())
-						msgJoystickInfo[2] = fmt.Sprintf("  - Number of Buttons: %d", joysticks[int(t.Which)].NumButtons())
-						msgJoystickInfo[3] = fmt.Sprintf("  - Number of Balls: %d", joysticks[int(t.Which)].NumBalls())
-						msgJoystickInfo[4] = fmt.Sprintf("  - Number of Hats: %d", joysticks[int(t.Which)].NumHats())
+				case *sdl.KeyboardEvent:
+					msgKeyboardEvent = fmt.Sprintf("Keyboard [%c] type:%d sym:%d scancode:%v modi:%d state:%d",
+					t.Keysym.Sym, t.Type, t.Keysym.Sym, t.Keysym.Scancode, t.Keysym.Mod, t.State)
+				case *sdl.JoyAxisEvent:
+					msgJoystickEvent[2] = fmt.Sprintf("JoyAxis type:%d which:%d axis:%d value:%d",
+						 t.Type, t.Which, t.Axis, t.Value)
+				case *sdl.JoyBallEvent:
+					msgJoystickEvent[3] = fmt.Sprintf("JoyBall type:%d which:%d ball:%d xrel:%d yrel:%d",
+						 t.Type, t.Which, t.Ball, t.XRel, t.YRel)
+				case *sdl.JoyButtonEvent:
+					msgJoystickEvent[0] = fmt.Sprintf("JoyButton type:%d which:%d button:%d state:%d",
+						 t.Type, t.Which, t.Button, t.State)
+				case *sdl.JoyHatEvent:
+					msgJoystickEvent[1] = fmt.Sprintf("JoyHat type:%d which:%d hat:%d value:%d",
+						 t.Type, t.Which, t.Hat, t.Value)
+				case *sdl.JoyDeviceAddedEvent:
+					if device != nil && change != input.NoChange {
+						msgJoystickEvent[0] = fmt.Sprintf("Joystick id=%v connected (%v)", device.ID, device.Name)
+						msgJoystickInfo[0] = fmt.Sprintf("Joystick Name: %s", device.Name)
+						msgJoystickInfo[1] = fmt.Sprintf("  - Number of Axes: %d", device.NumAxes())
+						msgJoystickInfo[2] = fmt.Sprintf("  - Number of Buttons: %d", device.NumButtons())
+						msgJoystickInfo[3] = fmt.Sprintf("  - Number of Balls: %d", device.Joystick.NumBalls())
+						msgJoystickInfo[4] = fmt.Sprintf("  - Number of Hats: %d", device.NumHats())
+					}
+				case *sdl.JoyDeviceRemovedEvent:
+					if device != nil {
+						msgJoystickEvent[0] = fmt.Sprintf("Joystick id=%v disconnected (%v)", t.Which, device.Name)
 					}
+				}
 			}
 		}
 
-		if (buttonState[6] & buttonState[7]) == 1 {
-			running = false
-		} 
+		for _, action := range hotkeys.Update(sdl.GetTicks()) {
+			if action == input.ActionQuit {
+				running = false
+			}
+		}
 
 		renderer.SetDrawColor(0, 0, 0, 255)
 		renderer.Clear()
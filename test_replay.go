@@ -0,0 +1,193 @@
+// Input recording and deterministic replay
+// Record:  ./test_replay record session.rec
+// Replay:  SDL_VIDEODRIVER=dummy ./test_replay play session.rec
+// Both modes print the same checksum when the replay matches the recording.
+
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"os"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+)
+
+var winTitle string = "Go-SDL2 Replay"
+var winWidth, winHeight int32 = 640, 480
+
+// game is moved by keyboard arrows, d-pad, left stick, mouse clicks and touch
+type game struct {
+	x, y  int32
+	keys  uint8 // held arrow keys as hat bits
+	stick *input.Stick
+}
+
+func (g *game) handle(event sdl.Event) {
+	switch t := event.(type) {
+	case *sdl.KeyboardEvent:
+		if t.Repeat != 0 {
+			break
+		}
+		var bit uint8
+		switch t.Keysym.Sym {
+		case sdl.K_UP:
+			bit = sdl.HAT_UP
+		case sdl.K_DOWN:
+			bit = sdl.HAT_DOWN
+		case sdl.K_LEFT:
+			bit = sdl.HAT_LEFT
+		case sdl.K_RIGHT:
+			bit = sdl.HAT_RIGHT
+		}
+		if t.State == sdl.PRESSED {
+			g.keys |= bit
+		} else {
+			g.keys &^= bit
+		}
+	case *sdl.MouseButtonEvent:
+		if t.State == sdl.PRESSED {
+			g.x, g.y = t.X, t.Y
+		}
+	case *sdl.TouchFingerEvent:
+		if t.Type == sdl.FINGERDOWN {
+			g.x, g.y = int32(t.X*float32(winWidth)), int32(t.Y*float32(winHeight))
+		}
+	}
+}
+
+// update moves the player one frame, it never looks at the clock
+func (g *game) update(device *input.Device) {
+	dir := g.keys
+	if device != nil {
+		_, _, stickHat := g.stick.UpdateDevice(nil, device)
+		dir |= device.Hat(0) | stickHat
+	}
+	if dir&sdl.HAT_UP != 0 && g.y > 0 {
+		g.y -= 4
+	}
+	if dir&sdl.HAT_DOWN != 0 && g.y < winHeight-20 {
+		g.y += 4
+	}
+	if dir&sdl.HAT_LEFT != 0 && g.x > 0 {
+		g.x -= 4
+	}
+	if dir&sdl.HAT_RIGHT != 0 && g.x < winWidth-20 {
+		g.x += 4
+	}
+}
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var recorder *input.Recorder
+	var player *input.Player
+	var err error
+
+	if len(os.Args) != 3 || (os.Args[1] != "record" && os.Args[1] != "play") {
+		fmt.Fprintf(os.Stderr, "Usage: %s record|play FILE\n", os.Args[0])
+		return 1
+	}
+	registry := input.NewRegistry(input.NewProfiles())
+	g := &game{x: winWidth / 2, y: winHeight / 2, stick: input.NewStick(0, 1)}
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	defer registry.Close()
+
+	if os.Args[1] == "record" {
+		if recorder, err = input.NewRecorder(os.Args[2]); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create recording: %s\n", err)
+			return 1
+		}
+		recorder.Registry = registry
+		defer func() {
+			if err := recorder.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to write recording: %s\n", err)
+			}
+		}()
+	} else {
+		if player, err = input.NewPlayer(os.Args[2]); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load recording: %s\n", err)
+			return 1
+		}
+		player.Registry = registry
+		fmt.Printf("Replaying %d events\n", len(player.Records))
+	}
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_SOFTWARE)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	if player != nil {
+		input.IgnoreJoysticks()
+	} else {
+		sdl.JoystickEventState(sdl.ENABLE)
+	}
+
+	hash := fnv.New64a()
+	frame := 0
+	running := true
+	for running {
+		if player != nil {
+			if player.Done() {
+				break
+			}
+			player.Step(input.Push)
+		}
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			registry.HandleEvent(event)
+			if recorder != nil {
+				recorder.Record(event)
+			}
+			switch t := event.(type) {
+			case *sdl.QuitEvent:
+				running = false
+			case *sdl.KeyboardEvent:
+				if t.Keysym.Sym == sdl.K_ESCAPE {
+					running = false
+				}
+			}
+			g.handle(event)
+		}
+
+		g.update(registry.First())
+		fmt.Fprintf(hash, "%d,%d;", g.x, g.y)
+		frame++
+		if recorder != nil {
+			recorder.NextFrame()
+		}
+
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+		renderer.SetDrawColor(0, 255, 0, 255)
+		renderer.FillRect(&sdl.Rect{g.x, g.y, 20, 20})
+		renderer.Present()
+		if player == nil {
+			sdl.Delay(16)
+		}
+	}
+
+	fmt.Printf("Frames: %d position: %d,%d checksum: %016x\n", frame, g.x, g.y, hash.Sum64())
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
@@ -0,0 +1,255 @@
+// Rumble patterns on the connected joysticks
+// A / 1: hit, B / 2: heavy hit, X / 3: KO, UP / DOWN: intensity of the
+// player, SELECT+START or ESC exits. Pads without rumble show what they
+// would feel.
+// ./test_rumble check plays the patterns on a fake device instead and
+// exits with 1 when the playback is wrong, no window and no pad needed.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+	"go-sdl2/rumble"
+)
+
+var winTitle string = "Go-SDL2 Rumble"
+var winWidth, winHeight int32 = 640, 480
+
+// checkPatterns plays the built-in patterns on a fake device
+func checkPatterns() int {
+	failed := 0
+	expect := func(desc string, ok bool) {
+		if ok {
+			fmt.Printf("ok    %s\n", desc)
+		} else {
+			fmt.Printf("FAIL  %s\n", desc)
+			failed++
+		}
+	}
+
+	service := rumble.NewService()
+	fake := &rumble.Fake{}
+	service.SetDevice(0, fake)
+	expect("unknown pattern is an error", service.Play(0, "nope") != nil)
+
+	service.Play(0, "hit")
+	service.Update(1000)
+	expect("hit starts on the light motor", fake.High == 0xcccc && fake.Low == 0x3333)
+	service.Update(1010)
+	expect("same level isn't sent again", len(fake.Calls) == 2) // triggers too
+	service.Update(1090)
+	expect("hit fades", fake.High > 0 && fake.High < 0xcccc)
+	service.Update(1200)
+	expect("hit ends with the motors off", fake.Low == 0 && fake.High == 0 && service.Playing(0) == "")
+
+	service.Play(0, "ko")
+	service.Update(2000)
+	service.Play(0, "hit")
+	service.Update(2010)
+	expect("hit doesn't interrupt ko", service.Playing(0) == "ko")
+	expect("ko uses the triggers", fake.Left > 0 && fake.Right > 0)
+	service.Update(2200)
+	expect("ko pauses between pulses", fake.Low == 0)
+	service.Update(2350)
+	expect("ko pulses again", fake.Low > 0)
+	service.Stop(0)
+	expect("stop turns the motors off", fake.Low == 0 && fake.High == 0 && fake.Left == 0)
+
+	service.SetIntensity(0, 0.5)
+	service.Play(0, "heavy hit")
+	service.Update(3000)
+	expect("intensity scales the pattern", fake.Low == 0x7fff)
+
+	silent := &rumble.Fake{Err: fmt.Errorf("no motors")}
+	service.SetDevice(1, silent)
+	service.Play(1, "heavy hit")
+	service.Update(4000)
+	expect("pad without rumble is silent", !service.Supported(1) && service.Playing(1) == "heavy hit")
+	service.SetDevice(2, nil)
+	service.Play(2, "hit")
+	service.Update(4000)
+	expect("player without pad is silent", !service.Supported(2))
+
+	if failed > 0 {
+		fmt.Printf("%d checks failed\n", failed)
+		return 1
+	}
+	fmt.Println("All checks passed")
+	return 0
+}
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var err error
+
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		return checkPatterns()
+	}
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	registry := input.NewRegistry(input.NewProfiles())
+	defer registry.Close()
+	service := rumble.NewService()
+	defer service.StopAll()
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	sdl.JoystickEventState(sdl.ENABLE)
+
+	// player is the slot of a device, in order of connection
+	player := func(d *input.Device) int {
+		for i, c := range registry.Devices() {
+			if c == d {
+				return i
+			}
+		}
+		return -1
+	}
+	// Devices shift when one is unplugged, so they are assigned again
+	assign := func() {
+		connected := registry.Devices()
+		for i := 0; i < 4; i++ {
+			if i < len(connected) && connected[i].Joystick != nil {
+				service.SetDevice(i, rumble.Open(connected[i].Joystick))
+			} else {
+				service.SetDevice(i, nil)
+			}
+		}
+	}
+	assign()
+
+	running := true
+	for running {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			device, change := registry.HandleEvent(event)
+			switch t := event.(type) {
+			case *sdl.QuitEvent:
+				running = false
+			case *sdl.KeyboardEvent:
+				if t.State != sdl.PRESSED || t.Repeat != 0 {
+					break
+				}
+				switch t.Keysym.Sym {
+				case sdl.K_ESCAPE:
+					running = false
+				case sdl.K_1:
+					service.Play(0, "hit")
+				case sdl.K_2:
+					service.Play(0, "heavy hit")
+				case sdl.K_3:
+					service.Play(0, "ko")
+				case sdl.K_UP:
+					service.SetIntensity(0, service.Intensity(0)+0.1)
+				case sdl.K_DOWN:
+					service.SetIntensity(0, service.Intensity(0)-0.1)
+				}
+			case *sdl.JoyButtonEvent:
+				if device == nil || t.State != sdl.PRESSED {
+					break
+				}
+				p := player(device)
+				switch device.Profile.ButtonName(int(t.Button)) {
+				case "A":
+					service.Play(p, "hit")
+				case "B":
+					service.Play(p, "heavy hit")
+				case "X":
+					service.Play(p, "ko")
+				case "UP":
+					service.SetIntensity(p, service.Intensity(p)+0.1)
+				case "DOWN":
+					service.SetIntensity(p, service.Intensity(p)-0.1)
+				}
+			case *sdl.JoyHatEvent:
+				if device == nil {
+					break
+				}
+				p := player(device)
+				if t.Value&sdl.HAT_UP != 0 {
+					service.SetIntensity(p, service.Intensity(p)+0.1)
+				} else if t.Value&sdl.HAT_DOWN != 0 {
+					service.SetIntensity(p, service.Intensity(p)-0.1)
+				}
+			case *sdl.JoyDeviceAddedEvent, *sdl.JoyDeviceRemovedEvent:
+				if change != input.NoChange {
+					assign()
+				}
+			}
+		}
+		for _, d := range registry.Devices() {
+			if d.Profile.ExitPressed(d.Button) {
+				running = false
+			}
+		}
+		service.Update(sdl.GetTicks())
+
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+		renderer.SetDrawColor(255, 255, 255, 255)
+		renderer.DrawRect(&sdl.Rect{0, 0, 640, 480})
+		gfx.StringRGBA(renderer, 100, 10, "Rumble: A/1 hit, B/2 heavy hit, X/3 KO", 255, 255, 255, 255)
+		gfx.StringRGBA(renderer, 100, 26, "UP/DOWN intensity", 255, 255, 255, 255)
+
+		connected := registry.Devices()
+		for i := 0; i < 4; i++ {
+			y := int32(60 + 100*i)
+			name := "keyboard"
+			if i < len(connected) {
+				name = connected[i].Name
+			} else if i > 0 {
+				continue
+			}
+			support := ""
+			if !service.Supported(i) {
+				support = " (no rumble)"
+			}
+			gfx.StringRGBA(renderer, 20, y, fmt.Sprintf("Player %d: %s%s", i+1, name, support), 255, 255, 0, 255)
+			gfx.StringRGBA(renderer, 20, y+16, fmt.Sprintf("Intensity %3.0f%%  %s", 100*service.Intensity(i), service.Playing(i)), 200, 200, 200, 255)
+			level := service.Level(i)
+			for j, m := range []struct {
+				name  string
+				value float64
+			}{{"Low", level.Low}, {"High", level.High}, {"L trig", level.Left}, {"R trig", level.Right}} {
+				x := int32(20 + 150*j)
+				gfx.StringRGBA(renderer, x, y+36, m.name, 200, 200, 200, 255)
+				renderer.SetDrawColor(200, 200, 200, 255)
+				renderer.DrawRect(&sdl.Rect{x, y + 50, 130, 14})
+				renderer.SetDrawColor(0, 255, 0, 255)
+				renderer.FillRect(&sdl.Rect{x + 1, y + 51, int32(128 * m.value), 12})
+			}
+		}
+
+		renderer.Present()
+		sdl.Delay(16)
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
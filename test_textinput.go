@@ -0,0 +1,129 @@
+// Text input with keyboard, IME or on-screen keyboard
+// Type on a keyboard, or use the d-pad and A of a joystick on the on-screen
+// keyboard (B delete, X shift, Y space, L1/R1 cursor, SELECT layout,
+// START done). ESC exits.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+
+	"go-sdl2/input"
+	"go-sdl2/textinput"
+)
+
+var winTitle string = "Go-SDL2 Text Input"
+var winWidth, winHeight int32 = 640, 480
+
+const fontPath = "DejaVuSans.ttf"
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var err error
+	var entered []string
+
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	if err := ttf.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init TTF: %s\n", err)
+		return -1
+	}
+	defer ttf.Quit()
+	registry := input.NewRegistry(input.NewProfiles())
+	defer registry.Close()
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	font, err := ttf.OpenFont(fontPath, 18)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open font: %s\n", err)
+		return 3
+	}
+	defer font.Close()
+
+	sdl.JoystickEventState(sdl.ENABLE)
+	box := textinput.New(font, sdl.Rect{100, 60, 440, 32}, sdl.Rect{20, 250, 600, 210})
+	box.Registry = registry
+	box.Max = 32
+	defer box.Close()
+	box.Start()
+	defer box.Stop()
+	// Handhelds have no keyboard, so open the on-screen one right away
+	box.ShowKeyboard(sdl.NumJoysticks() > 0)
+
+	running := true
+	for running {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			registry.HandleEvent(event)
+			if box.HandleEvent(event) {
+				continue
+			}
+			switch t := event.(type) {
+			case *sdl.QuitEvent:
+				running = false
+			case *sdl.KeyboardEvent:
+				if t.State == sdl.PRESSED && t.Keysym.Sym == sdl.K_ESCAPE {
+					running = false
+				}
+			}
+		}
+		if device := registry.First(); device != nil && device.Profile.ExitPressed(device.Button) {
+			running = false
+		}
+
+		switch box.Result {
+		case textinput.Done:
+			entered = append([]string{box.Text}, entered...)
+			box.SetText("")
+			box.Start()
+		case textinput.Canceled:
+			running = false
+		}
+
+		renderer.SetDrawColor(0, 0, 0, 255)
+		renderer.Clear()
+		gfx.StringRGBA(renderer, 100, 10, "Text Input (keyboard, IME or on-screen keyboard)", 255, 255, 255, 255)
+		gfx.StringRGBA(renderer, 100, 40, fmt.Sprintf("Name (%d/%d):", box.Len(), box.Max), 200, 200, 200, 255)
+		if box.Composition() != "" {
+			gfx.StringRGBA(renderer, 100, 100, "Composing...", 255, 255, 0, 255)
+		}
+		for i, s := range entered {
+			if i >= 8 {
+				break
+			}
+			gfx.StringRGBA(renderer, 100, int32(120+16*i), fmt.Sprintf("Entered %q", s), 0, 255, 0, 255)
+		}
+		box.Draw(renderer)
+
+		renderer.Present()
+		sdl.Delay(16)
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
@@ -0,0 +1,155 @@
+// On-screen gamepad for touch screens
+// The overlay acts as a joystick named "Touch Gamepad"; the text shows what
+// the input registry sees. The mouse works as a finger too.
+// E: edit layout (drag controls, pinch to resize), +/-: opacity,
+// [ ]: size, S: save layout to vpad.json, ESC or SELECT+START exits.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+	"go-sdl2/vpad"
+)
+
+var winTitle string = "Go-SDL2 Touch"
+var winWidth, winHeight int32 = 640, 480
+
+const layoutFile = "vpad.json"
+
+func run() int {
+	var window *sdl.Window
+	var renderer *sdl.Renderer
+	var err error
+	var msg string
+
+	// Clicks become touches, touches don't become clicks
+	sdl.SetHint(sdl.HINT_MOUSE_TOUCH_EVENTS, "1")
+	sdl.SetHint(sdl.HINT_TOUCH_MOUSE_EVENTS, "0")
+	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	registry := input.NewRegistry(input.NewProfiles())
+	defer registry.Close()
+
+	window, err = sdl.CreateWindow(winTitle, sdl.WINDOWPOS_UNDEFINED, sdl.WINDOWPOS_UNDEFINED,
+		winWidth, winHeight, sdl.WINDOW_SHOWN|sdl.WINDOW_RESIZABLE)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create window: %s\n", err)
+		return 1
+	}
+	defer window.Destroy()
+
+	renderer, err = sdl.CreateRenderer(window, -1, sdl.RENDERER_ACCELERATED)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create renderer: %s\n", err)
+		return 2
+	}
+	defer renderer.Destroy()
+
+	sdl.JoystickEventState(sdl.ENABLE)
+	pad := vpad.New(registry, winWidth, winHeight)
+	if layout, err := vpad.LoadLayout(layoutFile); err == nil {
+		pad.Layout = layout
+	} else if !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Failed to load layout: %s\n", err)
+	}
+	pad.Attach(input.Push)
+
+	running := true
+	for running {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			if pad.HandleEvent(event, input.Push) {
+				continue
+			}
+			device, _ := registry.HandleEvent(event)
+			switch t := event.(type) {
+			case *sdl.QuitEvent:
+				running = false
+			case *sdl.WindowEvent:
+				if t.Event == sdl.WINDOWEVENT_SIZE_CHANGED {
+					pad.Resize(t.Data1, t.Data2)
+				}
+			case *sdl.KeyboardEvent:
+				if t.State != sdl.PRESSED {
+					break
+				}
+				layout := pad.Layout
+				switch t.Keysym.Sym {
+				case sdl.K_ESCAPE:
+					running = false
+				case sdl.K_e:
+					pad.Edit(!pad.Editing(), input.Push)
+				case sdl.K_PLUS, sdl.K_EQUALS, sdl.K_KP_PLUS:
+					if layout.Opacity <= 255-16 {
+						layout.Opacity += 16
+					}
+				case sdl.K_MINUS, sdl.K_KP_MINUS:
+					if layout.Opacity >= 16 {
+						layout.Opacity -= 16
+					}
+				case sdl.K_LEFTBRACKET:
+					if layout.Scale > 0.5 {
+						layout.Scale -= 0.1
+					}
+				case sdl.K_RIGHTBRACKET:
+					if layout.Scale < 2 {
+						layout.Scale += 0.1
+					}
+				case sdl.K_s:
+					if err := layout.Save(layoutFile); err != nil {
+						msg = fmt.Sprintf("Failed to save layout: %s", err)
+					} else {
+						msg = "Layout saved to " + layoutFile
+					}
+				}
+			case *sdl.JoyDeviceAddedEvent:
+				if device != nil {
+					msg = fmt.Sprintf("Joystick id=%v connected (%v)", device.ID, device.Name)
+				}
+			}
+		}
+		if device := registry.Device(vpad.ID); device != nil && device.Profile.ExitPressed(device.Button) {
+			running = false
+		}
+
+		renderer.SetDrawColor(0, 0, 40, 255)
+		renderer.Clear()
+		gfx.StringRGBA(renderer, 100, 10, "Touch Gamepad (E: edit, +/-: opacity, [ ]: size, S: save)", 255, 255, 255, 255)
+		if pad.Editing() {
+			gfx.StringRGBA(renderer, 100, 26, "Editing: drag the controls, pinch to resize", 255, 255, 0, 255)
+		}
+		if device := registry.Device(vpad.ID); device != nil {
+			snap := device.Snapshot()
+			var held []string
+			for i := 0; i < device.NumButtons(); i++ {
+				if snap.Button(i) {
+					held = append(held, device.Profile.ButtonName(i))
+				}
+			}
+			gfx.StringRGBA(renderer, 100, 50, fmt.Sprintf("%s (%s)", device.Name, device.Profile.Name), 0, 255, 0, 255)
+			gfx.StringRGBA(renderer, 100, 66, "Buttons: "+strings.Join(held, " "), 0, 255, 0, 255)
+			gfx.StringRGBA(renderer, 100, 82, fmt.Sprintf("Hat: %d  LX: %6d  LY: %6d", snap.Hat(0), snap.Axis(0), snap.Axis(1)), 0, 255, 0, 255)
+		}
+		gfx.StringRGBA(renderer, 100, 98, fmt.Sprintf("Opacity: %d  Size: %.1f", pad.Layout.Opacity, pad.Layout.Scale), 200, 200, 200, 255)
+		gfx.StringRGBA(renderer, 100, 114, msg, 200, 200, 200, 255)
+		pad.Draw(renderer)
+
+		renderer.Present()
+		sdl.Delay(16)
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
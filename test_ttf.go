@@ -3,11 +3,16 @@ package main
 import (
 	"fmt"
 	"math/rand"
+	"os"
 	"runtime"
 	"time"
 
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/ttf"
+
+	"go-sdl2/assets"
+	"go-sdl2/input"
+	"go-sdl2/leak"
 )
 
 const (
@@ -38,6 +43,8 @@ func init() {
 
 func main() {
 	runtime.LockOSThread()
+	// Built with -tags leakcheck, lists what the defers below didn't free
+	defer leak.Check()
 
 	if err := sdl.Init(sdl.INIT_EVERYTHING); err != nil {
 		fmt.Println("SDL initialization failed:", err)
@@ -66,25 +73,44 @@ func main() {
 	}
 	defer renderer.Destroy()
 
-	font, err := ttf.OpenFont(fontPath, fontSize)
+	// ./test_ttf -dev reloads the font when the file changes
+	manager := assets.New(renderer)
+	defer manager.Close()
+	if len(os.Args) > 1 && os.Args[1] == "-dev" {
+		if err := manager.Watch(); err != nil {
+			fmt.Println("Watching files failed:", err)
+		}
+	}
+	font, err := manager.Font(fontPath, fontSize)
 	if err != nil {
 		fmt.Println("Font loading failed:", err)
 		return
 	}
-	defer font.Close()
+	defer manager.Release(font)
 
+	hotkeys := input.NewHotkeys(nil)
 	running := true
 	for running {
 		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
-			switch t := event.(type) {
-			case *sdl.QuitEvent:
-				running = false
-			case *sdl.KeyboardEvent:
-				if t.Keysym.Sym == 27 {
+			for _, e := range hotkeys.HandleEvent(event) {
+				if _, ok := e.(*sdl.QuitEvent); ok {
 					running = false
 				}
 			}
 		}
+		for _, action := range hotkeys.Update(sdl.GetTicks()) {
+			if action == input.ActionQuit {
+				running = false
+			}
+		}
+
+		for _, a := range manager.Update() {
+			if a.Err != nil {
+				fmt.Println("Reload failed:", a.Err)
+			} else {
+				fmt.Println("Reloaded", a.Key)
+			}
+		}
 
 		renderer.SetDrawColor(0, 0, 0, 255)
 		renderer.Clear()
@@ -102,22 +128,23 @@ func main() {
 
 			// Draw the text with rectangle dimensions
 			text := fmt.Sprintf("[%d] %dx%d", i+1, rectWidth, rectHeight)
-			textSurface, err := font.RenderUTF8Solid(text, sdl.Color{R: 255, G: 255, B: 255, A: 255})
+			textSurface, err := leak.Surface(font.Font.RenderUTF8Solid(text, sdl.Color{R: 255, G: 255, B: 255, A: 255}))
 			if err != nil {
 				fmt.Println("Text rendering failed:", err)
 				return
 			}
-			defer textSurface.Free()
-
-			textTexture, err := renderer.CreateTextureFromSurface(textSurface)
+			// Freed right away, a defer would only run when main returns
+			textTexture, err := leak.Texture(renderer.CreateTextureFromSurface(textSurface))
+			leak.FreeSurface(textSurface)
 			if err != nil {
 				fmt.Println("Texture creation failed:", err)
 				return
 			}
-			defer textTexture.Destroy()
 
 			renderer.Copy(textTexture, nil, &sdl.Rect{X: rect.X + rect.W/2 - 50, Y: rect.Y + 5, W: 100, H: 20})
+			leak.DestroyTexture(textTexture)
 		}
+		leak.Draw(renderer, 10, 10)
 
 		renderer.Present()
 
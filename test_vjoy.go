@@ -0,0 +1,123 @@
+// Headless joystick test with SDL virtual joysticks (needs SDL 2.0.14+)
+// Plugs, presses, moves and unplugs virtual pads frame by frame and checks
+// what the input registry makes of the resulting events. No window and no
+// physical pad are needed, so it can run in CI:
+// SDL_VIDEODRIVER=dummy ./test_vjoy
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+	"go-sdl2/vjoy"
+)
+
+// check is evaluated after the events of its frame were handled
+type check struct {
+	frame int
+	desc  string
+	ok    func() bool
+}
+
+func run() int {
+	var changes []input.Change
+	var first *input.Device
+	failed := 0
+
+	if err := sdl.Init(sdl.INIT_JOYSTICK | sdl.INIT_EVENTS); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		return -1
+	}
+	defer sdl.Quit()
+	sdl.JoystickEventState(sdl.ENABLE)
+
+	registry := input.NewRegistry(input.NewProfiles())
+	defer registry.Close()
+	stick := input.NewStick(0, 1)
+
+	script := vjoy.NewScript()
+	defer script.Close()
+	script.Attach(0, 0, vjoy.Gamepad)
+	script.Tap(2, 0, 0, 2)
+	script.Axis(6, 0, 0, 32767)
+	script.Axis(7, 0, 0, 0)
+	script.Hat(8, 0, 0, sdl.HAT_UP)
+	script.Detach(10, 0)
+	script.Attach(12, 0, vjoy.Gamepad)
+	script.Attach(14, 1, vjoy.Handheld)
+	script.Button(16, 0, 6, true)
+	script.Button(16, 0, 7, true)
+	script.Detach(18, 1)
+	script.Detach(18, 0)
+
+	lastChange := func(c input.Change) func() bool {
+		return func() bool { return len(changes) > 0 && changes[len(changes)-1] == c }
+	}
+	pad := func(ok func(d *input.Device) bool) func() bool {
+		return func() bool { d := registry.First(); return d != nil && ok(d) }
+	}
+	checks := []check{
+		{1, "pad is added", lastChange(input.Added)},
+		{1, "pad has the gamepad controls", pad(func(d *input.Device) bool {
+			first = d
+			return d.NumButtons() == vjoy.Gamepad.Buttons && d.NumAxes() == vjoy.Gamepad.Axes && d.NumHats() == vjoy.Gamepad.Hats
+		})},
+		{3, "button 0 is held", pad(func(d *input.Device) bool { return d.Button(0) })},
+		{5, "button 0 is released", pad(func(d *input.Device) bool { return !d.Button(0) })},
+		{6, "stick right is a d-pad right", pad(func(d *input.Device) bool {
+			_, _, hat := stick.UpdateDevice(nil, d)
+			return hat == sdl.HAT_RIGHT
+		})},
+		{7, "centered stick releases the d-pad", pad(func(d *input.Device) bool {
+			_, _, hat := stick.UpdateDevice(nil, d)
+			return hat == sdl.HAT_CENTERED
+		})},
+		{9, "hat is up", pad(func(d *input.Device) bool { return d.Hat(0) == sdl.HAT_UP })},
+		{11, "pad is removed", lastChange(input.Removed)},
+		{11, "no pad is connected", func() bool { return registry.First() == nil }},
+		{13, "pad is reconnected", lastChange(input.Reconnected)},
+		{13, "reconnected pad is the same device", pad(func(d *input.Device) bool { return d == first })},
+		{13, "reconnected pad starts released", pad(func(d *input.Device) bool { return d.Hat(0) == sdl.HAT_CENTERED })},
+		{15, "second pad is added", lastChange(input.Added)},
+		{15, "two pads are connected", func() bool { return len(registry.Devices()) == 2 }},
+		{17, "SELECT+START exits", pad(func(d *input.Device) bool { return d.Profile.ExitPressed(d.Button) })},
+		{19, "every pad is removed", func() bool { return len(registry.Devices()) == 0 }},
+	}
+
+	for frame := 0; !script.Done() || len(checks) > 0; frame++ {
+		if err := script.Step(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to play script: %s\n", err)
+			return 1
+		}
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			if _, change := registry.HandleEvent(event); change != input.NoChange {
+				changes = append(changes, change)
+			}
+		}
+		for len(checks) > 0 && checks[0].frame <= frame {
+			c := checks[0]
+			checks = checks[1:]
+			if c.ok() {
+				fmt.Printf("ok    frame %2d: %s\n", frame, c.desc)
+			} else {
+				fmt.Printf("FAIL  frame %2d: %s\n", frame, c.desc)
+				failed++
+			}
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d checks failed\n", failed)
+		return 1
+	}
+	fmt.Println("All checks passed")
+	return 0
+}
+
+func main() {
+	os.Exit(run())
+}
@@ -0,0 +1,193 @@
+package textinput
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+
+	"go-sdl2/leak"
+)
+
+// text is a rendered string.
+type text struct {
+	tex  *sdl.Texture
+	w, h int32
+	used bool
+}
+
+type textKey struct {
+	s     string
+	color sdl.Color
+}
+
+// textCache keeps the textures of the strings drawn in the last frame, so
+// the keyboard isn't rendered again every frame.
+type textCache struct {
+	renderer *sdl.Renderer
+	texts    map[textKey]*text
+}
+
+// get returns s rendered in color, nil for an empty string or on error.
+func (c *textCache) get(renderer *sdl.Renderer, font *ttf.Font, s string, color sdl.Color) *text {
+	if s == "" {
+		return nil
+	}
+	if c.renderer != renderer {
+		c.clear()
+		c.renderer = renderer
+	}
+	if c.texts == nil {
+		c.texts = make(map[textKey]*text)
+	}
+	key := textKey{s, color}
+	if t, ok := c.texts[key]; ok {
+		t.used = true
+		return t
+	}
+	surface, err := leak.Surface(font.RenderUTF8Blended(s, color))
+	if err != nil {
+		return nil
+	}
+	defer leak.FreeSurface(surface)
+	tex, err := leak.Texture(renderer.CreateTextureFromSurface(surface))
+	if err != nil {
+		return nil
+	}
+	t := &text{tex: tex, w: surface.W, h: surface.H, used: true}
+	c.texts[key] = t
+	return t
+}
+
+// sweep destroys the textures that weren't drawn since the last sweep.
+func (c *textCache) sweep() {
+	for key, t := range c.texts {
+		if !t.used {
+			leak.DestroyTexture(t.tex)
+			delete(c.texts, key)
+		}
+		t.used = false
+	}
+}
+
+func (c *textCache) clear() {
+	for _, t := range c.texts {
+		leak.DestroyTexture(t.tex)
+	}
+	c.texts = nil
+}
+
+// width returns the width of s in pixels.
+func width(font *ttf.Font, s string) int32 {
+	if s == "" {
+		return 0
+	}
+	w, _, err := font.SizeUTF8(s)
+	if err != nil {
+		return 0
+	}
+	return int32(w)
+}
+
+var (
+	white  = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+	black  = sdl.Color{R: 0, G: 0, B: 0, A: 255}
+	yellow = sdl.Color{R: 255, G: 255, B: 0, A: 255}
+)
+
+// Draw draws the text box, and the on-screen keyboard when it is open.
+func (in *Input) Draw(renderer *sdl.Renderer) {
+	in.drawBox(renderer)
+	if in.osk {
+		in.drawKeyboard(renderer)
+	}
+	in.texts.sweep()
+}
+
+func (in *Input) drawBox(renderer *sdl.Renderer) {
+	r := in.Rect
+	renderer.SetDrawColor(30, 30, 30, 255)
+	renderer.FillRect(&r)
+	renderer.SetDrawColor(200, 200, 200, 255)
+	renderer.DrawRect(&r)
+
+	// The composition is shown at the cursor until the IME commits it
+	before := in.Text[:in.Cursor] + in.composition
+	line := before + in.Text[in.Cursor:]
+	caretText := in.Text[:in.Cursor]
+	if in.composition != "" {
+		comp := []rune(in.composition)
+		if in.compCursor >= 0 && in.compCursor <= len(comp) {
+			caretText += string(comp[:in.compCursor])
+		} else {
+			caretText = before
+		}
+	}
+	caret := width(in.Font, caretText)
+
+	// Scroll so that the caret stays in the box
+	pad := int32(4)
+	scroll := int32(0)
+	if caret > r.W-2*pad {
+		scroll = caret - (r.W - 2*pad)
+	}
+	x := r.X + pad - scroll
+	h := int32(in.Font.Height())
+	y := r.Y + (r.H-h)/2
+
+	clip := sdl.Rect{X: r.X + 1, Y: r.Y + 1, W: r.W - 2, H: r.H - 2}
+	renderer.SetClipRect(&clip)
+	if t := in.texts.get(renderer, in.Font, line, white); t != nil {
+		renderer.Copy(t.tex, nil, &sdl.Rect{X: x, Y: y, W: t.w, H: t.h})
+	}
+	if in.composition != "" {
+		start := x + width(in.Font, in.Text[:in.Cursor])
+		end := x + width(in.Font, before)
+		renderer.SetDrawColor(255, 255, 0, 255)
+		renderer.DrawLine(start, y+h, end, y+h)
+	}
+	if sdl.GetTicks()/500%2 == 0 {
+		renderer.SetDrawColor(255, 255, 255, 255)
+		renderer.DrawLine(x+caret, y, x+caret, y+h)
+	}
+	renderer.SetClipRect(nil)
+}
+
+func (in *Input) drawKeyboard(renderer *sdl.Renderer) {
+	k := in.Keyboard
+	renderer.SetDrawColor(20, 20, 40, 255)
+	renderer.FillRect(&k)
+	layout := in.Layout()
+	rows := in.rows()
+	rowH := k.H / int32(len(rows))
+	for i, row := range rows {
+		keyW := k.W / int32(len(row))
+		for j, key := range row {
+			cell := sdl.Rect{X: k.X + int32(j)*keyW + 2, Y: k.Y + int32(i)*rowH + 2, W: keyW - 4, H: rowH - 4}
+			color := white
+			switch {
+			case i == in.row && j == in.col:
+				renderer.SetDrawColor(0, 200, 0, 255)
+				renderer.FillRect(&cell)
+				color = black
+			case key == KeyShift && in.shift:
+				renderer.SetDrawColor(80, 80, 0, 255)
+				renderer.FillRect(&cell)
+			default:
+				renderer.SetDrawColor(60, 60, 60, 255)
+				renderer.FillRect(&cell)
+			}
+			label := layout.Label(key)
+			if key == KeyLayout {
+				label = in.Layouts[(in.layout+1)%len(in.Layouts)].Name
+			}
+			if key == KeyShift && in.shift && color == white {
+				color = yellow
+			}
+			if t := in.texts.get(renderer, in.Font, label, color); t != nil {
+				renderer.Copy(t.tex, nil, &sdl.Rect{X: cell.X + (cell.W-t.w)/2, Y: cell.Y + (cell.H-t.h)/2, W: t.w, H: t.h})
+			}
+		}
+	}
+	if t := in.texts.get(renderer, in.Font, layout.Name, yellow); t != nil {
+		renderer.Copy(t.tex, nil, &sdl.Rect{X: k.X + k.W - t.w - 4, Y: k.Y - t.h - 2, W: t.w, H: t.h})
+	}
+}
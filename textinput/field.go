@@ -0,0 +1,96 @@
+// Package textinput edits a line of text with a keyboard, including IME
+// composition, or with an on-screen keyboard driven by a gamepad.
+package textinput
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Field is a line of UTF-8 text with a cursor. The cursor is a byte offset
+// that always sits between two runes.
+type Field struct {
+	Text   string
+	Cursor int
+	Max    int // maximum length in runes, 0 for no limit
+}
+
+// SetText replaces the text and puts the cursor at the end.
+func (f *Field) SetText(s string) {
+	f.Text = ""
+	f.Cursor = 0
+	f.Insert(s)
+}
+
+// Len returns the length of the text in runes.
+func (f *Field) Len() int {
+	return utf8.RuneCountInString(f.Text)
+}
+
+// Insert adds s at the cursor. Invalid UTF-8 and control characters are
+// dropped, and s is cut to fit Max.
+func (f *Field) Insert(s string) {
+	s = strings.Map(func(r rune) rune {
+		if r == utf8.RuneError || unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(s, ""))
+	if f.Max > 0 {
+		room := f.Max - f.Len()
+		if room <= 0 {
+			return
+		}
+		if utf8.RuneCountInString(s) > room {
+			s = string([]rune(s)[:room])
+		}
+	}
+	f.Text = f.Text[:f.Cursor] + s + f.Text[f.Cursor:]
+	f.Cursor += len(s)
+}
+
+// Backspace removes the rune before the cursor.
+func (f *Field) Backspace() {
+	if f.Cursor == 0 {
+		return
+	}
+	_, n := utf8.DecodeLastRuneInString(f.Text[:f.Cursor])
+	f.Text = f.Text[:f.Cursor-n] + f.Text[f.Cursor:]
+	f.Cursor -= n
+}
+
+// Delete removes the rune after the cursor.
+func (f *Field) Delete() {
+	if f.Cursor == len(f.Text) {
+		return
+	}
+	_, n := utf8.DecodeRuneInString(f.Text[f.Cursor:])
+	f.Text = f.Text[:f.Cursor] + f.Text[f.Cursor+n:]
+}
+
+// Left moves the cursor one rune back.
+func (f *Field) Left() {
+	if f.Cursor > 0 {
+		_, n := utf8.DecodeLastRuneInString(f.Text[:f.Cursor])
+		f.Cursor -= n
+	}
+}
+
+// Right moves the cursor one rune forward.
+func (f *Field) Right() {
+	if f.Cursor < len(f.Text) {
+		_, n := utf8.DecodeRuneInString(f.Text[f.Cursor:])
+		f.Cursor += n
+	}
+}
+
+// Home moves the cursor to the start.
+func (f *Field) Home() {
+	f.Cursor = 0
+}
+
+// End moves the cursor to the end.
+func (f *Field) End() {
+	f.Cursor = len(f.Text)
+}
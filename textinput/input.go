@@ -0,0 +1,254 @@
+package textinput
+
+import (
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+
+	"go-sdl2/input"
+)
+
+// Result tells whether the user is still typing.
+type Result int
+
+const (
+	Editing  Result = iota
+	Done            // Enter, OK on the on-screen keyboard or START
+	Canceled        // Escape
+)
+
+// Input is a text box. Text comes from SDL text input, with IME
+// composition, as long as the user types. Pressing the d-pad or a button
+// of a joystick opens an on-screen keyboard instead:
+// d-pad moves, A types, B deletes, X shift, Y space, L1/R1 move the
+// cursor, SELECT switches the layout and START is done.
+type Input struct {
+	Field
+	Font     *ttf.Font
+	Rect     sdl.Rect // text box
+	Keyboard sdl.Rect // area of the on-screen keyboard
+	Layouts  []*Layout
+	Registry *input.Registry // names the joystick buttons, Generic layout when nil
+	Result   Result
+
+	osk         bool
+	layout      int
+	row, col    int
+	shift       bool
+	composition string // IME text not committed yet
+	compCursor  int    // cursor inside the composition, in runes
+	hats        map[sdl.JoystickID]uint8
+	texts       textCache
+}
+
+// New returns a text box at rect with the Latin and Indonesian on-screen
+// keyboards drawn in keyboard.
+func New(font *ttf.Font, rect, keyboard sdl.Rect) *Input {
+	return &Input{
+		Font:     font,
+		Rect:     rect,
+		Keyboard: keyboard,
+		Layouts:  []*Layout{Latin(), Indonesian()},
+		hats:     make(map[sdl.JoystickID]uint8),
+	}
+}
+
+// Start begins editing and turns on SDL text input, which also places the
+// IME candidate window next to the box.
+func (in *Input) Start() {
+	in.Result = Editing
+	sdl.SetTextInputRect(&in.Rect)
+	sdl.StartTextInput()
+}
+
+// Stop turns off SDL text input and drops an unfinished composition.
+func (in *Input) Stop() {
+	sdl.StopTextInput()
+	in.composition = ""
+}
+
+// ShowKeyboard opens or closes the on-screen keyboard.
+func (in *Input) ShowKeyboard(show bool) {
+	in.osk = show
+}
+
+// KeyboardShown reports whether the on-screen keyboard is open.
+func (in *Input) KeyboardShown() bool {
+	return in.osk
+}
+
+// Layout returns the on-screen keyboard layout in use.
+func (in *Input) Layout() *Layout {
+	return in.Layouts[in.layout]
+}
+
+// Composition returns the text the IME is composing.
+func (in *Input) Composition() string {
+	return in.composition
+}
+
+// HandleEvent edits the text. It reports whether the event was used.
+func (in *Input) HandleEvent(event sdl.Event) bool {
+	if in.Result != Editing {
+		return false
+	}
+	switch t := event.(type) {
+	case *sdl.TextInputEvent:
+		in.Insert(t.GetText())
+		in.composition = ""
+		in.osk = false
+		return true
+	case *sdl.TextEditingEvent:
+		in.composition = t.GetText()
+		in.compCursor = int(t.Start)
+		return true
+	case *sdl.KeyboardEvent:
+		if t.State != sdl.PRESSED {
+			return false
+		}
+		if in.composition != "" {
+			// The IME is using the keys
+			return true
+		}
+		return in.key(t.Keysym.Sym)
+	case *sdl.JoyHatEvent:
+		prev := in.hats[t.Which]
+		in.hats[t.Which] = t.Value
+		pressed := t.Value &^ prev
+		if pressed == 0 {
+			return true
+		}
+		in.osk = true
+		if pressed&sdl.HAT_UP != 0 {
+			in.move(-1, 0)
+		}
+		if pressed&sdl.HAT_DOWN != 0 {
+			in.move(1, 0)
+		}
+		if pressed&sdl.HAT_LEFT != 0 {
+			in.move(0, -1)
+		}
+		if pressed&sdl.HAT_RIGHT != 0 {
+			in.move(0, 1)
+		}
+		return true
+	case *sdl.JoyButtonEvent:
+		if t.State != sdl.PRESSED {
+			return true
+		}
+		profile := input.GenericProfile()
+		if in.Registry != nil {
+			if d := in.Registry.Device(t.Which); d != nil {
+				profile = d.Profile
+			}
+		}
+		opened := !in.osk
+		in.osk = true
+		switch profile.ButtonName(int(t.Button)) {
+		case "UP":
+			in.move(-1, 0)
+		case "DOWN":
+			in.move(1, 0)
+		case "LEFT":
+			in.move(0, -1)
+		case "RIGHT":
+			in.move(0, 1)
+		case "A":
+			if !opened {
+				in.press()
+			}
+		case "B":
+			in.Backspace()
+		case "X":
+			in.shift = !in.shift
+		case "Y":
+			in.Insert(" ")
+		case "L1":
+			in.Left()
+		case "R1":
+			in.Right()
+		case "SELECT":
+			in.nextLayout()
+		case "START":
+			in.Result = Done
+		}
+		return true
+	}
+	return false
+}
+
+// key handles the editing keys of a keyboard.
+func (in *Input) key(sym sdl.Keycode) bool {
+	switch sym {
+	case sdl.K_BACKSPACE:
+		in.Backspace()
+	case sdl.K_DELETE:
+		in.Delete()
+	case sdl.K_LEFT:
+		in.Left()
+	case sdl.K_RIGHT:
+		in.Right()
+	case sdl.K_HOME:
+		in.Home()
+	case sdl.K_END:
+		in.End()
+	case sdl.K_RETURN, sdl.K_KP_ENTER:
+		in.Result = Done
+	case sdl.K_ESCAPE:
+		in.Result = Canceled
+	default:
+		return false
+	}
+	in.osk = false
+	return true
+}
+
+func (in *Input) rows() [][]string {
+	if in.shift {
+		return in.Layout().Shift
+	}
+	return in.Layout().Rows
+}
+
+// move changes the selected key. Going up or down keeps the key that is
+// closest horizontally, as rows may have different lengths.
+func (in *Input) move(dr, dc int) {
+	rows := in.rows()
+	if dr != 0 {
+		from := len(rows[in.row])
+		in.row = (in.row + dr + len(rows)) % len(rows)
+		to := len(rows[in.row])
+		in.col = (2*in.col + 1) * to / (2 * from)
+	}
+	n := len(rows[in.row])
+	in.col = (in.col + dc + n) % n
+}
+
+func (in *Input) nextLayout() {
+	in.layout = (in.layout + 1) % len(in.Layouts)
+	in.row, in.col = 0, 0
+}
+
+// press types the selected key of the on-screen keyboard.
+func (in *Input) press() {
+	key := in.rows()[in.row][in.col]
+	switch key {
+	case KeyShift:
+		in.shift = !in.shift
+	case KeyLayout:
+		in.nextLayout()
+	case KeySpace:
+		in.Insert(" ")
+	case KeyBackspace:
+		in.Backspace()
+	case KeyEnter:
+		in.Result = Done
+	default:
+		in.Insert(key)
+		in.shift = false
+	}
+}
+
+// Close frees the rendered text.
+func (in *Input) Close() {
+	in.texts.clear()
+}
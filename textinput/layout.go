@@ -0,0 +1,76 @@
+package textinput
+
+// Special keys of an on-screen keyboard layout. Every other key types its
+// text, which may be more than one rune.
+const (
+	KeyShift     = "{shift}"
+	KeyBackspace = "{backspace}"
+	KeySpace     = "{space}"
+	KeyEnter     = "{enter}"
+	KeyLayout    = "{layout}" // switch to the next layout
+)
+
+// Layout is an on-screen keyboard. Rows and Shift have the same shape.
+type Layout struct {
+	Name   string
+	Rows   [][]string
+	Shift  [][]string
+	Labels map[string]string // shown on the special keys
+}
+
+// Label returns what is drawn on key.
+func (l *Layout) Label(key string) string {
+	if label, ok := l.Labels[key]; ok {
+		return label
+	}
+	return key
+}
+
+// keys splits a row of single-rune keys.
+func keys(row string) []string {
+	var list []string
+	for _, r := range row {
+		list = append(list, string(r))
+	}
+	return list
+}
+
+var specialRow = []string{KeyShift, KeyLayout, KeySpace, KeyBackspace, KeyEnter}
+
+// Latin is a QWERTY layout with the accented letters of western European
+// names.
+func Latin() *Layout {
+	return &Layout{
+		Name: "Latin",
+		Rows: [][]string{
+			keys("1234567890"), keys("qwertyuiop"), keys("asdfghjkl'"), keys("zxcvbnm,.-"),
+			keys("éèêëàâçñöü"), specialRow,
+		},
+		Shift: [][]string{
+			keys("!@#$%&*()?"), keys("QWERTYUIOP"), keys("ASDFGHJKL\""), keys("ZXCVBNM;:_"),
+			keys("ÉÈÊËÀÂÇÑÖÜ"), specialRow,
+		},
+		Labels: map[string]string{
+			KeyShift: "Shift", KeyLayout: "ABC", KeySpace: "Space", KeyBackspace: "Del", KeyEnter: "OK",
+		},
+	}
+}
+
+// Indonesian is QWERTY like the keyboards sold in Indonesia, with Indonesian
+// labels and a Rupiah key.
+func Indonesian() *Layout {
+	return &Layout{
+		Name: "Indonesia",
+		Rows: [][]string{
+			keys("1234567890"), keys("qwertyuiop"), keys("asdfghjkl-"), append(keys("zxcvbnm,."), "Rp"),
+			specialRow,
+		},
+		Shift: [][]string{
+			keys("!@#$%&*()?"), keys("QWERTYUIOP"), keys("ASDFGHJKL_"), append(keys("ZXCVBNM;:"), "Rp"),
+			specialRow,
+		},
+		Labels: map[string]string{
+			KeyShift: "Kapital", KeyLayout: "ABC", KeySpace: "Spasi", KeyBackspace: "Hapus", KeyEnter: "Selesai",
+		},
+	}
+}
@@ -0,0 +1,154 @@
+package vjoy
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Script plugs, drives and unplugs virtual pads frame by frame. Pads are
+// addressed by slot, so a slot can be unplugged and plugged in again.
+//
+//	s := vjoy.NewScript()
+//	s.Attach(0, 0, vjoy.Gamepad)
+//	s.Button(10, 0, 6, true) // SELECT
+//	s.Button(10, 0, 7, true) // START
+//	s.Detach(20, 0)
+//	for !s.Done() {
+//		if err := s.Step(); err != nil { ... }
+//		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() { ... }
+//	}
+type Script struct {
+	Frame int // next frame to play
+
+	actions []action
+	pos     int
+	pads    map[int]*Pad
+}
+
+type action struct {
+	frame int
+	slot  int
+	desc  string
+	do    func(s *Script) error
+}
+
+// NewScript returns an empty script starting at frame 0.
+func NewScript() *Script {
+	return &Script{pads: make(map[int]*Pad)}
+}
+
+// add inserts an action after every action of the same frame.
+func (s *Script) add(frame, slot int, desc string, do func(s *Script) error) {
+	i := sort.Search(len(s.actions), func(i int) bool { return s.actions[i].frame > frame })
+	s.actions = append(s.actions, action{})
+	copy(s.actions[i+1:], s.actions[i:])
+	s.actions[i] = action{frame: frame, slot: slot, desc: desc, do: do}
+}
+
+// pad returns the attached pad of a slot.
+func (s *Script) pad(slot int) (*Pad, error) {
+	if p := s.pads[slot]; p != nil && p.Attached() {
+		return p, nil
+	}
+	return nil, fmt.Errorf("no pad attached in slot %d", slot)
+}
+
+// Attach plugs a pad with spec into slot at frame.
+func (s *Script) Attach(frame, slot int, spec Spec) {
+	s.add(frame, slot, "attach", func(s *Script) error {
+		if p := s.pads[slot]; p != nil && p.Attached() {
+			return fmt.Errorf("slot %d already has a pad", slot)
+		}
+		p, err := Attach(spec)
+		if err != nil {
+			return err
+		}
+		s.pads[slot] = p
+		return nil
+	})
+}
+
+// Detach unplugs the pad of slot at frame.
+func (s *Script) Detach(frame, slot int) {
+	s.add(frame, slot, "detach", func(s *Script) error {
+		p, err := s.pad(slot)
+		if err != nil {
+			return err
+		}
+		return p.Detach()
+	})
+}
+
+// Button presses or releases a button of the pad in slot at frame.
+func (s *Script) Button(frame, slot, button int, pressed bool) {
+	s.add(frame, slot, fmt.Sprintf("button %d", button), func(s *Script) error {
+		p, err := s.pad(slot)
+		if err != nil {
+			return err
+		}
+		return p.SetButton(button, pressed)
+	})
+}
+
+// Tap presses a button at frame and releases it frames later.
+func (s *Script) Tap(frame, slot, button, frames int) {
+	s.Button(frame, slot, button, true)
+	s.Button(frame+frames, slot, button, false)
+}
+
+// Axis moves an axis of the pad in slot at frame.
+func (s *Script) Axis(frame, slot, axis int, value int16) {
+	s.add(frame, slot, fmt.Sprintf("axis %d", axis), func(s *Script) error {
+		p, err := s.pad(slot)
+		if err != nil {
+			return err
+		}
+		return p.SetAxis(axis, value)
+	})
+}
+
+// Hat moves a hat of the pad in slot at frame.
+func (s *Script) Hat(frame, slot, hat int, value uint8) {
+	s.add(frame, slot, fmt.Sprintf("hat %d", hat), func(s *Script) error {
+		p, err := s.pad(slot)
+		if err != nil {
+			return err
+		}
+		return p.SetHat(hat, value)
+	})
+}
+
+// Pad returns the pad currently in slot, or nil.
+func (s *Script) Pad(slot int) *Pad {
+	if p := s.pads[slot]; p != nil && p.Attached() {
+		return p
+	}
+	return nil
+}
+
+// Done reports whether every action was played.
+func (s *Script) Done() bool {
+	return s.pos >= len(s.actions)
+}
+
+// Step plays the actions of the current frame and advances to the next.
+// Call it once per frame before polling events. It stops at the first
+// failing action; the rest of the frame is played on the next Step.
+func (s *Script) Step() error {
+	for ; s.pos < len(s.actions) && s.actions[s.pos].frame <= s.Frame; s.pos++ {
+		a := s.actions[s.pos]
+		if err := a.do(s); err != nil {
+			s.pos++
+			return fmt.Errorf("frame %d: slot %d: %s: %v", a.frame, a.slot, a.desc, err)
+		}
+	}
+	s.Frame++
+	return nil
+}
+
+// Close detaches every pad still attached.
+func (s *Script) Close() {
+	for _, p := range s.pads {
+		p.Detach()
+	}
+}
@@ -0,0 +1,181 @@
+// Package vjoy drives SDL virtual joysticks, so that joystick handling can
+// be exercised without a physical pad, e.g. in CI with SDL_VIDEODRIVER=dummy.
+//
+// go-sdl2 v0.4 doesn't wrap the virtual joystick API, so it is called here
+// directly. It needs SDL 2.0.14 or newer; with an older SDL, Supported is
+// false and Attach fails with ErrUnsupported.
+package vjoy
+
+/*
+#cgo windows LDFLAGS: -lSDL2
+#cgo linux freebsd darwin openbsd pkg-config: sdl2
+#include "SDL.h"
+
+static int vjoy_built_with_virtual(void)
+{
+	return SDL_VERSION_ATLEAST(2,0,14);
+}
+
+#if !SDL_VERSION_ATLEAST(2,0,14)
+static int SDL_JoystickAttachVirtual(SDL_JoystickType type, int naxes, int nbuttons, int nhats)
+{
+	return SDL_SetError("virtual joysticks need SDL 2.0.14");
+}
+
+static int SDL_JoystickDetachVirtual(int device_index)
+{
+	return SDL_SetError("virtual joysticks need SDL 2.0.14");
+}
+
+static int SDL_JoystickSetVirtualAxis(SDL_Joystick *joystick, int axis, Sint16 value)
+{
+	return SDL_SetError("virtual joysticks need SDL 2.0.14");
+}
+
+static int SDL_JoystickSetVirtualButton(SDL_Joystick *joystick, int button, Uint8 value)
+{
+	return SDL_SetError("virtual joysticks need SDL 2.0.14");
+}
+
+static int SDL_JoystickSetVirtualHat(SDL_Joystick *joystick, int hat, Uint8 value)
+{
+	return SDL_SetError("virtual joysticks need SDL 2.0.14");
+}
+#endif
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// ErrUnsupported is returned by Attach when SDL has no virtual joysticks.
+var ErrUnsupported = errors.New("virtual joysticks need SDL 2.0.14")
+
+// Supported reports whether virtual joysticks can be attached: the SDL
+// headers the package was built with and the SDL library it runs with both
+// have to be 2.0.14 or newer.
+func Supported() bool {
+	if C.vjoy_built_with_virtual() == 0 {
+		return false
+	}
+	var v sdl.Version
+	sdl.GetVersion(&v)
+	return sdl.VERSIONNUM(int(v.Major), int(v.Minor), int(v.Patch)) >= sdl.VERSIONNUM(2, 0, 14)
+}
+
+// errorFromInt turns the result of an SDL call into an error.
+func errorFromInt(ret C.int) error {
+	if ret >= 0 {
+		return nil
+	}
+	if err := sdl.GetError(); err != nil {
+		return err
+	}
+	return errors.New("unknown SDL error")
+}
+
+func cJoystick(joy *sdl.Joystick) *C.SDL_Joystick {
+	return (*C.SDL_Joystick)(unsafe.Pointer(joy))
+}
+
+// Spec describes the controls of a virtual joystick.
+type Spec struct {
+	Type    sdl.JoystickType
+	Axes    int
+	Buttons int
+	Hats    int
+}
+
+// Gamepad has the controls of an XInput pad: LX LY L2 RX RY R2, 11 buttons
+// and a d-pad hat.
+var Gamepad = Spec{Type: sdl.JOYSTICK_TYPE_GAMECONTROLLER, Axes: 6, Buttons: 11, Hats: 1}
+
+// Handheld has the controls of an RG353P: 4 axes, 17 buttons, no hat.
+var Handheld = Spec{Type: sdl.JOYSTICK_TYPE_GAMECONTROLLER, Axes: 4, Buttons: 17}
+
+// Pad is an attached virtual joystick. It keeps a joystick handle open,
+// which SDL requires to set the state of the controls.
+type Pad struct {
+	Spec
+	joy *sdl.Joystick
+	id  sdl.JoystickID
+}
+
+// Attach plugs in a new virtual joystick. Applications see it as a
+// JoyDeviceAddedEvent on their next PollEvent.
+func Attach(spec Spec) (*Pad, error) {
+	if !Supported() {
+		return nil, ErrUnsupported
+	}
+	index := C.SDL_JoystickAttachVirtual(C.SDL_JoystickType(spec.Type), C.int(spec.Axes), C.int(spec.Buttons), C.int(spec.Hats))
+	if err := errorFromInt(index); err != nil {
+		return nil, err
+	}
+	joy := sdl.JoystickOpen(int(index))
+	if joy == nil {
+		err := sdl.GetError()
+		C.SDL_JoystickDetachVirtual(index)
+		return nil, fmt.Errorf("open virtual joystick %d: %v", index, err)
+	}
+	return &Pad{Spec: spec, joy: joy, id: joy.InstanceID()}, nil
+}
+
+// ID returns the instance ID applications see in the joystick events.
+func (p *Pad) ID() sdl.JoystickID {
+	return p.id
+}
+
+// Attached reports whether the pad hasn't been detached yet.
+func (p *Pad) Attached() bool {
+	return p.joy != nil
+}
+
+// SetButton presses or releases a button. Like every state change, it is
+// reported on the next PollEvent.
+func (p *Pad) SetButton(button int, pressed bool) error {
+	if p.joy == nil {
+		return fmt.Errorf("virtual joystick %d is detached", p.id)
+	}
+	value := sdl.RELEASED
+	if pressed {
+		value = sdl.PRESSED
+	}
+	return errorFromInt(C.SDL_JoystickSetVirtualButton(cJoystick(p.joy), C.int(button), C.Uint8(value)))
+}
+
+// SetAxis moves an axis.
+func (p *Pad) SetAxis(axis int, value int16) error {
+	if p.joy == nil {
+		return fmt.Errorf("virtual joystick %d is detached", p.id)
+	}
+	return errorFromInt(C.SDL_JoystickSetVirtualAxis(cJoystick(p.joy), C.int(axis), C.Sint16(value)))
+}
+
+// SetHat moves a hat, value is a combination of the sdl.HAT_* bits.
+func (p *Pad) SetHat(hat int, value uint8) error {
+	if p.joy == nil {
+		return fmt.Errorf("virtual joystick %d is detached", p.id)
+	}
+	return errorFromInt(C.SDL_JoystickSetVirtualHat(cJoystick(p.joy), C.int(hat), C.Uint8(value)))
+}
+
+// Detach unplugs the pad. Applications see a JoyDeviceRemovedEvent.
+func (p *Pad) Detach() error {
+	if p.joy == nil {
+		return nil
+	}
+	p.joy.Close()
+	p.joy = nil
+	// Device indices shift when joysticks come and go, look the pad up again
+	for i := 0; i < sdl.NumJoysticks(); i++ {
+		if sdl.JoystickGetDeviceInstanceID(i) == p.id {
+			return errorFromInt(C.SDL_JoystickDetachVirtual(C.int(i)))
+		}
+	}
+	return fmt.Errorf("virtual joystick %d not found", p.id)
+}
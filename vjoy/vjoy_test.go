@@ -0,0 +1,229 @@
+package vjoy_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+	"go-sdl2/vjoy"
+)
+
+func TestMain(m *testing.M) {
+	// No window is opened, but CI machines have no display either
+	os.Setenv("SDL_VIDEODRIVER", "dummy")
+	if err := sdl.Init(sdl.INIT_JOYSTICK | sdl.INIT_EVENTS); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to init SDL: %s\n", err)
+		os.Exit(1)
+	}
+	sdl.JoystickEventState(sdl.ENABLE)
+	code := m.Run()
+	sdl.Quit()
+	os.Exit(code)
+}
+
+// harness plays a script and feeds the resulting events to a registry and
+// a state, one frame per Step.
+type harness struct {
+	t        *testing.T
+	script   *vjoy.Script
+	registry *input.Registry
+	state    *input.State
+	changes  []input.Change
+}
+
+func newHarness(t *testing.T) *harness {
+	if !vjoy.Supported() {
+		t.Skip("virtual joysticks need SDL 2.0.14")
+	}
+	// Events left over from another test
+	for sdl.PollEvent() != nil {
+	}
+	h := &harness{
+		t:        t,
+		script:   vjoy.NewScript(),
+		registry: input.NewRegistry(input.NewProfiles()),
+		state:    input.NewState(8),
+	}
+	t.Cleanup(func() {
+		h.script.Close()
+		for sdl.PollEvent() != nil {
+		}
+		h.registry.Close()
+	})
+	return h
+}
+
+// step plays one frame of the script and handles its events.
+func (h *harness) step() {
+	h.t.Helper()
+	h.state.NextFrame()
+	if err := h.script.Step(); err != nil {
+		h.t.Fatal(err)
+	}
+	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+		if _, change := h.registry.HandleEvent(event); change != input.NoChange {
+			h.changes = append(h.changes, change)
+		}
+		h.state.HandleEvent(event)
+	}
+}
+
+// until plays the script up to and including frame.
+func (h *harness) until(frame int) {
+	h.t.Helper()
+	for h.script.Frame <= frame {
+		h.step()
+	}
+}
+
+func (h *harness) lastChange() input.Change {
+	if len(h.changes) == 0 {
+		return input.NoChange
+	}
+	return h.changes[len(h.changes)-1]
+}
+
+func TestAttachDetach(t *testing.T) {
+	h := newHarness(t)
+	h.script.Attach(0, 0, vjoy.Gamepad)
+	h.script.Detach(2, 0)
+	h.script.Attach(4, 0, vjoy.Gamepad)
+	// Device indices of queued JOYDEVICEADDED events go stale when pads
+	// are attached together, one per frame keeps them apart
+	h.script.Attach(5, 1, vjoy.Handheld)
+	h.script.Detach(7, 1)
+
+	h.until(0)
+	d := h.registry.First()
+	if h.lastChange() != input.Added || d == nil {
+		t.Fatalf("pad not added: changes %v", h.changes)
+	}
+	if d.NumButtons() != vjoy.Gamepad.Buttons || d.NumAxes() != vjoy.Gamepad.Axes || d.NumHats() != vjoy.Gamepad.Hats {
+		t.Errorf("pad has %d buttons, %d axes, %d hats", d.NumButtons(), d.NumAxes(), d.NumHats())
+	}
+	if d.ID != h.script.Pad(0).ID() {
+		t.Errorf("registry ID %d, pad ID %d", d.ID, h.script.Pad(0).ID())
+	}
+
+	h.until(2)
+	if h.lastChange() != input.Removed || h.registry.First() != nil {
+		t.Errorf("pad not removed: changes %v", h.changes)
+	}
+
+	h.until(5)
+	if got := h.registry.Devices(); len(got) != 2 || got[0] != d {
+		t.Errorf("after reconnecting: %d devices, first is the old device: %v", len(got), len(got) > 0 && got[0] == d)
+	}
+	if len(h.changes) != 4 || h.changes[2] != input.Reconnected || h.changes[3] != input.Added {
+		t.Fatalf("changes %v, want the first pad reconnected and the second added", h.changes)
+	}
+
+	h.until(7)
+	if got := h.registry.Devices(); len(got) != 1 || got[0] != d {
+		t.Errorf("after removing the handheld: %d devices", len(got))
+	}
+}
+
+func TestButtonsAndState(t *testing.T) {
+	h := newHarness(t)
+	a := input.JoyButton(0)
+	h.script.Attach(0, 0, vjoy.Gamepad)
+	h.script.Button(2, 0, 0, true)
+	h.script.Button(5, 0, 0, false)
+
+	h.until(1)
+	if h.state.Held(a) || h.registry.First().Button(0) {
+		t.Error("button held before the press")
+	}
+	h.until(2)
+	if !h.state.JustPressed(a) || !h.registry.First().Button(0) {
+		t.Error("press not seen")
+	}
+	h.until(4)
+	if h.state.JustPressed(a) || h.state.HeldFrames(a) != 3 {
+		t.Errorf("held for %d frames, want 3", h.state.HeldFrames(a))
+	}
+	h.until(5)
+	if !h.state.JustReleased(a) || h.state.Held(a) || h.registry.First().Button(0) {
+		t.Error("release not seen")
+	}
+	if h.state.TotalPresses(a) != 1 {
+		t.Errorf("%d presses, want 1", h.state.TotalPresses(a))
+	}
+}
+
+func TestAxesAndHats(t *testing.T) {
+	h := newHarness(t)
+	stick := input.NewStick(0, 1)
+	h.script.Attach(0, 0, vjoy.Gamepad)
+	h.script.Axis(1, 0, 0, 32767)
+	h.script.Axis(2, 0, 1, -32768)
+	h.script.Axis(3, 0, 0, 0)
+	h.script.Axis(3, 0, 1, 0)
+	h.script.Hat(4, 0, 0, sdl.HAT_UP|sdl.HAT_LEFT)
+	h.script.Hat(5, 0, 0, sdl.HAT_CENTERED)
+
+	hats := []uint8{sdl.HAT_CENTERED, sdl.HAT_RIGHT, sdl.HAT_RIGHT | sdl.HAT_UP, sdl.HAT_CENTERED}
+	for frame, want := range hats {
+		h.until(frame)
+		if _, _, hat := stick.UpdateDevice(nil, h.registry.First()); hat != want {
+			t.Errorf("frame %d: stick as d-pad %#x, want %#x", frame, hat, want)
+		}
+	}
+	if got := h.state.AxisAt(0, 2); got != 32767 {
+		t.Errorf("axis 0 two frames ago: %d, want 32767", got)
+	}
+
+	h.until(4)
+	if got := h.registry.First().Hat(0); got != sdl.HAT_UP|sdl.HAT_LEFT {
+		t.Errorf("registry hat %#x", got)
+	}
+	if !h.state.JustPressed(input.JoyHat(0, sdl.HAT_UP)) || !h.state.Held(input.JoyHat(0, sdl.HAT_LEFT)) {
+		t.Error("hat directions not pressed in the state")
+	}
+	h.until(5)
+	if h.state.Hat(0) != sdl.HAT_CENTERED || !h.state.JustReleased(input.JoyHat(0, sdl.HAT_UP)) {
+		t.Errorf("hat %#x after centering", h.state.Hat(0))
+	}
+}
+
+func TestUnplugReleases(t *testing.T) {
+	h := newHarness(t)
+	h.script.Attach(0, 0, vjoy.Gamepad)
+	h.script.Button(1, 0, 6, true)
+	h.script.Button(1, 0, 7, true)
+	h.script.Detach(3, 0)
+
+	h.until(1)
+	d := h.registry.First()
+	if !d.Profile.ExitPressed(d.Button) {
+		t.Error("SELECT+START not seen as the exit chord")
+	}
+	h.until(3)
+	if d.Connected || d.Button(6) || d.Button(7) {
+		t.Error("removed pad still holds its buttons")
+	}
+	if h.state.Held(input.JoyButton(6)) || h.state.Held(input.JoyButton(7)) {
+		t.Error("state still holds the buttons of a removed pad")
+	}
+}
+
+func TestScriptErrors(t *testing.T) {
+	h := newHarness(t)
+	h.script.Button(0, 3, 0, true)
+	h.script.Attach(1, 0, vjoy.Gamepad)
+	h.script.Attach(1, 0, vjoy.Gamepad)
+	if err := h.script.Step(); err == nil {
+		t.Error("button of an empty slot played")
+	}
+	h.script.Step() // the rest of frame 0
+	if err := h.script.Step(); err == nil {
+		t.Error("second pad attached to a used slot")
+	}
+	if !h.script.Done() {
+		t.Error("script not done")
+	}
+}
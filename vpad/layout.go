@@ -0,0 +1,83 @@
+// Package vpad draws a gamepad on a touch screen and turns the fingers on
+// it into the joystick events of a "Touch Gamepad" device, so the game
+// handles it like a physical pad.
+package vpad
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// Kind is the type of an on-screen control.
+type Kind string
+
+const (
+	Button Kind = "button"
+	DPad   Kind = "dpad"
+	Stick  Kind = "stick"
+)
+
+// Control is one element of the overlay. Positions are fractions of the
+// screen so a layout fits any resolution.
+type Control struct {
+	Kind   Kind    `json:"kind"`
+	Name   string  `json:"name"`   // button name of the profile, "DPAD", or "L" / "R" for the LX LY / RX RY sticks
+	Label  string  `json:"label"`  // drawn on buttons, Name when empty
+	X      float32 `json:"x"`      // center, 0 left to 1 right
+	Y      float32 `json:"y"`      // center, 0 top to 1 bottom
+	Radius float32 `json:"radius"` // fraction of the screen height
+}
+
+// Layout is the set of controls with their common look.
+type Layout struct {
+	Controls []Control `json:"controls"`
+	Scale    float32   `json:"scale"`   // size of every control, 1 as laid out
+	Opacity  uint8     `json:"opacity"` // alpha of released controls
+}
+
+// DefaultLayout puts the d-pad and the stick on the left, the face buttons
+// on the right, shoulders in the top corners and SELECT START in the middle.
+func DefaultLayout() *Layout {
+	return &Layout{
+		Scale:   1,
+		Opacity: 96,
+		Controls: []Control{
+			{Kind: DPad, Name: "DPAD", X: 0.15, Y: 0.72, Radius: 0.16},
+			{Kind: Stick, Name: "L", X: 0.33, Y: 0.82, Radius: 0.11},
+			{Kind: Button, Name: "A", X: 0.90, Y: 0.78, Radius: 0.065},
+			{Kind: Button, Name: "B", X: 0.82, Y: 0.88, Radius: 0.065},
+			{Kind: Button, Name: "X", X: 0.82, Y: 0.66, Radius: 0.065},
+			{Kind: Button, Name: "Y", X: 0.74, Y: 0.76, Radius: 0.065},
+			{Kind: Button, Name: "L1", X: 0.08, Y: 0.10, Radius: 0.07},
+			{Kind: Button, Name: "R1", X: 0.92, Y: 0.10, Radius: 0.07},
+			{Kind: Button, Name: "SELECT", Label: "SEL", X: 0.43, Y: 0.94, Radius: 0.045},
+			{Kind: Button, Name: "START", Label: "STA", X: 0.57, Y: 0.94, Radius: 0.045},
+		},
+	}
+}
+
+// LoadLayout reads a layout saved with Save.
+func LoadLayout(filename string) (*Layout, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	l := DefaultLayout()
+	l.Controls = nil
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, err
+	}
+	if l.Scale <= 0 {
+		l.Scale = 1
+	}
+	return l, nil
+}
+
+// Save writes the layout as indented JSON.
+func (l *Layout) Save(filename string) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, data, 0644)
+}
@@ -0,0 +1,361 @@
+package vpad
+
+import (
+	"math"
+
+	"github.com/veandco/go-sdl2/gfx"
+	"github.com/veandco/go-sdl2/sdl"
+
+	"go-sdl2/input"
+)
+
+// ID is the instance ID of the touch gamepad. SDL never hands out negative
+// IDs, and input.KeyPadID is -2.
+const ID sdl.JoystickID = -3
+
+// deviceIndex is the device index announced for the touch gamepad.
+const deviceIndex = 1<<16 + 1
+
+// state is what the fingers do to one control.
+type state struct {
+	fingers int     // fingers on it
+	hat     uint8   // d-pad
+	x, y    float32 // stick, -1 to 1
+}
+
+// finger is a touch that started on a control.
+type finger struct {
+	control int
+	x, y    float32 // last position, normalized
+}
+
+// Overlay is an on-screen gamepad.
+type Overlay struct {
+	Layout   *Layout
+	Registry *input.Registry
+
+	editing  bool
+	profile  *input.Profile
+	w, h     int32
+	attached bool
+	states   []state
+	fingers  map[sdl.FingerID]*finger
+}
+
+// New returns an overlay with the default layout for a screen of w x h
+// pixels.
+func New(registry *input.Registry, w, h int32) *Overlay {
+	o := &Overlay{
+		Layout:   DefaultLayout(),
+		Registry: registry,
+		fingers:  make(map[sdl.FingerID]*finger),
+	}
+	if registry != nil && registry.Profiles != nil {
+		o.profile = registry.Profiles.Get("Touch Gamepad")
+	}
+	if o.profile == nil {
+		o.profile = input.NewProfiles().Get("Touch Gamepad")
+	}
+	o.Resize(w, h)
+	return o
+}
+
+// Resize tells the overlay the size of the screen, e.g. on a
+// WINDOWEVENT_SIZE_CHANGED.
+func (o *Overlay) Resize(w, h int32) {
+	o.w, o.h = w, h
+}
+
+// Attached reports whether the touch gamepad is announced.
+func (o *Overlay) Attached() bool {
+	return o.attached
+}
+
+// Attach announces the touch gamepad. handle gets the device event, e.g.
+// input.Push or the input layer of the game.
+func (o *Overlay) Attach(handle func(sdl.Event)) {
+	if o.attached {
+		return
+	}
+	o.Registry.Expect(input.DeviceInfo{Index: deviceIndex, ID: ID, GUID: "touch", Name: o.profile.Name,
+		Buttons: len(o.profile.Buttons), Axes: len(o.profile.Axes), Hats: len(o.profile.Hats)})
+	handle(&sdl.JoyDeviceAddedEvent{Type: sdl.JOYDEVICEADDED, Timestamp: sdl.GetTicks(), Which: deviceIndex})
+	o.attached = true
+}
+
+// Detach releases every control and removes the touch gamepad.
+func (o *Overlay) Detach(handle func(sdl.Event)) {
+	if !o.attached {
+		return
+	}
+	for id := range o.fingers {
+		o.lift(id, handle)
+	}
+	handle(&sdl.JoyDeviceRemovedEvent{Type: sdl.JOYDEVICEREMOVED, Timestamp: sdl.GetTicks(), Which: ID})
+	o.attached = false
+}
+
+// Edit turns the edit mode on or off. While editing, fingers move the
+// controls and pinching resizes them, and no input is sent. Held controls
+// are released first.
+func (o *Overlay) Edit(on bool, handle func(sdl.Event)) {
+	for id := range o.fingers {
+		o.lift(id, handle)
+	}
+	o.editing = on
+}
+
+// Editing reports whether the edit mode is on.
+func (o *Overlay) Editing() bool {
+	return o.editing
+}
+
+// center returns the position and radius of control i in pixels.
+func (o *Overlay) center(i int) (x, y, r float32) {
+	c := o.Layout.Controls[i]
+	return c.X * float32(o.w), c.Y * float32(o.h), c.Radius * o.Layout.Scale * float32(o.h)
+}
+
+// hit returns the control under a normalized position, or -1. A finger
+// slightly off a control still counts, the nearest control wins.
+func (o *Overlay) hit(fx, fy float32) int {
+	best, bestDist := -1, float32(1.25)
+	px, py := fx*float32(o.w), fy*float32(o.h)
+	for i := range o.Layout.Controls {
+		x, y, r := o.center(i)
+		if r <= 0 {
+			continue
+		}
+		d := float32(math.Hypot(float64(px-x), float64(py-y))) / r
+		if d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// HandleEvent turns touch events into gamepad events, or moves and resizes
+// the controls while editing. It reports whether the event was used up;
+// fingers that miss every control are left to the game.
+func (o *Overlay) HandleEvent(event sdl.Event, handle func(sdl.Event)) bool {
+	if len(o.states) != len(o.Layout.Controls) {
+		o.states = make([]state, len(o.Layout.Controls))
+	}
+	switch t := event.(type) {
+	case *sdl.TouchFingerEvent:
+		switch t.Type {
+		case sdl.FINGERDOWN:
+			i := o.hit(t.X, t.Y)
+			if i < 0 {
+				return false
+			}
+			o.fingers[t.FingerID] = &finger{control: i, x: t.X, y: t.Y}
+			o.states[i].fingers++
+			o.update(i, t.X, t.Y, handle)
+			return true
+		case sdl.FINGERMOTION:
+			f, ok := o.fingers[t.FingerID]
+			if !ok {
+				return false
+			}
+			if o.editing {
+				c := &o.Layout.Controls[f.control]
+				c.X = clamp(c.X+t.X-f.x, 0, 1)
+				c.Y = clamp(c.Y+t.Y-f.y, 0, 1)
+			}
+			f.x, f.y = t.X, t.Y
+			o.update(f.control, t.X, t.Y, handle)
+			return true
+		case sdl.FINGERUP:
+			if _, ok := o.fingers[t.FingerID]; !ok {
+				return false
+			}
+			o.lift(t.FingerID, handle)
+			return true
+		}
+	case *sdl.MultiGestureEvent:
+		if o.editing {
+			o.Layout.Scale = clamp(o.Layout.Scale*(1+2*t.DDist), 0.5, 2)
+			return true
+		}
+	}
+	return false
+}
+
+// lift removes a finger from its control.
+func (o *Overlay) lift(id sdl.FingerID, handle func(sdl.Event)) {
+	f := o.fingers[id]
+	delete(o.fingers, id)
+	s := &o.states[f.control]
+	s.fingers--
+	if s.fingers > 0 {
+		return
+	}
+	c := o.Layout.Controls[f.control]
+	switch c.Kind {
+	case Button:
+		o.button(c.Name, false, handle)
+	case DPad:
+		o.hat(s, sdl.HAT_CENTERED, handle)
+	case Stick:
+		o.stick(c.Name, s, 0, 0, handle)
+	}
+}
+
+// update sets control i from a finger at a normalized position.
+func (o *Overlay) update(i int, fx, fy float32, handle func(sdl.Event)) {
+	c := o.Layout.Controls[i]
+	s := &o.states[i]
+	x, y, r := o.center(i)
+	dx := (fx*float32(o.w) - x) / r
+	dy := (fy*float32(o.h) - y) / r
+	switch c.Kind {
+	case Button:
+		if s.fingers == 1 {
+			o.button(c.Name, true, handle)
+		}
+	case DPad:
+		o.hat(s, direction(dx, dy), handle)
+	case Stick:
+		if l := float32(math.Hypot(float64(dx), float64(dy))); l > 1 {
+			dx, dy = dx/l, dy/l
+		}
+		o.stick(c.Name, s, dx, dy, handle)
+	}
+}
+
+// direction returns the hat position of an offset from the d-pad center,
+// in eight directions with a dead zone in the middle.
+func direction(dx, dy float32) uint8 {
+	l := float32(math.Hypot(float64(dx), float64(dy)))
+	if l < 0.2 {
+		return sdl.HAT_CENTERED
+	}
+	const diagonal = 0.38 // sin(22.5°)
+	var v uint8
+	if dy < -diagonal*l {
+		v |= sdl.HAT_UP
+	} else if dy > diagonal*l {
+		v |= sdl.HAT_DOWN
+	}
+	if dx < -diagonal*l {
+		v |= sdl.HAT_LEFT
+	} else if dx > diagonal*l {
+		v |= sdl.HAT_RIGHT
+	}
+	return v
+}
+
+func (o *Overlay) button(name string, down bool, handle func(sdl.Event)) {
+	b := o.profile.Button(name)
+	if b < 0 || o.editing {
+		return
+	}
+	e := &sdl.JoyButtonEvent{Type: sdl.JOYBUTTONUP, Timestamp: sdl.GetTicks(), Which: ID, Button: uint8(b), State: sdl.RELEASED}
+	if down {
+		e.Type, e.State = sdl.JOYBUTTONDOWN, sdl.PRESSED
+	}
+	handle(e)
+}
+
+func (o *Overlay) hat(s *state, v uint8, handle func(sdl.Event)) {
+	if o.editing {
+		v = sdl.HAT_CENTERED
+	}
+	if s.hat == v {
+		return
+	}
+	s.hat = v
+	handle(&sdl.JoyHatEvent{Type: sdl.JOYHATMOTION, Timestamp: sdl.GetTicks(), Which: ID, Hat: 0, Value: v})
+}
+
+func (o *Overlay) stick(name string, s *state, x, y float32, handle func(sdl.Event)) {
+	if o.editing {
+		x, y = 0, 0
+	}
+	now := sdl.GetTicks()
+	if ax := o.profile.Axis(name + "X"); ax >= 0 && x != s.x {
+		handle(&sdl.JoyAxisEvent{Type: sdl.JOYAXISMOTION, Timestamp: now, Which: ID, Axis: uint8(ax), Value: axisValue(x)})
+	}
+	if ay := o.profile.Axis(name + "Y"); ay >= 0 && y != s.y {
+		handle(&sdl.JoyAxisEvent{Type: sdl.JOYAXISMOTION, Timestamp: now, Which: ID, Axis: uint8(ay), Value: axisValue(y)})
+	}
+	s.x, s.y = x, y
+}
+
+func axisValue(v float32) int16 {
+	if v < 0 {
+		return int16(v * 32768)
+	}
+	return int16(v * 32767)
+}
+
+func clamp(v, lo, hi float32) float32 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
+
+// Draw draws the overlay. Held controls are drawn opaque.
+func (o *Overlay) Draw(renderer *sdl.Renderer) {
+	if len(o.states) != len(o.Layout.Controls) {
+		o.states = make([]state, len(o.Layout.Controls))
+	}
+	renderer.SetDrawBlendMode(sdl.BLENDMODE_BLEND)
+	alpha := o.Layout.Opacity
+	held := uint8(255)
+	if o.editing {
+		alpha, held = 160, 160
+	}
+	for i, c := range o.Layout.Controls {
+		s := o.states[i]
+		fx, fy, fr := o.center(i)
+		x, y, r := int32(fx), int32(fy), int32(fr)
+		switch c.Kind {
+		case Button:
+			if s.fingers > 0 {
+				gfx.FilledCircleRGBA(renderer, x, y, r, 0, 200, 0, held)
+			} else {
+				gfx.FilledCircleRGBA(renderer, x, y, r, 128, 128, 128, alpha)
+			}
+			gfx.AACircleRGBA(renderer, x, y, r, 255, 255, 255, alpha)
+			label := c.Label
+			if label == "" {
+				label = c.Name
+			}
+			gfx.StringRGBA(renderer, x-int32(4*len(label)), y-4, label, 255, 255, 255, held)
+		case DPad:
+			w := r / 3
+			gfx.BoxRGBA(renderer, x-w, y-r, x+w, y+r, 128, 128, 128, alpha)
+			gfx.BoxRGBA(renderer, x-r, y-w, x-w, y+w, 128, 128, 128, alpha)
+			gfx.BoxRGBA(renderer, x+w, y-w, x+r, y+w, 128, 128, 128, alpha)
+			arms := []struct {
+				dir            uint8
+				x1, y1, x2, y2 int32
+			}{
+				{sdl.HAT_UP, x - w, y - r, x + w, y - w},
+				{sdl.HAT_DOWN, x - w, y + w, x + w, y + r},
+				{sdl.HAT_LEFT, x - r, y - w, x - w, y + w},
+				{sdl.HAT_RIGHT, x + w, y - w, x + r, y + w},
+			}
+			for _, a := range arms {
+				if s.hat&a.dir != 0 {
+					gfx.BoxRGBA(renderer, a.x1, a.y1, a.x2, a.y2, 0, 200, 0, held)
+				}
+			}
+		case Stick:
+			gfx.FilledCircleRGBA(renderer, x, y, r, 128, 128, 128, alpha/2)
+			gfx.AACircleRGBA(renderer, x, y, r, 255, 255, 255, alpha)
+			kx, ky := x+int32(s.x*fr), y+int32(s.y*fr)
+			if s.fingers > 0 {
+				gfx.FilledCircleRGBA(renderer, kx, ky, r/2, 0, 200, 0, held)
+			} else {
+				gfx.FilledCircleRGBA(renderer, kx, ky, r/2, 200, 200, 200, alpha)
+			}
+		}
+	}
+}